@@ -14,9 +14,11 @@ import (
 	"github.com/omarshah0/rest-api-with-social-auth/internal/config"
 	"github.com/omarshah0/rest-api-with-social-auth/internal/database"
 	"github.com/omarshah0/rest-api-with-social-auth/internal/handlers"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/marketdata"
 	"github.com/omarshah0/rest-api-with-social-auth/internal/middleware"
 	"github.com/omarshah0/rest-api-with-social-auth/internal/repositories"
 	"github.com/omarshah0/rest-api-with-social-auth/internal/services"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/storage"
 )
 
 func main() {
@@ -52,7 +54,13 @@ func main() {
 	userRepo := repositories.NewUserRepository(postgresDB.DB)
 	adminRepo := repositories.NewAdminRepository(postgresDB.DB)
 	oauthProviderRepo := repositories.NewOAuthProviderRepository(postgresDB.DB)
-	tradingSignalRepo := repositories.NewTradingSignalRepository(postgresDB.DB)
+	tradingSignalRepo := repositories.NewTradingSignalRepository(postgresDB.DB, cfg.Visibility.PremiumEmbargo, cfg.Visibility.TierReleaseDelay)
+	notificationOutboxRepo := repositories.NewNotificationOutboxRepository(postgresDB.DB)
+	pushTokenRepo := repositories.NewPushTokenRepository(postgresDB.DB)
+	notificationPreferenceRepo := repositories.NewNotificationPreferenceRepository(postgresDB.DB)
+	notificationDigestRepo := repositories.NewNotificationDigestRepository(postgresDB.DB)
+	telegramLinkRepo := repositories.NewTelegramLinkRepository(postgresDB.DB)
+	notificationTemplateRepo := repositories.NewNotificationTemplateRepository(postgresDB.DB)
 	// logRepo := repositories.NewLogRepository(mongoDB.Database)
 
 	// Initialize services
@@ -80,26 +88,90 @@ func main() {
 		cfg.OAuth.Facebook.RedirectURL,
 		cfg.OAuth.Facebook.Enabled,
 	)
-	notificationService := services.NewNotificationService(
-		cfg.Notifications.TelegramEnabled,
-		cfg.Notifications.TelegramBotToken,
-		cfg.Notifications.TelegramChatID,
-		cfg.Notifications.DiscordEnabled,
-		cfg.Notifications.DiscordWebhookURL,
-		cfg.Notifications.ExpoEnabled,
-	)
+	userNotificationService := services.NewUserNotificationService(&cfg.Notifications, notificationPreferenceRepo, notificationDigestRepo, pushTokenRepo, telegramLinkRepo, emailService)
+	notificationTemplateService := services.NewNotificationTemplateService(notificationTemplateRepo)
+	notificationService := services.NewNotificationService(&cfg.Notifications, userNotificationService, notificationTemplateService)
+	notificationOutboxService := services.NewNotificationOutboxService(notificationOutboxRepo, notificationService, &cfg.Notifications)
 	authService := services.NewAuthService(userRepo, oauthProviderRepo, adminRepo, jwtService, oauthService, passwordService, emailService)
-	tradingSignalService := services.NewTradingSignalService(tradingSignalRepo, notificationService)
+	instrumentRepo := repositories.NewInstrumentRepository(postgresDB.DB)
+	instrumentService := services.NewInstrumentService(instrumentRepo)
+	statsCache := services.NewStatsCache(redisDB, cfg.Stats.CacheTTL)
+	packageRepo := repositories.NewPackageRepository(postgresDB.DB)
+	tradingSignalService := services.NewTradingSignalService(tradingSignalRepo, packageRepo, instrumentService, &cfg.SignalRules, &cfg.Visibility, statsCache)
+
+	if cfg.Instruments.SeedFile != "" {
+		seedFile, err := os.Open(cfg.Instruments.SeedFile)
+		if err != nil {
+			log.Fatalf("Failed to open instrument seed file: %v", err)
+		}
+		result, err := instrumentService.SeedCSV(seedFile)
+		seedFile.Close()
+		if err != nil {
+			log.Fatalf("Failed to seed instruments: %v", err)
+		}
+		log.Printf("Seeded instruments from %s: %d created, %d already listed, %d invalid rows",
+			cfg.Instruments.SeedFile, result.Created, result.Skipped, len(result.Errors))
+		for _, rowErr := range result.Errors {
+			log.Printf("Instrument seed line %d (%s): %s", rowErr.Line, rowErr.Symbol, rowErr.Message)
+		}
+	}
 
 	// New repositories
-	packageRepo := repositories.NewPackageRepository(postgresDB.DB)
 	subscriptionRepo := repositories.NewSubscriptionRepository(postgresDB.DB)
 	paymentRepo := repositories.NewPaymentRepository(postgresDB.DB)
+	statsRepo := repositories.NewStatsRepository(postgresDB.DB)
+	packageChannelRepo := repositories.NewPackageChannelRepository(postgresDB.DB)
+	discordLinkRepo := repositories.NewDiscordLinkRepository(postgresDB.DB)
+	channelMembershipRepo := repositories.NewChannelMembershipRepository(postgresDB.DB)
 
 	// New services
 	packageService := services.NewPackageService(packageRepo)
-	subscriptionService := services.NewSubscriptionService(subscriptionRepo, packageRepo, paymentRepo, emailService, userRepo)
+	channelMembershipService := services.NewChannelMembershipService(&cfg.Memberships, &cfg.Notifications, channelMembershipRepo, packageChannelRepo, packageRepo, subscriptionRepo)
+	discordLinkService := services.NewDiscordLinkService(&cfg.Memberships, discordLinkRepo, channelMembershipService)
+	subscriptionService := services.NewSubscriptionService(subscriptionRepo, packageRepo, paymentRepo, emailService, userRepo, channelMembershipService)
 	paymentService := services.NewPaymentService(paymentRepo, packageRepo)
+	statsService := services.NewStatsService(statsRepo, packageRepo, statsCache)
+	pushTokenService := services.NewPushTokenService(pushTokenRepo)
+	notificationPreferenceService := services.NewNotificationPreferenceService(notificationPreferenceRepo)
+	telegramBotService := services.NewTelegramBotService(&cfg.Notifications, telegramLinkRepo, userRepo, subscriptionService, tradingSignalService, channelMembershipService)
+
+	attachmentStorage, err := storage.NewLocalStorage(cfg.Attachments.StorageDir)
+	if err != nil {
+		log.Fatalf("Failed to initialize attachment storage: %v", err)
+	}
+	attachmentRepo := repositories.NewAttachmentRepository(postgresDB.DB)
+	attachmentService := services.NewAttachmentService(attachmentRepo, tradingSignalRepo, attachmentStorage, &cfg.Attachments)
+
+	// Background workers
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	defer stopWorkers()
+
+	signalPublisher := services.NewSignalPublisher(tradingSignalService, cfg.Scheduler.PublishInterval)
+	go signalPublisher.Start(workerCtx)
+
+	notificationDispatcher := services.NewNotificationDispatcher(notificationOutboxService, userNotificationService, cfg.Notifications.OutboxInterval)
+	go notificationDispatcher.Start(workerCtx)
+
+	membershipReconciler := services.NewMembershipReconciler(channelMembershipService, cfg.Memberships.ReconcileInterval)
+	go membershipReconciler.Start(workerCtx)
+
+	if cfg.Notifications.ExpoEnabled {
+		expoService := services.NewExpoNotificationService(pushTokenRepo, cfg.Notifications.ExpoAPIURL, cfg.Notifications.ExpoAccessToken)
+		expoReceiptChecker := services.NewExpoReceiptChecker(expoService, cfg.Notifications.ExpoReceiptInterval)
+		go expoReceiptChecker.Start(workerCtx)
+	}
+
+	if cfg.MarketData.Feed == "replay" {
+		replayFeed, err := marketdata.NewReplayFeedFromFile(cfg.MarketData.ReplayFile, cfg.MarketData.ReplaySpeed)
+		if err != nil {
+			log.Fatalf("Failed to load market data replay file: %v", err)
+		}
+		signalResolver := services.NewSignalResolver(tradingSignalService, replayFeed, cfg.MarketData.ResolverInterval)
+		go signalResolver.Start(workerCtx)
+	} else {
+		signalExpirer := services.NewSignalExpirer(tradingSignalService, cfg.Scheduler.ExpiryInterval)
+		go signalExpirer.Start(workerCtx)
+	}
 
 	// Initialize middleware
 	authMiddleware := middleware.NewAuthMiddleware(jwtService)
@@ -111,11 +183,20 @@ func main() {
 	healthHandler := handlers.NewHealthHandler(postgresDB, mongoDB, redisDB)
 	authHandler := handlers.NewAuthHandler(authService, oauthService, cfg.Cookie)
 	emailAuthHandler := handlers.NewEmailAuthHandler(authService, cfg.Cookie)
-	tradingSignalHandler := handlers.NewTradingSignalHandler(tradingSignalService)
+	tradingSignalHandler := handlers.NewTradingSignalHandler(tradingSignalService, attachmentService)
 	profileHandler := handlers.NewProfileHandler(authService)
 	packageHandler := handlers.NewPackageHandler(packageService)
 	subscriptionHandler := handlers.NewSubscriptionHandler(subscriptionService)
 	paymentHandler := handlers.NewPaymentHandler(paymentService)
+	statsHandler := handlers.NewStatsHandler(statsService)
+	instrumentHandler := handlers.NewInstrumentHandler(instrumentService)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentService, tradingSignalService, cfg.Attachments.MaxBytes)
+	notificationHandler := handlers.NewNotificationHandler(notificationOutboxService)
+	notificationTemplateHandler := handlers.NewNotificationTemplateHandler(notificationTemplateService)
+	pushTokenHandler := handlers.NewPushTokenHandler(pushTokenService)
+	notificationPreferenceHandler := handlers.NewNotificationPreferenceHandler(notificationPreferenceService)
+	telegramHandler := handlers.NewTelegramHandler(telegramBotService)
+	channelMembershipHandler := handlers.NewChannelMembershipHandler(channelMembershipService, discordLinkService)
 
 	// Setup router
 	router := mux.NewRouter()
@@ -133,6 +214,12 @@ func main() {
 	// Health check endpoint (no auth required)
 	router.HandleFunc("/health", healthHandler.HealthCheck).Methods("GET", "OPTIONS")
 
+	// Signal attachment images (authorized by the signed URL, no auth header)
+	router.HandleFunc("/media/attachments/{id}/{variant}", attachmentHandler.Serve).Methods("GET")
+
+	// Telegram bot updates (authorized by the webhook secret, no auth header)
+	router.HandleFunc("/telegram/webhook", telegramHandler.Webhook).Methods("POST")
+
 	// Auth routes
 	authRouter := router.PathPrefix("/auth").Subrouter()
 
@@ -166,6 +253,14 @@ func main() {
 
 	// Profile route
 	apiRouter.HandleFunc("/profile", profileHandler.GetProfile).Methods("GET")
+	apiRouter.HandleFunc("/profile/notification-preferences", notificationPreferenceHandler.Get).Methods("GET")
+	apiRouter.HandleFunc("/profile/notification-preferences", notificationPreferenceHandler.Update).Methods("PUT")
+	apiRouter.HandleFunc("/profile/telegram", telegramHandler.GetLink).Methods("GET")
+	apiRouter.HandleFunc("/profile/telegram", telegramHandler.Unlink).Methods("DELETE")
+	apiRouter.HandleFunc("/profile/telegram/link", telegramHandler.CreateLinkToken).Methods("POST")
+	apiRouter.HandleFunc("/profile/discord", channelMembershipHandler.GetDiscordLink).Methods("GET")
+	apiRouter.HandleFunc("/profile/discord", channelMembershipHandler.LinkDiscord).Methods("POST")
+	apiRouter.HandleFunc("/profile/discord", channelMembershipHandler.UnlinkDiscord).Methods("DELETE")
 
 	// Packages routes (public - authenticated users can view)
 	apiRouter.HandleFunc("/packages", packageHandler.GetAll).Methods("GET")
@@ -177,13 +272,28 @@ func main() {
 	apiRouter.HandleFunc("/subscriptions/history", subscriptionHandler.GetHistory).Methods("GET")
 	apiRouter.HandleFunc("/subscriptions/check-access", subscriptionHandler.CheckAccess).Methods("POST")
 
+	// Push token routes (mobile app devices)
+	apiRouter.HandleFunc("/push-tokens", pushTokenHandler.Register).Methods("POST")
+	apiRouter.HandleFunc("/push-tokens/{deviceId}", pushTokenHandler.Unregister).Methods("DELETE")
+
+	// Private channel membership routes
+	apiRouter.HandleFunc("/channel-memberships", channelMembershipHandler.GetMemberships).Methods("GET")
+	apiRouter.HandleFunc("/channel-memberships/{id}/invite", channelMembershipHandler.Invite).Methods("POST")
+
 	// Payment routes (authenticated users)
 	apiRouter.HandleFunc("/payments/history", paymentHandler.GetHistory).Methods("GET")
 
+	// Performance statistics
+	apiRouter.HandleFunc("/stats/performance", statsHandler.GetPerformance).Methods("GET")
+	apiRouter.HandleFunc("/stats/equity", statsHandler.GetEquityCurve).Methods("GET")
+
 	// Trading signals routes (authenticated users - filtered by subscription)
 	signalsRouter := apiRouter.PathPrefix("/trading-signals").Subrouter()
 	signalsRouter.HandleFunc("", tradingSignalHandler.GetAll).Methods("GET")
 	signalsRouter.HandleFunc("/{id}", tradingSignalHandler.GetByID).Methods("GET")
+	signalsRouter.HandleFunc("/{id}/history", tradingSignalHandler.GetHistory).Methods("GET")
+	signalsRouter.HandleFunc("/{id}/updates", tradingSignalHandler.GetUpdates).Methods("GET")
+	signalsRouter.HandleFunc("/{id}/attachments", attachmentHandler.GetAll).Methods("GET")
 
 	// Admin routes
 	adminRouter := apiRouter.PathPrefix("/admin").Subrouter()
@@ -193,16 +303,49 @@ func main() {
 	adminRouter.HandleFunc("/trading-signals", tradingSignalHandler.GetAllAdmin).Methods("GET")
 	adminRouter.HandleFunc("/trading-signals", tradingSignalHandler.Create).Methods("POST")
 	adminRouter.HandleFunc("/trading-signals/{id}", tradingSignalHandler.Update).Methods("PUT")
+	adminRouter.HandleFunc("/trading-signals/{id}/status", tradingSignalHandler.UpdateStatus).Methods("POST")
+	adminRouter.HandleFunc("/trading-signals/{id}/targets/{position}/hit", tradingSignalHandler.MarkTargetHit).Methods("POST")
+	adminRouter.HandleFunc("/trading-signals/{id}/updates", tradingSignalHandler.PostUpdate).Methods("POST")
 	adminRouter.HandleFunc("/trading-signals/{id}", tradingSignalHandler.Delete).Methods("DELETE")
+	adminRouter.HandleFunc("/trading-signals/{id}/attachments", attachmentHandler.GetAllAdmin).Methods("GET")
+	adminRouter.HandleFunc("/trading-signals/{id}/attachments", attachmentHandler.Upload).Methods("POST")
+	adminRouter.HandleFunc("/trading-signals/{id}/attachments/{attachmentId}", attachmentHandler.Delete).Methods("DELETE")
 
 	// Admin - Packages
 	adminRouter.HandleFunc("/packages", packageHandler.Create).Methods("POST")
 	adminRouter.HandleFunc("/packages/{id}", packageHandler.Update).Methods("PUT")
 	adminRouter.HandleFunc("/packages/{id}", packageHandler.Delete).Methods("DELETE")
+	adminRouter.HandleFunc("/packages/{id}/channels", channelMembershipHandler.GetPackageChannels).Methods("GET")
+	adminRouter.HandleFunc("/packages/{id}/channels", channelMembershipHandler.AddPackageChannel).Methods("POST")
+	adminRouter.HandleFunc("/packages/{id}/channels/{channelId}", channelMembershipHandler.RemovePackageChannel).Methods("DELETE")
 
 	// Admin - Payments
 	adminRouter.HandleFunc("/payments", paymentHandler.RecordPayment).Methods("POST")
 
+	// Admin - Instruments
+	adminRouter.HandleFunc("/instruments", instrumentHandler.GetAll).Methods("GET")
+	adminRouter.HandleFunc("/instruments", instrumentHandler.Create).Methods("POST")
+	adminRouter.HandleFunc("/instruments/import", instrumentHandler.Import).Methods("POST")
+	adminRouter.HandleFunc("/instruments/{id}", instrumentHandler.GetByID).Methods("GET")
+	adminRouter.HandleFunc("/instruments/{id}", instrumentHandler.Update).Methods("PUT")
+	adminRouter.HandleFunc("/instruments/{id}", instrumentHandler.Delete).Methods("DELETE")
+
+	// Admin - Notification outbox
+	adminRouter.HandleFunc("/notifications/deliveries", notificationHandler.GetDeliveries).Methods("GET")
+	adminRouter.HandleFunc("/notifications/deliveries/{id}/retry", notificationHandler.RetryDelivery).Methods("POST")
+
+	// Admin - Notification templates
+	adminRouter.HandleFunc("/notification-templates", notificationTemplateHandler.GetAll).Methods("GET")
+	adminRouter.HandleFunc("/notification-templates/preview", notificationTemplateHandler.Preview).Methods("POST")
+	adminRouter.HandleFunc("/notification-templates/validate", notificationTemplateHandler.Validate).Methods("POST")
+	adminRouter.HandleFunc("/notification-templates/{channel}/{event}", notificationTemplateHandler.Get).Methods("GET")
+	adminRouter.HandleFunc("/notification-templates/{channel}/{event}", notificationTemplateHandler.Save).Methods("PUT")
+	adminRouter.HandleFunc("/notification-templates/{channel}/{event}", notificationTemplateHandler.Delete).Methods("DELETE")
+
+	// Admin - Channel memberships
+	adminRouter.HandleFunc("/channel-memberships/reconcile", channelMembershipHandler.Reconcile).Methods("POST")
+	adminRouter.HandleFunc("/channel-memberships/events", channelMembershipHandler.GetEvents).Methods("GET")
+
 	// Create HTTP server
 	srv := &http.Server{
 		Addr:         ":" + cfg.Server.Port,
@@ -226,6 +369,7 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopWorkers()
 
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
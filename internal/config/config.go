@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
 )
 
 type Config struct {
@@ -21,6 +22,14 @@ type Config struct {
 	Cookie        CookieConfig
 	Notifications NotificationConfig
 	Subscription  SubscriptionConfig
+	MarketData    MarketDataConfig
+	SignalRules   SignalRulesConfig
+	Stats         StatsConfig
+	Instruments   InstrumentsConfig
+	Scheduler     SchedulerConfig
+	Visibility    VisibilityConfig
+	Attachments   AttachmentsConfig
+	Memberships   MembershipConfig
 }
 
 type ServerConfig struct {
@@ -91,6 +100,117 @@ type NotificationConfig struct {
 	DiscordEnabled    bool
 	DiscordWebhookURL string
 	ExpoEnabled       bool
+	EmailEnabled      bool // Signal notifications by email, for users who choose it
+
+	// Expo push API; point ExpoAPIURL at a local stub in tests
+	ExpoAPIURL          string
+	ExpoAccessToken     string        // Only needed with enhanced push security enabled
+	ExpoReceiptInterval time.Duration // How often push receipts are checked for unregistered devices
+
+	// Telegram Bot API; point TelegramAPIURL at a local stub in tests
+	TelegramAPIURL        string
+	TelegramBotUsername   string        // Users link their account through a deep link to the bot
+	TelegramWebhookSecret string        // Sent by Telegram with every update, as set with setWebhook
+	TelegramLinkTTL       time.Duration // How long a link token can be used
+
+	// Channels of the VIP tier, which hear of new signals before the channels above
+	TelegramVIPChatID    string
+	DiscordVIPWebhookURL string
+
+	// Signal events each channel receives (created, updated, target_hit, closed,
+	// cancelled, expired); every event when empty
+	TelegramEvents []string
+	DiscordEvents  []string
+	ExpoEvents     []string
+	EmailEvents    []string
+
+	// Delivery of the notification outbox
+	OutboxInterval time.Duration // How often queued notifications are delivered
+	RetryBaseDelay time.Duration // Wait after the first failed attempt, doubled after each further one
+	RetryMaxDelay  time.Duration // Longest wait between attempts
+	MaxAttempts    int           // Attempts before a delivery is dead-lettered
+}
+
+// TelegramBotEnabled reports whether users can link their Telegram account to the
+// bot and receive signals as direct messages
+func (c *NotificationConfig) TelegramBotEnabled() bool {
+	return c.TelegramEnabled && c.TelegramBotToken != "" && c.TelegramBotUsername != ""
+}
+
+type MarketDataConfig struct {
+	Feed             string // "" disables automatic resolution, "replay" replays a local file
+	ReplayFile       string
+	ReplaySpeed      float64
+	ResolverInterval time.Duration
+}
+
+type SignalRulesConfig struct {
+	// RiskReward holds the accepted risk-reward range keyed by asset class (FOREX, CRYPTO, PSX)
+	RiskReward map[string]RiskRewardBounds
+}
+
+// RiskRewardBounds limits the reward-to-risk ratio of new signals; 0 disables a bound
+type RiskRewardBounds struct {
+	Min float64
+	Max float64
+}
+
+type VisibilityConfig struct {
+	// PremiumEmbargo holds how long after publication premium signals become visible
+	// to all users, keyed by asset class and duration (e.g. FOREX_SHORT_TERM); 0 keeps
+	// them subscriber-only
+	PremiumEmbargo map[string]time.Duration
+
+	// TierReleaseDelay holds how long after publication subscribers of each package
+	// tier (VIP, STANDARD) see a premium signal and are notified of it
+	TierReleaseDelay map[string]time.Duration
+}
+
+// ReleaseDelay returns the release delay of a package tier, 0 if none is configured
+func (c *VisibilityConfig) ReleaseDelay(tier string) time.Duration {
+	return c.TierReleaseDelay[tier]
+}
+
+type AttachmentsConfig struct {
+	StorageDir    string        // Root directory of the local attachment storage
+	MaxBytes      int           // Largest accepted upload
+	ThumbnailSize int           // Longest side of generated thumbnails, in pixels
+	URLSecret     string        // Signs attachment URLs, defaults to JWT_ACCESS_SECRET
+	URLTTL        time.Duration // How long signed attachment URLs stay valid
+}
+
+type MembershipConfig struct {
+	ReconcileInterval time.Duration // How often private chat and role memberships are reconciled with subscriptions
+	InviteTTL         time.Duration // How long invite links stay valid, at most 7 days
+
+	// Discord bot managing the roles of the guild; point DiscordAPIURL at a local stub in tests
+	DiscordAPIURL          string
+	DiscordBotToken        string
+	DiscordGuildID         string
+	DiscordInviteChannelID string // Channel guild invites lead to
+
+	// Discord OAuth app users link their Discord account with
+	DiscordClientID     string
+	DiscordClientSecret string
+	DiscordRedirectURL  string
+}
+
+// DiscordEnabled reports whether Discord roles follow subscriptions
+func (c *MembershipConfig) DiscordEnabled() bool {
+	return c.DiscordBotToken != "" && c.DiscordGuildID != "" && c.DiscordClientID != ""
+}
+
+type SchedulerConfig struct {
+	PublishInterval time.Duration // How often scheduled signals are checked for publication
+	ExpiryInterval  time.Duration // How often signals past valid_until are expired when no price feed is configured
+}
+
+type InstrumentsConfig struct {
+	SeedFile string // CSV whose missing instruments are added at startup; "" disables seeding
+}
+
+type StatsConfig struct {
+	CacheTTL time.Duration
 }
 
 type SubscriptionConfig struct {
@@ -173,10 +293,99 @@ func Load() (*Config, error) {
 			DiscordEnabled:    getEnvBool("DISCORD_NOTIFICATIONS_ENABLED", false),
 			DiscordWebhookURL: getEnv("DISCORD_WEBHOOK_URL", ""),
 			ExpoEnabled:       getEnvBool("EXPO_NOTIFICATIONS_ENABLED", false),
+			EmailEnabled:      getEnvBool("EMAIL_NOTIFICATIONS_ENABLED", false),
+
+			ExpoAPIURL:          getEnv("EXPO_API_URL", "https://exp.host"),
+			ExpoAccessToken:     getEnv("EXPO_ACCESS_TOKEN", ""),
+			ExpoReceiptInterval: getEnvDuration("EXPO_RECEIPT_INTERVAL", 5*time.Minute),
+
+			TelegramAPIURL:        getEnv("TELEGRAM_API_URL", "https://api.telegram.org"),
+			TelegramBotUsername:   getEnv("TELEGRAM_BOT_USERNAME", ""),
+			TelegramWebhookSecret: getEnv("TELEGRAM_WEBHOOK_SECRET", ""),
+			TelegramLinkTTL:       getEnvDuration("TELEGRAM_LINK_TTL", 15*time.Minute),
+
+			TelegramVIPChatID:    getEnv("TELEGRAM_VIP_CHAT_ID", ""),
+			DiscordVIPWebhookURL: getEnv("DISCORD_VIP_WEBHOOK_URL", ""),
+
+			TelegramEvents: getEnvArray("TELEGRAM_NOTIFICATION_EVENTS", nil),
+			DiscordEvents:  getEnvArray("DISCORD_NOTIFICATION_EVENTS", nil),
+			ExpoEvents:     getEnvArray("EXPO_NOTIFICATION_EVENTS", nil),
+			EmailEvents:    getEnvArray("EMAIL_NOTIFICATION_EVENTS", nil),
+
+			OutboxInterval: getEnvDuration("NOTIFICATION_OUTBOX_INTERVAL", 5*time.Second),
+			RetryBaseDelay: getEnvDuration("NOTIFICATION_RETRY_BASE_DELAY", 30*time.Second),
+			RetryMaxDelay:  getEnvDuration("NOTIFICATION_RETRY_MAX_DELAY", time.Hour),
+			MaxAttempts:    getEnvInt("NOTIFICATION_MAX_ATTEMPTS", 8),
 		},
 		Subscription: SubscriptionConfig{
 			DefaultExpiryDays: getEnvInt("SUBSCRIPTION_DEFAULT_EXPIRY_DAYS", 30),
 		},
+		MarketData: MarketDataConfig{
+			Feed:             getEnv("MARKET_DATA_FEED", ""),
+			ReplayFile:       getEnv("MARKET_DATA_REPLAY_FILE", ""),
+			ReplaySpeed:      getEnvFloat("MARKET_DATA_REPLAY_SPEED", 1),
+			ResolverInterval: getEnvDuration("SIGNAL_RESOLVER_INTERVAL", 30*time.Second),
+		},
+		SignalRules: SignalRulesConfig{
+			RiskReward: map[string]RiskRewardBounds{
+				"FOREX": {
+					Min: getEnvFloat("FOREX_MIN_RISK_REWARD", 0),
+					Max: getEnvFloat("FOREX_MAX_RISK_REWARD", 0),
+				},
+				"CRYPTO": {
+					Min: getEnvFloat("CRYPTO_MIN_RISK_REWARD", 0),
+					Max: getEnvFloat("CRYPTO_MAX_RISK_REWARD", 0),
+				},
+				"PSX": {
+					Min: getEnvFloat("PSX_MIN_RISK_REWARD", 0),
+					Max: getEnvFloat("PSX_MAX_RISK_REWARD", 0),
+				},
+			},
+		},
+		Visibility: VisibilityConfig{
+			PremiumEmbargo: map[string]time.Duration{
+				"FOREX_SHORT_TERM":  getEnvDuration("FOREX_SHORT_TERM_EMBARGO", 0),
+				"FOREX_LONG_TERM":   getEnvDuration("FOREX_LONG_TERM_EMBARGO", 0),
+				"CRYPTO_SHORT_TERM": getEnvDuration("CRYPTO_SHORT_TERM_EMBARGO", 0),
+				"CRYPTO_LONG_TERM":  getEnvDuration("CRYPTO_LONG_TERM_EMBARGO", 0),
+				"PSX_SHORT_TERM":    getEnvDuration("PSX_SHORT_TERM_EMBARGO", 0),
+				"PSX_LONG_TERM":     getEnvDuration("PSX_LONG_TERM_EMBARGO", 0),
+			},
+			TierReleaseDelay: map[string]time.Duration{
+				"VIP":      getEnvDuration("VIP_RELEASE_DELAY", 0),
+				"STANDARD": getEnvDuration("STANDARD_RELEASE_DELAY", 0),
+			},
+		},
+		Attachments: AttachmentsConfig{
+			StorageDir:    getEnv("ATTACHMENT_STORAGE_DIR", "./uploads"),
+			MaxBytes:      getEnvInt("ATTACHMENT_MAX_BYTES", 5<<20),
+			ThumbnailSize: getEnvInt("ATTACHMENT_THUMBNAIL_SIZE", 320),
+			URLSecret:     getEnv("ATTACHMENT_URL_SECRET", getEnv("JWT_ACCESS_SECRET", "")),
+			URLTTL:        getEnvDuration("ATTACHMENT_URL_TTL", 5*time.Minute),
+		},
+		Memberships: MembershipConfig{
+			ReconcileInterval: getEnvDuration("MEMBERSHIP_RECONCILE_INTERVAL", 10*time.Minute),
+			InviteTTL:         getEnvDuration("MEMBERSHIP_INVITE_TTL", 24*time.Hour),
+
+			DiscordAPIURL:          getEnv("DISCORD_API_URL", "https://discord.com/api/v10"),
+			DiscordBotToken:        getEnv("DISCORD_BOT_TOKEN", ""),
+			DiscordGuildID:         getEnv("DISCORD_GUILD_ID", ""),
+			DiscordInviteChannelID: getEnv("DISCORD_INVITE_CHANNEL_ID", ""),
+
+			DiscordClientID:     getEnv("DISCORD_CLIENT_ID", ""),
+			DiscordClientSecret: getEnv("DISCORD_CLIENT_SECRET", ""),
+			DiscordRedirectURL:  getEnv("DISCORD_REDIRECT_URL", ""),
+		},
+		Scheduler: SchedulerConfig{
+			PublishInterval: getEnvDuration("SIGNAL_PUBLISH_INTERVAL", 15*time.Second),
+			ExpiryInterval:  getEnvDuration("SIGNAL_EXPIRY_INTERVAL", time.Minute),
+		},
+		Instruments: InstrumentsConfig{
+			SeedFile: getEnv("INSTRUMENTS_SEED_FILE", ""),
+		},
+		Stats: StatsConfig{
+			CacheTTL: getEnvDuration("STATS_CACHE_TTL", 15*time.Minute),
+		},
 		Auth: AuthConfig{
 			EmailPasswordEnabled:     getEnvBool("EMAIL_PASSWORD_AUTH_ENABLED", false),
 			RequireEmailVerification: getEnvBool("REQUIRE_EMAIL_VERIFICATION", true),
@@ -207,6 +416,57 @@ func (c *Config) Validate() error {
 	if c.OAuth.Facebook.Enabled && (c.OAuth.Facebook.ClientID == "" || c.OAuth.Facebook.ClientSecret == "") {
 		return fmt.Errorf("Facebook OAuth is enabled but credentials are missing")
 	}
+	if c.MarketData.Feed == "replay" && c.MarketData.ReplayFile == "" {
+		return fmt.Errorf("MARKET_DATA_REPLAY_FILE is required for the replay feed")
+	}
+	for category, embargo := range c.Visibility.PremiumEmbargo {
+		if embargo < 0 {
+			return fmt.Errorf("%s_EMBARGO must not be negative", category)
+		}
+	}
+	if c.Attachments.MaxBytes <= 0 || c.Attachments.ThumbnailSize <= 0 || c.Attachments.URLTTL <= 0 {
+		return fmt.Errorf("ATTACHMENT_MAX_BYTES, ATTACHMENT_THUMBNAIL_SIZE and ATTACHMENT_URL_TTL must be positive")
+	}
+	if c.Memberships.ReconcileInterval <= 0 || c.Memberships.InviteTTL <= 0 || c.Memberships.InviteTTL > 7*24*time.Hour {
+		return fmt.Errorf("MEMBERSHIP_RECONCILE_INTERVAL must be positive and MEMBERSHIP_INVITE_TTL between 0 and 168h")
+	}
+	if c.Memberships.DiscordEnabled() && (c.Memberships.DiscordAPIURL == "" || c.Memberships.DiscordInviteChannelID == "" || c.Memberships.DiscordClientSecret == "" || c.Memberships.DiscordRedirectURL == "") {
+		return fmt.Errorf("DISCORD_API_URL, DISCORD_INVITE_CHANNEL_ID, DISCORD_CLIENT_SECRET and DISCORD_REDIRECT_URL are required when Discord roles are managed")
+	}
+	for tier, delay := range c.Visibility.TierReleaseDelay {
+		if delay < 0 {
+			return fmt.Errorf("%s_RELEASE_DELAY must not be negative", tier)
+		}
+	}
+	for channel, events := range map[string][]string{
+		"TELEGRAM": c.Notifications.TelegramEvents,
+		"DISCORD":  c.Notifications.DiscordEvents,
+		"EXPO":     c.Notifications.ExpoEvents,
+		"EMAIL":    c.Notifications.EmailEvents,
+	} {
+		for _, event := range events {
+			if !models.NotificationEvent(strings.TrimSpace(event)).IsValid() {
+				return fmt.Errorf("unknown event %q in %s_NOTIFICATION_EVENTS", event, channel)
+			}
+		}
+	}
+	if c.Notifications.ExpoEnabled && (c.Notifications.ExpoAPIURL == "" || c.Notifications.ExpoReceiptInterval <= 0) {
+		return fmt.Errorf("EXPO_API_URL is required and EXPO_RECEIPT_INTERVAL must be positive when Expo notifications are enabled")
+	}
+	if c.Notifications.TelegramEnabled && c.Notifications.TelegramAPIURL == "" {
+		return fmt.Errorf("TELEGRAM_API_URL is required when Telegram notifications are enabled")
+	}
+	if c.Notifications.TelegramBotEnabled() && (c.Notifications.TelegramWebhookSecret == "" || c.Notifications.TelegramLinkTTL <= 0) {
+		return fmt.Errorf("TELEGRAM_WEBHOOK_SECRET is required and TELEGRAM_LINK_TTL must be positive when TELEGRAM_BOT_USERNAME is set")
+	}
+	if c.Notifications.OutboxInterval <= 0 || c.Notifications.RetryBaseDelay <= 0 || c.Notifications.RetryMaxDelay <= 0 || c.Notifications.MaxAttempts <= 0 {
+		return fmt.Errorf("NOTIFICATION_OUTBOX_INTERVAL, NOTIFICATION_RETRY_BASE_DELAY, NOTIFICATION_RETRY_MAX_DELAY and NOTIFICATION_MAX_ATTEMPTS must be positive")
+	}
+	for assetClass, bounds := range c.SignalRules.RiskReward {
+		if bounds.Min < 0 || bounds.Max < 0 || (bounds.Max > 0 && bounds.Min > bounds.Max) {
+			return fmt.Errorf("invalid risk-reward bounds for %s: min %.2f, max %.2f", assetClass, bounds.Min, bounds.Max)
+		}
+	}
 	return nil
 }
 
@@ -228,6 +488,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		if boolValue, err := strconv.ParseBool(value); err == nil {
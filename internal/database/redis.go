@@ -77,3 +77,8 @@ func (r *RedisDB) Delete(ctx context.Context, keys ...string) error {
 func (r *RedisDB) Exists(ctx context.Context, keys ...string) (int64, error) {
 	return r.Client.Exists(ctx, keys...).Result()
 }
+
+// Incr increments the integer value of a key by one
+func (r *RedisDB) Incr(ctx context.Context, key string) (int64, error) {
+	return r.Client.Incr(ctx, key).Result()
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/middleware"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/services"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+// multipartOverhead is allowed on top of the attachment size limit for the
+// multipart framing of an upload
+const multipartOverhead = 1 << 20
+
+type AttachmentHandler struct {
+	service       *services.AttachmentService
+	signalService *services.TradingSignalService
+	maxBytes      int
+}
+
+func NewAttachmentHandler(service *services.AttachmentService, signalService *services.TradingSignalService, maxBytes int) *AttachmentHandler {
+	return &AttachmentHandler{
+		service:       service,
+		signalService: signalService,
+		maxBytes:      maxBytes,
+	}
+}
+
+// Upload attaches a chart image, sent as multipart form field "file", to a signal (admin only)
+func (h *AttachmentHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	signalID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid signal ID")
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, int64(h.maxBytes)+multipartOverhead)
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			utils.SendError(w, http.StatusRequestEntityTooLarge, utils.ErrorTypeBadRequest, fmt.Sprintf("Attachments are limited to %d bytes", h.maxBytes))
+			return
+		}
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "An image is required in the file field of a multipart form")
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.service.Upload(r.Context(), signalID, header.Filename, file, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTradingSignalNotFound):
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Trading signal not found")
+		case errors.Is(err, services.ErrAttachmentTooLarge):
+			utils.SendError(w, http.StatusRequestEntityTooLarge, utils.ErrorTypeBadRequest, err.Error())
+		case errors.Is(err, services.ErrUnsupportedImage):
+			utils.SendError(w, http.StatusUnsupportedMediaType, utils.ErrorTypeBadRequest, err.Error())
+		default:
+			utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to store attachment")
+		}
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusCreated, utils.ResponseTypeResource, attachment, "Attachment uploaded successfully")
+}
+
+// GetAll lists the attachments of a signal the authenticated user has access to
+func (h *AttachmentHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	signalID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid signal ID")
+		return
+	}
+
+	// Signed URLs are only handed out to users with access to the signal
+	hasAccess, err := h.signalService.CheckUserAccessToSignal(userID, signalID)
+	if err != nil {
+		utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Trading signal not found")
+		return
+	}
+	if !hasAccess {
+		utils.SendError(w, http.StatusForbidden, utils.ErrorTypeForbidden, "You don't have access to this signal. Subscribe to the appropriate package to view this signal.")
+		return
+	}
+
+	h.sendAttachments(w, signalID)
+}
+
+// GetAllAdmin lists the attachments of any signal (admin only)
+func (h *AttachmentHandler) GetAllAdmin(w http.ResponseWriter, r *http.Request) {
+	signalID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid signal ID")
+		return
+	}
+
+	h.sendAttachments(w, signalID)
+}
+
+func (h *AttachmentHandler) sendAttachments(w http.ResponseWriter, signalID int64) {
+	attachments, err := h.service.GetForSignal(signalID)
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve attachments")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeCollection, attachments, "Attachments retrieved successfully")
+}
+
+// Delete removes an attachment from a signal (admin only)
+func (h *AttachmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	signalID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid signal ID")
+		return
+	}
+	attachmentID, err := strconv.ParseInt(vars["attachmentId"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid attachment ID")
+		return
+	}
+
+	if err := h.service.Delete(r.Context(), signalID, attachmentID); err != nil {
+		if errors.Is(err, services.ErrAttachmentNotFound) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Attachment not found")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to delete attachment")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeAction, nil, "Attachment deleted successfully")
+}
+
+// Serve streams an attachment image through a signed URL. It needs no bearer
+// token so the URL can be used directly as an image source.
+func (h *AttachmentHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	attachmentID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid attachment ID")
+		return
+	}
+	expires, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusForbidden, utils.ErrorTypeForbidden, "Invalid attachment URL")
+		return
+	}
+
+	file, contentType, expiresAt, err := h.service.Open(r.Context(), attachmentID, vars["variant"], expires, r.URL.Query().Get("signature"))
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAttachmentURL):
+			utils.SendError(w, http.StatusForbidden, utils.ErrorTypeForbidden, "Invalid attachment URL")
+		case errors.Is(err, services.ErrExpiredAttachmentURL):
+			utils.SendError(w, http.StatusForbidden, utils.ErrorTypeForbidden, "Attachment URL has expired, request a new one")
+		case errors.Is(err, services.ErrAttachmentNotFound), errors.Is(err, services.ErrUnknownAttachmentVariant):
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Attachment not found")
+		default:
+			utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve attachment")
+		}
+		return
+	}
+	defer file.Close()
+
+	// Browsers may reuse the image until the URL expires, shared caches may not
+	maxAge := int(time.Until(expiresAt).Seconds())
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", fmt.Sprintf("private, max-age=%d", max(maxAge, 0)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, file)
+}
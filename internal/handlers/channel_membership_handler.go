@@ -0,0 +1,313 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/middleware"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/services"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+type ChannelMembershipHandler struct {
+	membershipService  *services.ChannelMembershipService
+	discordLinkService *services.DiscordLinkService
+}
+
+func NewChannelMembershipHandler(membershipService *services.ChannelMembershipService, discordLinkService *services.DiscordLinkService) *ChannelMembershipHandler {
+	return &ChannelMembershipHandler{
+		membershipService:  membershipService,
+		discordLinkService: discordLinkService,
+	}
+}
+
+// GetMemberships retrieves the private channels the authenticated user has access
+// to, with the invites of those they have not joined yet
+func (h *ChannelMembershipHandler) GetMemberships(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	memberships, err := h.membershipService.GetMemberships(userID)
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve channel memberships")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeCollection, memberships, "Channel memberships retrieved successfully")
+}
+
+// Invite creates a new personal invite for one of the authenticated user's
+// memberships
+func (h *ChannelMembershipHandler) Invite(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid membership ID")
+		return
+	}
+
+	membership, err := h.membershipService.Invite(userID, id)
+	if err != nil {
+		if errors.Is(err, services.ErrMembershipNotFound) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Channel membership not found")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to create invite")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, membership, "Invite created successfully")
+}
+
+// LinkDiscord links the Discord account that granted an OAuth authorization code
+// to the authenticated user
+func (h *ChannelMembershipHandler) LinkDiscord(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	var req models.DiscordLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.SendValidationError(w, err)
+		return
+	}
+
+	link, err := h.discordLinkService.Link(r.Context(), userID, req.Code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrDiscordLinkingDisabled):
+			utils.SendError(w, http.StatusServiceUnavailable, utils.ErrorTypeServiceUnavailable, "Discord linking is not available")
+		case errors.Is(err, services.ErrDiscordAuthFailed):
+			utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid or expired authorization code")
+		default:
+			utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to link Discord account")
+		}
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusCreated, utils.ResponseTypeResource, link, "Discord account linked successfully")
+}
+
+// GetDiscordLink retrieves the Discord account linked to the authenticated user
+func (h *ChannelMembershipHandler) GetDiscordLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	link, err := h.discordLinkService.GetLink(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrDiscordNotLinked) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Discord account not linked")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve Discord link")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, link, "Discord link retrieved successfully")
+}
+
+// UnlinkDiscord removes the Discord account linked to the authenticated user
+func (h *ChannelMembershipHandler) UnlinkDiscord(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	if err := h.discordLinkService.Unlink(userID); err != nil {
+		if errors.Is(err, services.ErrDiscordNotLinked) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Discord account not linked")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to unlink Discord account")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeAction, nil, "Discord account unlinked successfully")
+}
+
+// GetPackageChannels lists the private channels a package gives access to (admin only)
+func (h *ChannelMembershipHandler) GetPackageChannels(w http.ResponseWriter, r *http.Request) {
+	packageID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid package ID")
+		return
+	}
+
+	channels, err := h.membershipService.GetPackageChannels(packageID)
+	if err != nil {
+		if errors.Is(err, services.ErrPackageNotFound) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Package not found")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve package channels")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeCollection, channels, "Package channels retrieved successfully")
+}
+
+// AddPackageChannel maps a Telegram chat or Discord role to a package (admin only)
+func (h *ChannelMembershipHandler) AddPackageChannel(w http.ResponseWriter, r *http.Request) {
+	packageID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid package ID")
+		return
+	}
+
+	var req models.PackageChannelCreate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.SendValidationError(w, err)
+		return
+	}
+
+	channel, err := h.membershipService.AddPackageChannel(packageID, &req)
+	if err != nil {
+		var fieldErrs utils.ValidationErrors
+		switch {
+		case errors.As(err, &fieldErrs):
+			utils.SendValidationError(w, fieldErrs)
+		case errors.Is(err, services.ErrPackageNotFound):
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Package not found")
+		case errors.Is(err, services.ErrPackageChannelExists):
+			utils.SendError(w, http.StatusConflict, utils.ErrorTypeConflict, err.Error())
+		default:
+			utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to add package channel")
+		}
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusCreated, utils.ResponseTypeResource, channel, "Package channel added successfully")
+}
+
+// RemovePackageChannel takes a channel off a package (admin only)
+func (h *ChannelMembershipHandler) RemovePackageChannel(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	packageID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid package ID")
+		return
+	}
+	channelID, err := strconv.ParseInt(vars["channelId"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid channel ID")
+		return
+	}
+
+	if err := h.membershipService.RemovePackageChannel(packageID, channelID); err != nil {
+		if errors.Is(err, services.ErrPackageChannelNotFound) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Package channel not found")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to remove package channel")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeAction, nil, "Package channel removed successfully")
+}
+
+// Reconcile brings every membership in line with the subscriptions right away
+// (admin only)
+func (h *ChannelMembershipHandler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	result, err := h.membershipService.Reconcile(r.Context(), models.MembershipTriggerAdmin, &adminID)
+	if err != nil {
+		if errors.Is(err, services.ErrReconcileInProgress) {
+			utils.SendError(w, http.StatusConflict, utils.ErrorTypeConflict, err.Error())
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to reconcile channel memberships")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeAction, result, "Channel memberships reconciled successfully")
+}
+
+// GetEvents lists the membership audit trail, newest first (admin only)
+func (h *ChannelMembershipHandler) GetEvents(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := &models.MembershipEventFilter{}
+	if value := query.Get("user_id"); value != "" {
+		userID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			utils.SendValidationError(w, utils.ValidationErrors{{Field: "user_id", Message: "user_id must be a number"}})
+			return
+		}
+		filter.UserID = &userID
+	}
+	if value := query.Get("platform"); value != "" {
+		platform := models.ChannelPlatform(strings.ToUpper(value))
+		filter.Platform = &platform
+	}
+	if value := query.Get("action"); value != "" {
+		action := models.MembershipAction(strings.ToUpper(value))
+		filter.Action = &action
+	}
+	if err := utils.ValidateStruct(filter); err != nil {
+		utils.SendValidationError(w, err)
+		return
+	}
+
+	limit := 50
+	offset := 0
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= 200 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(query.Get("offset")); err == nil && o > 0 {
+		offset = o
+	}
+
+	events, err := h.membershipService.GetEvents(filter, limit, offset)
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve channel membership events")
+		return
+	}
+
+	count, err := h.membershipService.CountEvents(filter)
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to count channel membership events")
+		return
+	}
+
+	response := map[string]interface{}{
+		"events": events,
+		"total":  count,
+		"limit":  limit,
+		"offset": offset,
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeCollection, response, "Channel membership events retrieved successfully")
+}
@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/services"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+// maxInstrumentCSVSize limits the size of bulk instrument imports
+const maxInstrumentCSVSize = 5 << 20
+
+type InstrumentHandler struct {
+	service *services.InstrumentService
+}
+
+func NewInstrumentHandler(service *services.InstrumentService) *InstrumentHandler {
+	return &InstrumentHandler{service: service}
+}
+
+// GetAll lists the instrument catalog (admin only)
+func (h *InstrumentHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := &models.InstrumentFilter{Search: strings.TrimSpace(query.Get("search"))}
+	if value := query.Get("asset_class"); value != "" {
+		assetClass := models.AssetClass(strings.ToUpper(value))
+		filter.AssetClass = &assetClass
+	}
+	if value := query.Get("active"); value != "" {
+		activeOnly, err := strconv.ParseBool(value)
+		if err != nil {
+			utils.SendValidationError(w, utils.ValidationErrors{{Field: "active", Message: "active must be true or false"}})
+			return
+		}
+		filter.ActiveOnly = activeOnly
+	}
+	if err := utils.ValidateStruct(filter); err != nil {
+		utils.SendValidationError(w, err)
+		return
+	}
+
+	limit := 100
+	offset := 0
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= 500 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(query.Get("offset")); err == nil && o > 0 {
+		offset = o
+	}
+
+	instruments, err := h.service.GetAll(filter, limit, offset)
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve instruments")
+		return
+	}
+
+	count, err := h.service.Count(filter)
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to count instruments")
+		return
+	}
+
+	response := map[string]interface{}{
+		"instruments": instruments,
+		"total":       count,
+		"limit":       limit,
+		"offset":      offset,
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeCollection, response, "Instruments retrieved successfully")
+}
+
+// GetByID retrieves a single instrument (admin only)
+func (h *InstrumentHandler) GetByID(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid instrument ID")
+		return
+	}
+
+	instrument, err := h.service.GetByID(id)
+	if err != nil {
+		if errors.Is(err, services.ErrInstrumentNotFound) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Instrument not found")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve instrument")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, instrument, "Instrument retrieved successfully")
+}
+
+// Create adds an instrument to the catalog (admin only)
+func (h *InstrumentHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var instrumentCreate models.InstrumentCreate
+	if err := json.NewDecoder(r.Body).Decode(&instrumentCreate); err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(instrumentCreate); err != nil {
+		utils.SendValidationError(w, err)
+		return
+	}
+
+	instrument, err := h.service.Create(&instrumentCreate)
+	if err != nil {
+		var fieldErrs utils.ValidationErrors
+		switch {
+		case errors.As(err, &fieldErrs):
+			utils.SendValidationError(w, err)
+		case errors.Is(err, services.ErrInstrumentExists):
+			utils.SendError(w, http.StatusConflict, utils.ErrorTypeConflict, err.Error())
+		default:
+			utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to create instrument")
+		}
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusCreated, utils.ResponseTypeResource, instrument, "Instrument created successfully")
+}
+
+// Update updates an instrument's details (admin only)
+func (h *InstrumentHandler) Update(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid instrument ID")
+		return
+	}
+
+	var instrumentUpdate models.InstrumentUpdate
+	if err := json.NewDecoder(r.Body).Decode(&instrumentUpdate); err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(instrumentUpdate); err != nil {
+		utils.SendValidationError(w, err)
+		return
+	}
+
+	instrument, err := h.service.Update(id, &instrumentUpdate)
+	if err != nil {
+		var fieldErrs utils.ValidationErrors
+		switch {
+		case errors.As(err, &fieldErrs):
+			utils.SendValidationError(w, err)
+		case errors.Is(err, services.ErrInstrumentNotFound):
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Instrument not found")
+		default:
+			utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to update instrument")
+		}
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, instrument, "Instrument updated successfully")
+}
+
+// Delete removes an instrument from the catalog (admin only)
+func (h *InstrumentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid instrument ID")
+		return
+	}
+
+	if err := h.service.Delete(id); err != nil {
+		if errors.Is(err, services.ErrInstrumentNotFound) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Instrument not found")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to delete instrument")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeAction, nil, "Instrument deleted successfully")
+}
+
+// Import creates or updates instruments from a CSV file, sent either as the
+// request body or as the "file" field of a multipart form (admin only)
+func (h *InstrumentHandler) Import(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxInstrumentCSVSize)
+
+	var reader io.Reader = r.Body
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "A CSV file is required in the file field")
+			return
+		}
+		defer file.Close()
+		reader = file
+	}
+
+	result, err := h.service.ImportCSV(reader)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidCSV) {
+			utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, err.Error())
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to import instruments")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeAction, result, "Instruments imported successfully")
+}
@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/services"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+type NotificationHandler struct {
+	outboxService *services.NotificationOutboxService
+}
+
+func NewNotificationHandler(outboxService *services.NotificationOutboxService) *NotificationHandler {
+	return &NotificationHandler{outboxService: outboxService}
+}
+
+// GetDeliveries lists notification deliveries, dead-lettered ones unless another
+// status is requested (admin only)
+func (h *NotificationHandler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	status := models.NotificationDeliveryDead
+	if value := query.Get("status"); value != "" {
+		status = models.NotificationDeliveryStatus(strings.ToUpper(value))
+	}
+	filter := &models.NotificationDeliveryFilter{
+		Status:  &status,
+		Channel: strings.TrimSpace(query.Get("channel")),
+	}
+	if value := query.Get("signal_id"); value != "" {
+		signalID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			utils.SendValidationError(w, utils.ValidationErrors{{Field: "signal_id", Message: "signal_id must be a number"}})
+			return
+		}
+		filter.SignalID = &signalID
+	}
+	if err := utils.ValidateStruct(filter); err != nil {
+		utils.SendValidationError(w, err)
+		return
+	}
+
+	limit := 50
+	offset := 0
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil && l > 0 && l <= 200 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(query.Get("offset")); err == nil && o > 0 {
+		offset = o
+	}
+
+	deliveries, err := h.outboxService.GetDeliveries(filter, limit, offset)
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve notification deliveries")
+		return
+	}
+
+	count, err := h.outboxService.CountDeliveries(filter)
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to count notification deliveries")
+		return
+	}
+
+	response := map[string]interface{}{
+		"deliveries": deliveries,
+		"total":      count,
+		"limit":      limit,
+		"offset":     offset,
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeCollection, response, "Notification deliveries retrieved successfully")
+}
+
+// RetryDelivery queues a dead-lettered delivery again (admin only)
+func (h *NotificationHandler) RetryDelivery(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid delivery ID")
+		return
+	}
+
+	delivery, err := h.outboxService.Retry(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrNotificationDeliveryNotFound):
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Notification delivery not found")
+		case errors.Is(err, services.ErrDeliveryNotRetryable):
+			utils.SendError(w, http.StatusConflict, utils.ErrorTypeConflict, err.Error())
+		default:
+			utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retry notification delivery")
+		}
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, delivery, "Notification delivery queued for retry")
+}
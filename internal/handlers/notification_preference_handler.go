@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/middleware"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/services"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+type NotificationPreferenceHandler struct {
+	service *services.NotificationPreferenceService
+}
+
+func NewNotificationPreferenceHandler(service *services.NotificationPreferenceService) *NotificationPreferenceHandler {
+	return &NotificationPreferenceHandler{service: service}
+}
+
+// Get retrieves the authenticated user's notification preferences
+func (h *NotificationPreferenceHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	preferences, err := h.service.Get(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrUserNotFound) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "User not found")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve notification preferences")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, preferences, "Notification preferences retrieved successfully")
+}
+
+// Update replaces the authenticated user's notification preferences
+func (h *NotificationPreferenceHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	var update models.NotificationPreferencesUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(update); err != nil {
+		utils.SendValidationError(w, err)
+		return
+	}
+
+	preferences, err := h.service.Update(userID, &update)
+	if err != nil {
+		var fieldErrs utils.ValidationErrors
+		if errors.As(err, &fieldErrs) {
+			utils.SendValidationError(w, fieldErrs)
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to save notification preferences")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, preferences, "Notification preferences saved successfully")
+}
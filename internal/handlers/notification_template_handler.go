@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/middleware"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/services"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+type NotificationTemplateHandler struct {
+	templateService *services.NotificationTemplateService
+}
+
+func NewNotificationTemplateHandler(templateService *services.NotificationTemplateService) *NotificationTemplateHandler {
+	return &NotificationTemplateHandler{templateService: templateService}
+}
+
+// templatePath reads the channel and event of a template from the URL, writing
+// an error response if either is unknown
+func templatePath(w http.ResponseWriter, r *http.Request) (models.TemplateChannel, models.NotificationEvent, bool) {
+	vars := mux.Vars(r)
+	channel := models.TemplateChannel(vars["channel"])
+	event := models.NotificationEvent(vars["event"])
+
+	if channel != models.TemplateChannelTelegram && channel != models.TemplateChannelDiscord {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid channel. Must be 'telegram' or 'discord'")
+		return "", "", false
+	}
+	if !event.IsValid() {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid notification event")
+		return "", "", false
+	}
+	return channel, event, true
+}
+
+// decodeDraft reads a template draft from the request body, writing an error
+// response if it is invalid
+func decodeDraft(w http.ResponseWriter, r *http.Request) (*models.NotificationTemplateDraft, bool) {
+	var draft models.NotificationTemplateDraft
+	if err := json.NewDecoder(r.Body).Decode(&draft); err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid request body")
+		return nil, false
+	}
+
+	if err := utils.ValidateStruct(draft); err != nil {
+		utils.SendValidationError(w, err)
+		return nil, false
+	}
+	return &draft, true
+}
+
+// GetAll lists the saved notification templates (admin only)
+func (h *NotificationTemplateHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.templateService.GetAll()
+	if err != nil {
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve notification templates")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeCollection, templates, "Notification templates retrieved successfully")
+}
+
+// Get retrieves the template of a channel for an event (admin only)
+func (h *NotificationTemplateHandler) Get(w http.ResponseWriter, r *http.Request) {
+	channel, event, ok := templatePath(w, r)
+	if !ok {
+		return
+	}
+
+	template, err := h.templateService.Get(channel, event)
+	if err != nil {
+		if errors.Is(err, services.ErrNotificationTemplateNotFound) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "No template saved, the built-in message is used")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve notification template")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, template, "Notification template retrieved successfully")
+}
+
+// Save creates or replaces the template of a channel for an event (admin only)
+func (h *NotificationTemplateHandler) Save(w http.ResponseWriter, r *http.Request) {
+	adminID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	channel, event, ok := templatePath(w, r)
+	if !ok {
+		return
+	}
+
+	var req models.NotificationTemplateUpdate
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(req); err != nil {
+		utils.SendValidationError(w, err)
+		return
+	}
+
+	draft := &models.NotificationTemplateDraft{Channel: channel, Event: event, NotificationTemplateUpdate: req}
+	template, err := h.templateService.Save(draft, adminID)
+	if err != nil {
+		var fieldErrs utils.ValidationErrors
+		if errors.As(err, &fieldErrs) {
+			utils.SendValidationError(w, fieldErrs)
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to save notification template")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, template, "Notification template saved successfully")
+}
+
+// Delete removes the template of a channel for an event, restoring the built-in
+// message (admin only)
+func (h *NotificationTemplateHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	channel, event, ok := templatePath(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.templateService.Delete(channel, event); err != nil {
+		if errors.Is(err, services.ErrNotificationTemplateNotFound) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Notification template not found")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to delete notification template")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeAction, nil, "Notification template deleted, the built-in message is used")
+}
+
+// Preview renders a template against a sample signal without saving it (admin only)
+func (h *NotificationTemplateHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	draft, ok := decodeDraft(w, r)
+	if !ok {
+		return
+	}
+
+	preview, err := h.templateService.Preview(draft)
+	if err != nil {
+		var fieldErrs utils.ValidationErrors
+		if errors.As(err, &fieldErrs) {
+			utils.SendValidationError(w, fieldErrs)
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to preview notification template")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, preview, "Notification template rendered successfully")
+}
+
+// Validate checks that a template can be saved (admin only)
+func (h *NotificationTemplateHandler) Validate(w http.ResponseWriter, r *http.Request) {
+	draft, ok := decodeDraft(w, r)
+	if !ok {
+		return
+	}
+
+	if _, err := h.templateService.Preview(draft); err != nil {
+		var fieldErrs utils.ValidationErrors
+		if errors.As(err, &fieldErrs) {
+			utils.SendValidationError(w, fieldErrs)
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to validate notification template")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeAction, nil, "Notification template is valid")
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+// parsePageRequest reads limit, offset and cursor from the query string. Invalid
+// limit and offset values fall back to the defaults; an invalid cursor is an error.
+func parsePageRequest(r *http.Request) (models.PageRequest, error) {
+	query := r.URL.Query()
+	page := models.PageRequest{Limit: models.DefaultPageLimit}
+
+	if l, err := strconv.Atoi(query.Get("limit")); err == nil {
+		page.Limit = l
+	}
+	if o, err := strconv.Atoi(query.Get("offset")); err == nil {
+		page.Offset = o
+	}
+
+	if cursor := query.Get("cursor"); cursor != "" {
+		after, err := models.DecodePageCursor(cursor)
+		if err != nil {
+			return page, utils.ValidationErrors{{Field: "cursor", Message: "cursor is invalid or expired"}}
+		}
+		page.After = after
+	}
+
+	page.Normalize()
+	return page, nil
+}
+
+// pageResponse builds the response of a paginated list under key. In offset mode
+// the total is counted with countFn; cursor mode skips the count query.
+func pageResponse(key string, items interface{}, page models.PageRequest, nextCursor string, countFn func() (int64, error)) (map[string]interface{}, error) {
+	response := map[string]interface{}{
+		key:           items,
+		"limit":       page.Limit,
+		"next_cursor": nil,
+	}
+	if nextCursor != "" {
+		response["next_cursor"] = nextCursor
+	}
+
+	if page.After == nil {
+		count, err := countFn()
+		if err != nil {
+			return nil, err
+		}
+		response["total"] = count
+		response["offset"] = page.Offset
+	}
+
+	return response, nil
+}
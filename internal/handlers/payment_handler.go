@@ -3,7 +3,6 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
 
 	"github.com/omarshah0/rest-api-with-social-auth/internal/middleware"
 	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
@@ -28,45 +27,26 @@ func (h *PaymentHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse query parameters
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
-
-	limit := 50
-	offset := 0
-
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil {
-			limit = l
-		}
-	}
-
-	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil {
-			offset = o
-		}
+	page, err := parsePageRequest(r)
+	if err != nil {
+		utils.SendValidationError(w, err)
+		return
 	}
 
-	payments, err := h.service.GetByUserID(userID, limit, offset)
+	payments, nextCursor, err := h.service.GetByUserID(userID, page)
 	if err != nil {
 		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve payment history")
 		return
 	}
 
-	// Get total count
-	count, err := h.service.CountByUserID(userID)
+	response, err := pageResponse("payments", payments, page, nextCursor, func() (int64, error) {
+		return h.service.CountByUserID(userID)
+	})
 	if err != nil {
 		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to count payments")
 		return
 	}
 
-	response := map[string]interface{}{
-		"payments": payments,
-		"total":    count,
-		"limit":    limit,
-		"offset":   offset,
-	}
-
 	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeCollection, response, "Payment history retrieved successfully")
 }
 
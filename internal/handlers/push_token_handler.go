@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/middleware"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/services"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+type PushTokenHandler struct {
+	service *services.PushTokenService
+}
+
+func NewPushTokenHandler(service *services.PushTokenService) *PushTokenHandler {
+	return &PushTokenHandler{service: service}
+}
+
+// Register stores the Expo push token of the authenticated user's device
+func (h *PushTokenHandler) Register(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	var register models.PushTokenRegister
+	if err := json.NewDecoder(r.Body).Decode(&register); err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := utils.ValidateStruct(register); err != nil {
+		utils.SendValidationError(w, err)
+		return
+	}
+
+	token, err := h.service.Register(userID, &register)
+	if err != nil {
+		var fieldErrs utils.ValidationErrors
+		if errors.As(err, &fieldErrs) {
+			utils.SendValidationError(w, fieldErrs)
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to register push token")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, token, "Push token registered successfully")
+}
+
+// Unregister removes the push token of one of the authenticated user's devices
+func (h *PushTokenHandler) Unregister(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	if err := h.service.Unregister(userID, mux.Vars(r)["deviceId"]); err != nil {
+		if errors.Is(err, services.ErrPushTokenNotFound) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "No push token registered for this device")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to unregister push token")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeAction, nil, "Push token unregistered successfully")
+}
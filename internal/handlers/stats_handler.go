@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/services"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+type StatsHandler struct {
+	service *services.StatsService
+}
+
+func NewStatsHandler(service *services.StatsService) *StatsHandler {
+	return &StatsHandler{service: service}
+}
+
+// GetPerformance returns win rate, returns and other track record figures per asset class and duration
+func (h *StatsHandler) GetPerformance(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		utils.SendValidationError(w, err)
+		return
+	}
+
+	report, err := h.service.GetPerformance(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, services.ErrPackageNotFound) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Package not found")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to calculate performance statistics")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, report, "Performance statistics retrieved successfully")
+}
+
+// GetEquityCurve returns the cumulative return of closed signals over time with drawdowns and monthly returns
+func (h *StatsHandler) GetEquityCurve(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r)
+	if err != nil {
+		utils.SendValidationError(w, err)
+		return
+	}
+
+	interval := strings.ToLower(r.URL.Query().Get("interval"))
+	if interval == "" {
+		interval = models.EquityIntervalDaily
+	}
+	if interval != models.EquityIntervalDaily && interval != models.EquityIntervalWeekly {
+		utils.SendValidationError(w, utils.ValidationErrors{{Field: "interval", Message: "interval must be daily or weekly"}})
+		return
+	}
+
+	curve, err := h.service.GetEquityCurve(r.Context(), filter, interval)
+	if err != nil {
+		if errors.Is(err, services.ErrPackageNotFound) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Package not found")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to build equity curve")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, curve, "Equity curve retrieved successfully")
+}
+
+// parseStatsFilter reads the from, to, asset_class, duration_type and package_id
+// query parameters. A date for to includes the whole day.
+func parseStatsFilter(r *http.Request) (*models.StatsFilter, error) {
+	query := r.URL.Query()
+	filter := &models.StatsFilter{}
+
+	if value := query.Get("asset_class"); value != "" {
+		assetClass := models.AssetClass(strings.ToUpper(value))
+		filter.AssetClass = &assetClass
+	}
+	if value := query.Get("duration_type"); value != "" {
+		durationType := models.DurationType(strings.ToUpper(value))
+		filter.DurationType = &durationType
+	}
+
+	var errs utils.ValidationErrors
+	if value := query.Get("package_id"); value != "" {
+		packageID, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			errs = append(errs, utils.FieldError{Field: "package_id", Message: "package_id must be a package ID"})
+		} else {
+			filter.PackageID = &packageID
+		}
+	}
+	if filter.PackageID != nil && (filter.AssetClass != nil || filter.DurationType != nil) {
+		errs = append(errs, utils.FieldError{Field: "package_id", Message: "package_id cannot be combined with asset_class or duration_type"})
+	}
+	for _, bound := range []struct {
+		field    string
+		target   **time.Time
+		endOfDay bool
+	}{
+		{"from", &filter.From, false},
+		{"to", &filter.To, true},
+	} {
+		value := query.Get(bound.field)
+		if value == "" {
+			continue
+		}
+		at, err := parseFilterTime(value, bound.endOfDay)
+		if err != nil {
+			errs = append(errs, utils.FieldError{Field: bound.field, Message: bound.field + " must be a date (YYYY-MM-DD) or an RFC 3339 timestamp"})
+			continue
+		}
+		*bound.target = &at
+	}
+	if filter.From != nil && filter.To != nil && !filter.From.Before(*filter.To) {
+		errs = append(errs, utils.FieldError{Field: "to", Message: "to must be after from"})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if err := utils.ValidateStruct(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
@@ -3,7 +3,6 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
-	"strconv"
 
 	"github.com/omarshah0/rest-api-with-social-auth/internal/middleware"
 	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
@@ -81,45 +80,26 @@ func (h *SubscriptionHandler) GetHistory(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Parse query parameters
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
-
-	limit := 50
-	offset := 0
-
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil {
-			limit = l
-		}
-	}
-
-	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil {
-			offset = o
-		}
+	page, err := parsePageRequest(r)
+	if err != nil {
+		utils.SendValidationError(w, err)
+		return
 	}
 
-	subscriptions, err := h.service.GetAllSubscriptions(userID, limit, offset)
+	subscriptions, nextCursor, err := h.service.GetAllSubscriptions(userID, page)
 	if err != nil {
 		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve subscription history")
 		return
 	}
 
-	// Get total count
-	count, err := h.service.CountByUserID(userID)
+	response, err := pageResponse("subscriptions", subscriptions, page, nextCursor, func() (int64, error) {
+		return h.service.CountByUserID(userID)
+	})
 	if err != nil {
 		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to count subscriptions")
 		return
 	}
 
-	response := map[string]interface{}{
-		"subscriptions": subscriptions,
-		"total":         count,
-		"limit":         limit,
-		"offset":        offset,
-	}
-
 	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeCollection, response, "Subscription history retrieved successfully")
 }
 
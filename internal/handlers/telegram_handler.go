@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/middleware"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/services"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+// telegramSecretHeader carries the secret token set with setWebhook
+const telegramSecretHeader = "X-Telegram-Bot-Api-Secret-Token"
+
+type TelegramHandler struct {
+	service *services.TelegramBotService
+}
+
+func NewTelegramHandler(service *services.TelegramBotService) *TelegramHandler {
+	return &TelegramHandler{service: service}
+}
+
+// CreateLinkToken issues a one-time deep link linking the authenticated user's
+// Telegram account to the bot
+func (h *TelegramHandler) CreateLinkToken(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	token, err := h.service.CreateLinkToken(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrTelegramBotDisabled) {
+			utils.SendError(w, http.StatusServiceUnavailable, utils.ErrorTypeServiceUnavailable, "Telegram linking is not available")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to create Telegram link")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusCreated, utils.ResponseTypeResource, token, "Telegram link created successfully")
+}
+
+// GetLink retrieves the Telegram chat linked to the authenticated user
+func (h *TelegramHandler) GetLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	link, err := h.service.GetLink(userID)
+	if err != nil {
+		if errors.Is(err, services.ErrTelegramNotLinked) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Telegram account not linked")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve Telegram link")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, link, "Telegram link retrieved successfully")
+}
+
+// Unlink removes the Telegram chat linked to the authenticated user
+func (h *TelegramHandler) Unlink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	if err := h.service.Unlink(userID); err != nil {
+		if errors.Is(err, services.ErrTelegramNotLinked) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Telegram account not linked")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to unlink Telegram account")
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeAction, nil, "Telegram account unlinked successfully")
+}
+
+// Webhook receives the bot's updates from Telegram. Failures to handle an update
+// are logged and still acknowledged, as Telegram would otherwise keep resending it.
+func (h *TelegramHandler) Webhook(w http.ResponseWriter, r *http.Request) {
+	if !h.service.ValidWebhookSecret(r.Header.Get(telegramSecretHeader)) {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Invalid webhook secret")
+		return
+	}
+
+	var update models.TelegramUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := h.service.HandleUpdate(&update); err != nil {
+		log.Printf("Failed to handle Telegram update %d: %v", update.UpdateID, err)
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeAction, nil, "Update processed")
+}
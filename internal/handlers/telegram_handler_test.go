@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/config"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/services"
+)
+
+const (
+	testLinkToken    = "0123456789abcdef"
+	testLinkUserID   = 7
+	testChatID       = 4242
+	testGroupID      = "-1001234567890"
+	testWebhookToken = "webhook-secret"
+)
+
+// fakeLinkStore links chats with testLinkToken only, to testLinkUserID. Calls
+// the tests do not expect panic on the nil TelegramLinkStore.
+type fakeLinkStore struct {
+	services.TelegramLinkStore
+	calls  []string
+	linked []models.TelegramLink // Links made
+}
+
+func (f *fakeLinkStore) Link(token string, chatID int64, username *string) (*models.TelegramLink, error) {
+	f.calls = append(f.calls, "Link")
+	if token != testLinkToken {
+		return nil, nil
+	}
+	link := models.TelegramLink{UserID: testLinkUserID, ChatID: chatID, Username: username, LinkedAt: time.Now()}
+	f.linked = append(f.linked, link)
+	return &link, nil
+}
+
+// fakeMembershipStore only holds a pending membership of testChatID in
+// testGroupID. Calls the tests do not expect panic on the nil
+// ChannelMembershipStore.
+type fakeMembershipStore struct {
+	services.ChannelMembershipStore
+	calls []string
+	saved []models.ChannelMembership // Memberships saved
+}
+
+func (f *fakeMembershipStore) GetByMember(platform models.ChannelPlatform, targetID, memberID string) (*models.ChannelMembership, error) {
+	f.calls = append(f.calls, "GetByMember")
+	if platform != models.ChannelPlatformTelegram || targetID != testGroupID || memberID != fmt.Sprint(testChatID) {
+		return nil, nil
+	}
+	return &models.ChannelMembership{ID: 1, UserID: testLinkUserID, Platform: platform, TargetID: targetID, MemberID: memberID, Status: models.MembershipStatusPending}, nil
+}
+
+func (f *fakeMembershipStore) Save(membership *models.ChannelMembership) (*models.ChannelMembership, error) {
+	f.calls = append(f.calls, "Save")
+	f.saved = append(f.saved, *membership)
+	return membership, nil
+}
+
+func (f *fakeMembershipStore) LogEvent(*models.MembershipEvent) error {
+	f.calls = append(f.calls, "LogEvent")
+	return nil
+}
+
+// fakeBotAPI records the calls the bot makes to the Bot API
+type fakeBotAPI struct {
+	mu       sync.Mutex
+	methods  []string
+	messages []map[string]interface{} // Parameters of the messages sent
+}
+
+// newTestTelegramHandler returns a handler whose bot answers through a fake Bot
+// API, along with the fake stores and the calls the bot made
+func newTestTelegramHandler(t *testing.T) (*TelegramHandler, *fakeLinkStore, *fakeMembershipStore, *fakeBotAPI) {
+	t.Helper()
+
+	bot := &fakeBotAPI{}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var params map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			t.Errorf("decode Bot API request: %v", err)
+		}
+		method := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		bot.mu.Lock()
+		bot.methods = append(bot.methods, method)
+		if method == "sendMessage" {
+			bot.messages = append(bot.messages, params)
+		}
+		bot.mu.Unlock()
+		w.Write([]byte(`{"ok":true,"result":true}`))
+	}))
+	t.Cleanup(api.Close)
+
+	cfg := &config.NotificationConfig{
+		TelegramEnabled:       true,
+		TelegramAPIURL:        api.URL,
+		TelegramBotToken:      "test-token",
+		TelegramBotUsername:   "signals_bot",
+		TelegramWebhookSecret: testWebhookToken,
+	}
+	links := &fakeLinkStore{}
+	memberships := &fakeMembershipStore{}
+	membershipService := services.NewChannelMembershipService(&config.MembershipConfig{}, cfg, memberships, nil, nil, nil)
+	service := services.NewTelegramBotService(cfg, links, nil, nil, nil, membershipService)
+	return NewTelegramHandler(service), links, memberships, bot
+}
+
+// postUpdate sends a private message update to the webhook
+func postUpdate(handler *TelegramHandler, secret, text string) *httptest.ResponseRecorder {
+	body := fmt.Sprintf(`{"update_id":1,"message":{"message_id":1,"from":{"id":%d,"username":"trader"},"chat":{"id":%d,"type":"private"},"text":%q}}`,
+		testChatID, testChatID, text)
+	return postWebhook(handler, secret, body)
+}
+
+func postWebhook(handler *TelegramHandler, secret, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/api/telegram/webhook", strings.NewReader(body))
+	if secret != "" {
+		req.Header.Set(telegramSecretHeader, secret)
+	}
+	rec := httptest.NewRecorder()
+	handler.Webhook(rec, req)
+	return rec
+}
+
+func TestTelegramWebhookStartLinksChat(t *testing.T) {
+	handler, links, _, bot := newTestTelegramHandler(t)
+
+	rec := postUpdate(handler, testWebhookToken, "/start "+testLinkToken)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	if len(links.linked) != 1 || links.linked[0].ChatID != testChatID || links.linked[0].Username == nil || *links.linked[0].Username != "trader" {
+		t.Errorf("links = %+v, want chat %d of trader linked", links.linked, testChatID)
+	}
+	if len(bot.messages) != 1 {
+		t.Fatalf("bot sent %d messages, want 1", len(bot.messages))
+	}
+	reply := bot.messages[0]
+	if reply["chat_id"] != fmt.Sprint(testChatID) || !strings.Contains(fmt.Sprint(reply["text"]), "Your account is linked") {
+		t.Errorf("reply = %v, want the linked confirmation in chat %d", reply, testChatID)
+	}
+}
+
+func TestTelegramWebhookStartInvalidToken(t *testing.T) {
+	handler, links, _, bot := newTestTelegramHandler(t)
+
+	rec := postUpdate(handler, testWebhookToken, "/start unknown")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+	}
+
+	if len(links.linked) != 0 {
+		t.Errorf("chat linked as %+v for an unknown token", links.linked)
+	}
+	if len(bot.messages) != 1 || !strings.Contains(fmt.Sprint(bot.messages[0]["text"]), "invalid or has expired") {
+		t.Errorf("replies = %v, want the invalid link reply", bot.messages)
+	}
+}
+
+func TestTelegramWebhookRejectsBadSecret(t *testing.T) {
+	tests := []struct {
+		name   string
+		secret string
+	}{
+		{"missing", ""},
+		{"wrong", "not-the-secret"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, links, memberships, bot := newTestTelegramHandler(t)
+
+			rec := postUpdate(handler, tt.secret, "/start "+testLinkToken)
+			if rec.Code != http.StatusUnauthorized {
+				t.Errorf("status = %d, want 401", rec.Code)
+			}
+			if len(links.calls) != 0 || len(memberships.calls) != 0 || len(bot.messages) != 0 {
+				t.Errorf("update handled: store calls %v and %v, %d messages sent", links.calls, memberships.calls, len(bot.messages))
+			}
+		})
+	}
+}
+
+func TestTelegramWebhookJoinRequest(t *testing.T) {
+	tests := []struct {
+		name       string
+		fromID     int64
+		wantMethod string
+		wantCalls  []string
+		wantStatus models.MembershipStatus
+	}{
+		{"linked member", testChatID, "approveChatJoinRequest", []string{"GetByMember", "Save", "LogEvent"}, models.MembershipStatusActive},
+		{"forwarded invite", 999, "declineChatJoinRequest", []string{"GetByMember"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, _, memberships, bot := newTestTelegramHandler(t)
+
+			body := fmt.Sprintf(`{"update_id":1,"chat_join_request":{"chat":{"id":%s,"type":"supergroup"},"from":{"id":%d}}}`, testGroupID, tt.fromID)
+			rec := postWebhook(handler, testWebhookToken, body)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body)
+			}
+
+			if len(bot.methods) != 1 || bot.methods[0] != tt.wantMethod {
+				t.Errorf("Bot API calls = %v, want %s", bot.methods, tt.wantMethod)
+			}
+			if !slices.Equal(memberships.calls, tt.wantCalls) {
+				t.Errorf("membership store calls = %v, want %v", memberships.calls, tt.wantCalls)
+			}
+			var status models.MembershipStatus
+			if len(memberships.saved) > 0 {
+				status = memberships.saved[0].Status
+			}
+			if status != tt.wantStatus {
+				t.Errorf("membership saved as %q, want %q", status, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/omarshah0/rest-api-with-social-auth/internal/middleware"
@@ -13,14 +16,19 @@ import (
 )
 
 type TradingSignalHandler struct {
-	service *services.TradingSignalService
+	service           *services.TradingSignalService
+	attachmentService *services.AttachmentService
 }
 
-func NewTradingSignalHandler(service *services.TradingSignalService) *TradingSignalHandler {
-	return &TradingSignalHandler{service: service}
+func NewTradingSignalHandler(service *services.TradingSignalService, attachmentService *services.AttachmentService) *TradingSignalHandler {
+	return &TradingSignalHandler{
+		service:           service,
+		attachmentService: attachmentService,
+	}
 }
 
-// GetAll retrieves trading signals visible to the authenticated user
+// GetAll retrieves trading signals visible to the authenticated user. With
+// include_locked=true the other published signals are listed as locked teasers.
 func (h *TradingSignalHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
 	userID, ok := middleware.GetUserIDFromContext(r.Context())
@@ -29,90 +37,52 @@ func (h *TradingSignalHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse query parameters
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
-
-	limit := 50
-	offset := 0
-
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil {
-			limit = l
-		}
-	}
-
-	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil {
-			offset = o
-		}
+	page, filter, err := parseSignalListRequest(r)
+	if err != nil {
+		utils.SendValidationError(w, err)
+		return
 	}
 
 	// Get signals visible to this user based on their subscriptions
-	signals, err := h.service.GetSignalsForUser(userID, limit, offset)
+	signals, nextCursor, err := h.service.GetSignalsForUser(userID, filter, page)
 	if err != nil {
 		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve trading signals")
 		return
 	}
 
-	// Get total count for this user
-	count, err := h.service.CountForUser(userID)
+	response, err := pageResponse("signals", signals, page, nextCursor, func() (int64, error) {
+		return h.service.CountForUser(userID, filter)
+	})
 	if err != nil {
 		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to count trading signals")
 		return
 	}
 
-	response := map[string]interface{}{
-		"signals": signals,
-		"total":   count,
-		"limit":   limit,
-		"offset":  offset,
-	}
-
 	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeCollection, response, "Trading signals retrieved successfully")
 }
 
 // GetAllAdmin retrieves all trading signals (admin only)
 func (h *TradingSignalHandler) GetAllAdmin(w http.ResponseWriter, r *http.Request) {
-	// Parse query parameters
-	limitStr := r.URL.Query().Get("limit")
-	offsetStr := r.URL.Query().Get("offset")
-
-	limit := 50
-	offset := 0
-
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil {
-			limit = l
-		}
-	}
-
-	if offsetStr != "" {
-		if o, err := strconv.Atoi(offsetStr); err == nil {
-			offset = o
-		}
+	page, filter, err := parseSignalListRequest(r)
+	if err != nil {
+		utils.SendValidationError(w, err)
+		return
 	}
 
-	signals, err := h.service.GetAll(limit, offset)
+	signals, nextCursor, err := h.service.GetAll(filter, page)
 	if err != nil {
 		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve trading signals")
 		return
 	}
 
-	// Get total count
-	count, err := h.service.Count()
+	response, err := pageResponse("signals", signals, page, nextCursor, func() (int64, error) {
+		return h.service.Count(filter)
+	})
 	if err != nil {
 		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to count trading signals")
 		return
 	}
 
-	response := map[string]interface{}{
-		"signals": signals,
-		"total":   count,
-		"limit":   limit,
-		"offset":  offset,
-	}
-
 	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeCollection, response, "Trading signals retrieved successfully")
 }
 
@@ -157,6 +127,53 @@ func (h *TradingSignalHandler) GetByID(w http.ResponseWriter, r *http.Request) {
 	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, signal, "Trading signal retrieved successfully")
 }
 
+// GetHistory retrieves the edit history of a trading signal
+func (h *TradingSignalHandler) GetHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid signal ID")
+		return
+	}
+
+	// The history reveals the signal's levels, so it needs the same access as the signal itself
+	hasAccess, err := h.service.CheckUserAccessToSignal(userID, id)
+	if err != nil {
+		utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Trading signal not found")
+		return
+	}
+
+	if !hasAccess {
+		utils.SendError(w, http.StatusForbidden, utils.ErrorTypeForbidden, "You don't have access to this signal. Subscribe to the appropriate package to view this signal.")
+		return
+	}
+
+	revisions, err := h.service.GetRevisions(id)
+	if err != nil {
+		if errors.Is(err, services.ErrTradingSignalNotFound) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Trading signal not found")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve trading signal history")
+		return
+	}
+
+	response := map[string]interface{}{
+		"signal_id": id,
+		"revisions": revisions,
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeCollection, response, "Trading signal history retrieved successfully")
+}
+
 // Create creates a new trading signal (admin only)
 func (h *TradingSignalHandler) Create(w http.ResponseWriter, r *http.Request) {
 	// Get user ID from context
@@ -174,12 +191,17 @@ func (h *TradingSignalHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 	// Validate input
 	if err := utils.ValidateStruct(signalCreate); err != nil {
-		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeValidation, err.Error())
+		utils.SendValidationError(w, err)
 		return
 	}
 
 	signal, err := h.service.Create(&signalCreate, userID)
 	if err != nil {
+		var fieldErrs utils.ValidationErrors
+		if errors.As(err, &fieldErrs) || errors.Is(err, services.ErrInvalidTargets) {
+			utils.SendValidationError(w, err)
+			return
+		}
 		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to create trading signal")
 		return
 	}
@@ -189,6 +211,12 @@ func (h *TradingSignalHandler) Create(w http.ResponseWriter, r *http.Request) {
 
 // Update updates a trading signal (admin only)
 func (h *TradingSignalHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
 	vars := mux.Vars(r)
 	idStr := vars["id"]
 
@@ -206,19 +234,201 @@ func (h *TradingSignalHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 	// Validate input
 	if err := utils.ValidateStruct(signalUpdate); err != nil {
-		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeValidation, err.Error())
+		utils.SendValidationError(w, err)
 		return
 	}
 
-	signal, err := h.service.Update(id, &signalUpdate)
+	signal, err := h.service.Update(id, &signalUpdate, userID)
 	if err != nil {
-		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to update trading signal")
+		var fieldErrs utils.ValidationErrors
+		switch {
+		case errors.Is(err, services.ErrTradingSignalNotFound):
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Trading signal not found")
+		case errors.As(err, &fieldErrs), errors.Is(err, services.ErrInvalidTargets):
+			utils.SendValidationError(w, err)
+		default:
+			utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to update trading signal")
+		}
 		return
 	}
 
 	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, signal, "Trading signal updated successfully")
 }
 
+// UpdateStatus moves a trading signal to a new lifecycle status (admin only)
+func (h *TradingSignalHandler) UpdateStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid signal ID")
+		return
+	}
+
+	var statusUpdate models.SignalStatusUpdate
+	if err := json.NewDecoder(r.Body).Decode(&statusUpdate); err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input
+	if err := utils.ValidateStruct(statusUpdate); err != nil {
+		utils.SendValidationError(w, err)
+		return
+	}
+
+	signal, err := h.service.TransitionStatus(id, &statusUpdate, &userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTradingSignalNotFound):
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Trading signal not found")
+		case errors.Is(err, services.ErrInvalidStatusTransition):
+			utils.SendError(w, http.StatusConflict, utils.ErrorTypeConflict, err.Error())
+		case errors.Is(err, services.ErrExitPriceRequired):
+			utils.SendValidationError(w, err)
+		default:
+			utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to update trading signal status")
+		}
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, signal, "Trading signal status updated successfully")
+}
+
+// MarkTargetHit records that a take-profit target was reached (admin only)
+func (h *TradingSignalHandler) MarkTargetHit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	id, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid signal ID")
+		return
+	}
+
+	position, err := strconv.Atoi(vars["position"])
+	if err != nil || position < 1 {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid target position")
+		return
+	}
+
+	signal, err := h.service.MarkTargetHit(id, position, &userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTradingSignalNotFound):
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Trading signal not found")
+		case errors.Is(err, services.ErrInvalidStatusTransition), errors.Is(err, services.ErrInvalidTargets):
+			utils.SendError(w, http.StatusConflict, utils.ErrorTypeConflict, err.Error())
+		default:
+			utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to mark target as hit")
+		}
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeResource, signal, "Target marked as hit successfully")
+}
+
+// PostUpdate posts an analyst update on a signal's timeline and notifies subscribers (admin only)
+func (h *TradingSignalHandler) PostUpdate(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid signal ID")
+		return
+	}
+
+	var create models.SignalUpdateCreate
+	if err := json.NewDecoder(r.Body).Decode(&create); err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid request body")
+		return
+	}
+
+	// Validate input
+	if err := utils.ValidateStruct(create); err != nil {
+		utils.SendValidationError(w, err)
+		return
+	}
+
+	update, err := h.service.PostUpdate(id, &create, userID)
+	if err != nil {
+		var fieldErrs utils.ValidationErrors
+		switch {
+		case errors.Is(err, services.ErrTradingSignalNotFound):
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Trading signal not found")
+		case errors.As(err, &fieldErrs):
+			utils.SendValidationError(w, err)
+		case errors.Is(err, services.ErrUpdateNotAllowed):
+			utils.SendError(w, http.StatusConflict, utils.ErrorTypeConflict, err.Error())
+		default:
+			utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to post signal update")
+		}
+		return
+	}
+
+	utils.SendSuccess(w, http.StatusCreated, utils.ResponseTypeResource, update, "Signal update posted successfully")
+}
+
+// GetUpdates retrieves the analyst updates on a signal the authenticated user has access to
+func (h *TradingSignalHandler) GetUpdates(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		utils.SendError(w, http.StatusUnauthorized, utils.ErrorTypeUnauthorized, "Authentication required")
+		return
+	}
+
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.SendError(w, http.StatusBadRequest, utils.ErrorTypeBadRequest, "Invalid signal ID")
+		return
+	}
+
+	// Updates reveal the signal's levels, so they need the same access as the signal itself
+	hasAccess, err := h.service.CheckUserAccessToSignal(userID, id)
+	if err != nil {
+		utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Trading signal not found")
+		return
+	}
+
+	if !hasAccess {
+		utils.SendError(w, http.StatusForbidden, utils.ErrorTypeForbidden, "You don't have access to this signal. Subscribe to the appropriate package to view this signal.")
+		return
+	}
+
+	updates, err := h.service.GetUpdates(id)
+	if err != nil {
+		if errors.Is(err, services.ErrTradingSignalNotFound) {
+			utils.SendError(w, http.StatusNotFound, utils.ErrorTypeNotFound, "Trading signal not found")
+			return
+		}
+		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to retrieve signal updates")
+		return
+	}
+
+	response := map[string]interface{}{
+		"signal_id": id,
+		"updates":   updates,
+	}
+
+	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeCollection, response, "Signal updates retrieved successfully")
+}
+
 // Delete deletes a trading signal (admin only)
 func (h *TradingSignalHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -234,6 +444,126 @@ func (h *TradingSignalHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		utils.SendError(w, http.StatusInternalServerError, utils.ErrorTypeInternalServer, "Failed to delete trading signal")
 		return
 	}
+	h.attachmentService.DeleteSignalFiles(r.Context(), id)
 
 	utils.SendSuccess(w, http.StatusOK, utils.ResponseTypeAction, nil, "Trading signal deleted successfully")
 }
+
+// parseSignalListRequest reads the page and filters of a signal list request
+func parseSignalListRequest(r *http.Request) (models.PageRequest, *models.TradingSignalFilter, error) {
+	page, err := parsePageRequest(r)
+	if err != nil {
+		return page, nil, err
+	}
+
+	filter, err := parseSignalFilter(r)
+	if err != nil {
+		return page, nil, err
+	}
+
+	if page.After != nil && filter.SortBy == models.SignalSortReturn {
+		return page, nil, utils.ValidationErrors{{Field: "cursor", Message: "cursor pagination is only available when sorting by created_at"}}
+	}
+	return page, filter, nil
+}
+
+// parseSignalFilter reads the list filters and sort options from the query string
+func parseSignalFilter(r *http.Request) (*models.TradingSignalFilter, error) {
+	query := r.URL.Query()
+	filter := &models.TradingSignalFilter{
+		Symbol:    strings.TrimSpace(query.Get("symbol")),
+		SortBy:    strings.ToLower(query.Get("sort_by")),
+		SortOrder: strings.ToLower(query.Get("sort_order")),
+	}
+
+	if value := query.Get("asset_class"); value != "" {
+		assetClass := models.AssetClass(strings.ToUpper(value))
+		filter.AssetClass = &assetClass
+	}
+	if value := query.Get("duration_type"); value != "" {
+		durationType := models.DurationType(strings.ToUpper(value))
+		filter.DurationType = &durationType
+	}
+	if value := query.Get("type"); value != "" {
+		signalType := models.SignalType(strings.ToUpper(value))
+		filter.Type = &signalType
+	}
+	if value := query.Get("result"); value != "" {
+		result := models.SignalResult(strings.ToUpper(value))
+		filter.Result = &result
+	}
+	if value := query.Get("status"); value != "" {
+		status := models.SignalStatus(strings.ToUpper(value))
+		filter.Status = &status
+	}
+
+	var errs utils.ValidationErrors
+	if value := query.Get("free_for_all"); value != "" {
+		freeForAll, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, utils.FieldError{Field: "free_for_all", Message: "free_for_all must be true or false"})
+		} else {
+			filter.FreeForAll = &freeForAll
+		}
+	}
+	if value := query.Get("include_locked"); value != "" {
+		includeLocked, err := strconv.ParseBool(value)
+		if err != nil {
+			errs = append(errs, utils.FieldError{Field: "include_locked", Message: "include_locked must be true or false"})
+		} else {
+			filter.IncludeLocked = includeLocked
+		}
+	}
+	if value := query.Get("created_by"); value != "" {
+		createdBy, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			errs = append(errs, utils.FieldError{Field: "created_by", Message: "created_by must be a user ID"})
+		} else {
+			filter.CreatedBy = &createdBy
+		}
+	}
+	for _, bound := range []struct {
+		field    string
+		target   **time.Time
+		endOfDay bool
+	}{
+		{"created_from", &filter.CreatedFrom, false},
+		{"created_to", &filter.CreatedTo, true},
+	} {
+		value := query.Get(bound.field)
+		if value == "" {
+			continue
+		}
+		at, err := parseFilterTime(value, bound.endOfDay)
+		if err != nil {
+			errs = append(errs, utils.FieldError{Field: bound.field, Message: bound.field + " must be a date (YYYY-MM-DD) or an RFC 3339 timestamp"})
+			continue
+		}
+		*bound.target = &at
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	if err := utils.ValidateStruct(filter); err != nil {
+		return nil, err
+	}
+	return filter, nil
+}
+
+// parseFilterTime parses a date or RFC 3339 timestamp. A date used as an exclusive
+// upper bound is moved to the start of the next day so the whole day is included.
+func parseFilterTime(value string, endOfDay bool) (time.Time, error) {
+	if at, err := time.Parse(time.RFC3339, value); err == nil {
+		return at.UTC(), nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if endOfDay {
+		date = date.AddDate(0, 0, 1)
+	}
+	return date, nil
+}
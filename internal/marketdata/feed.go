@@ -0,0 +1,52 @@
+package marketdata
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Candle is a price bar for a symbol. A single tick is represented as a candle
+// whose open, high, low and close are all the traded price.
+type Candle struct {
+	Symbol string    `json:"symbol"`
+	Open   float64   `json:"open"`
+	High   float64   `json:"high"`
+	Low    float64   `json:"low"`
+	Close  float64   `json:"close"`
+	Time   time.Time `json:"time"`
+}
+
+// NewTick creates a candle representing a single trade at price
+func NewTick(symbol string, price float64, at time.Time) Candle {
+	return Candle{
+		Symbol: NormalizeSymbol(symbol),
+		Open:   price,
+		High:   price,
+		Low:    price,
+		Close:  price,
+		Time:   at,
+	}
+}
+
+// Touches reports whether price lies within the candle's range
+func (c Candle) Touches(price float64) bool {
+	return c.Low <= price && price <= c.High
+}
+
+// PriceFeed is the interface implemented by market data sources.
+// Exchange adapters and the replay feed all plug in behind it.
+type PriceFeed interface {
+	// Name identifies the feed in logs and status notes
+	Name() string
+	// Fetch returns candles for the given symbols with a time after since,
+	// ordered oldest first
+	Fetch(ctx context.Context, symbols []string, since time.Time) ([]Candle, error)
+}
+
+// NormalizeSymbol converts a symbol to the form used for matching feed data,
+// e.g. "eur/usd" becomes "EURUSD"
+func NormalizeSymbol(symbol string) string {
+	replacer := strings.NewReplacer("/", "", "-", "", "_", "", " ", "")
+	return strings.ToUpper(replacer.Replace(strings.TrimSpace(symbol)))
+}
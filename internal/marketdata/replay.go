@@ -0,0 +1,211 @@
+package marketdata
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReplayFeed replays recorded ticks or candles from a CSV or JSON file.
+// Recorded timestamps are shifted onto the wall clock starting when the feed
+// is created, and can be sped up so hours of data play back in minutes.
+type ReplayFeed struct {
+	candles []Candle
+	start   time.Time
+	speed   float64
+}
+
+// replayRecord is a single row of a replay file. Rows either carry a price
+// (a tick) or open/high/low/close values (a candle).
+type replayRecord struct {
+	Symbol string   `json:"symbol"`
+	Time   string   `json:"time"`
+	Price  *float64 `json:"price"`
+	Open   *float64 `json:"open"`
+	High   *float64 `json:"high"`
+	Low    *float64 `json:"low"`
+	Close  *float64 `json:"close"`
+}
+
+// NewReplayFeedFromFile loads a replay file; the format is chosen by extension (.csv or .json)
+func NewReplayFeedFromFile(path string, speed float64) (*ReplayFeed, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open replay file: %w", err)
+	}
+	defer file.Close()
+
+	var records []replayRecord
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		records, err = readCSVRecords(file)
+	case ".json":
+		err = json.NewDecoder(file).Decode(&records)
+	default:
+		return nil, fmt.Errorf("unsupported replay file format %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay file: %w", err)
+	}
+
+	return newReplayFeed(records, speed)
+}
+
+// newReplayFeed creates a replay feed from parsed records
+func newReplayFeed(records []replayRecord, speed float64) (*ReplayFeed, error) {
+	if speed <= 0 {
+		speed = 1
+	}
+
+	candles := make([]Candle, 0, len(records))
+	for i, record := range records {
+		candle, err := record.toCandle()
+		if err != nil {
+			return nil, fmt.Errorf("replay record %d: %w", i+1, err)
+		}
+		candles = append(candles, candle)
+	}
+	sort.SliceStable(candles, func(i, j int) bool {
+		return candles[i].Time.Before(candles[j].Time)
+	})
+
+	feed := &ReplayFeed{
+		candles: candles,
+		start:   time.Now(),
+		speed:   speed,
+	}
+	feed.rebase()
+	return feed, nil
+}
+
+func (f *ReplayFeed) Name() string {
+	return "replay"
+}
+
+// Fetch returns the replayed candles that became due after since
+func (f *ReplayFeed) Fetch(ctx context.Context, symbols []string, since time.Time) ([]Candle, error) {
+	wanted := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		wanted[NormalizeSymbol(symbol)] = true
+	}
+
+	now := time.Now()
+	var due []Candle
+	for _, candle := range f.candles {
+		if candle.Time.After(now) {
+			break
+		}
+		if candle.Time.After(since) && wanted[candle.Symbol] {
+			due = append(due, candle)
+		}
+	}
+
+	return due, nil
+}
+
+// rebase shifts recorded timestamps so the first record plays at start
+func (f *ReplayFeed) rebase() {
+	if len(f.candles) == 0 {
+		return
+	}
+	first := f.candles[0].Time
+	for i := range f.candles {
+		offset := time.Duration(float64(f.candles[i].Time.Sub(first)) / f.speed)
+		f.candles[i].Time = f.start.Add(offset)
+	}
+}
+
+func (r replayRecord) toCandle() (Candle, error) {
+	if r.Symbol == "" {
+		return Candle{}, fmt.Errorf("symbol is required")
+	}
+
+	at, err := time.Parse(time.RFC3339, r.Time)
+	if err != nil {
+		return Candle{}, fmt.Errorf("invalid time %q: %w", r.Time, err)
+	}
+
+	if r.Price != nil {
+		return NewTick(r.Symbol, *r.Price, at), nil
+	}
+	if r.Open == nil || r.High == nil || r.Low == nil || r.Close == nil {
+		return Candle{}, fmt.Errorf("either price or open, high, low and close are required")
+	}
+
+	return Candle{
+		Symbol: NormalizeSymbol(r.Symbol),
+		Open:   *r.Open,
+		High:   *r.High,
+		Low:    *r.Low,
+		Close:  *r.Close,
+		Time:   at,
+	}, nil
+}
+
+// readCSVRecords parses a CSV file with a header row naming its columns,
+// e.g. "symbol,time,price" or "symbol,time,open,high,low,close"
+func readCSVRecords(reader io.Reader) ([]replayRecord, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+
+	header, err := csvReader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+
+	var records []replayRecord
+	for {
+		row, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		record := replayRecord{
+			Symbol: csvValue(row, columns, "symbol"),
+			Time:   csvValue(row, columns, "time"),
+		}
+		for name, target := range map[string]**float64{
+			"price": &record.Price,
+			"open":  &record.Open,
+			"high":  &record.High,
+			"low":   &record.Low,
+			"close": &record.Close,
+		} {
+			value := csvValue(row, columns, name)
+			if value == "" {
+				continue
+			}
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q", name, value)
+			}
+			*target = &parsed
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+func csvValue(row []string, columns map[string]int, name string) string {
+	index, ok := columns[name]
+	if !ok || index >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[index])
+}
@@ -0,0 +1,155 @@
+package marketdata
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadCSVRecords(t *testing.T) {
+	input := "symbol,time,price\n" +
+		"eur/usd,2024-03-04T09:00:00Z,1.085\n" +
+		"BTC-USDT, 2024-03-04T09:01:00Z ,63000\n"
+
+	records, err := readCSVRecords(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("readCSVRecords: %v", err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+
+	candle, err := records[0].toCandle()
+	if err != nil {
+		t.Fatalf("toCandle: %v", err)
+	}
+	if candle.Symbol != "EURUSD" || candle.Open != 1.085 || candle.Low != 1.085 || candle.Close != 1.085 {
+		t.Errorf("tick candle = %+v, want EURUSD at 1.085", candle)
+	}
+	if records[1].Time != "2024-03-04T09:01:00Z" {
+		t.Errorf("time = %q, want it trimmed", records[1].Time)
+	}
+}
+
+func TestReadCSVRecordsInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"bad price", "symbol,time,price\nEURUSD,2024-03-04T09:00:00Z,abc\n"},
+		{"bare quote", "symbol,time,price\nEUR\"USD,2024-03-04T09:00:00Z,1.085\n"},
+		{"empty file", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := readCSVRecords(strings.NewReader(tt.input)); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestReplayRecordToCandle(t *testing.T) {
+	price := 1.085
+	tests := []struct {
+		name    string
+		record  replayRecord
+		wantErr bool
+	}{
+		{"tick", replayRecord{Symbol: "EURUSD", Time: "2024-03-04T09:00:00Z", Price: &price}, false},
+		{"candle", replayRecord{Symbol: "EURUSD", Time: "2024-03-04T09:00:00Z", Open: &price, High: &price, Low: &price, Close: &price}, false},
+		{"missing symbol", replayRecord{Time: "2024-03-04T09:00:00Z", Price: &price}, true},
+		{"invalid time", replayRecord{Symbol: "EURUSD", Time: "04/03/2024", Price: &price}, true},
+		{"partial candle", replayRecord{Symbol: "EURUSD", Time: "2024-03-04T09:00:00Z", Open: &price}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.record.toCandle()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("toCandle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReplayFeedRebase(t *testing.T) {
+	price := 1.0
+	records := []replayRecord{
+		{Symbol: "EURUSD", Time: "2024-03-04T10:00:00Z", Price: &price},
+		{Symbol: "EURUSD", Time: "2024-03-04T09:00:00Z", Price: &price},
+	}
+
+	feed, err := newReplayFeed(records, 60)
+	if err != nil {
+		t.Fatalf("newReplayFeed: %v", err)
+	}
+
+	if !feed.candles[0].Time.Equal(feed.start) {
+		t.Errorf("first candle plays at %v, want the feed start %v", feed.candles[0].Time, feed.start)
+	}
+	// An hour of data at 60x plays back in a minute
+	if got := feed.candles[1].Time.Sub(feed.start); got != time.Minute {
+		t.Errorf("second candle plays %v after start, want 1m", got)
+	}
+}
+
+func TestReplayFeedFetch(t *testing.T) {
+	feed, err := NewReplayFeedFromFile(filepath.Join("..", "..", "testdata", "prices.csv"), 1)
+	if err != nil {
+		t.Fatalf("NewReplayFeedFromFile: %v", err)
+	}
+
+	// The first candles of each symbol play as soon as the feed is created
+	candles, err := feed.Fetch(context.Background(), []string{"eur/usd"}, time.Time{})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(candles) != 1 {
+		t.Fatalf("got %d candles, want only the first EURUSD candle", len(candles))
+	}
+	if candles[0].Symbol != "EURUSD" || candles[0].Close != 1.0850 {
+		t.Errorf("candle = %+v, want the first EURUSD candle", candles[0])
+	}
+
+	// Candles already fetched are not returned again
+	candles, err = feed.Fetch(context.Background(), []string{"EURUSD"}, candles[0].Time)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(candles) != 0 {
+		t.Errorf("got %d candles after the first, want none due yet", len(candles))
+	}
+}
+
+func TestNewReplayFeedFromFileJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.json")
+	data := `[{"symbol":"BTCUSDT","time":"2024-03-04T09:00:00Z","open":1,"high":2,"low":0.5,"close":1.5}]`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	feed, err := NewReplayFeedFromFile(path, 1)
+	if err != nil {
+		t.Fatalf("NewReplayFeedFromFile: %v", err)
+	}
+	candles, err := feed.Fetch(context.Background(), []string{"BTCUSDT"}, time.Time{})
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+	if len(candles) != 1 || candles[0].High != 2 || !candles[0].Touches(0.75) {
+		t.Errorf("candles = %+v, want the recorded candle", candles)
+	}
+}
+
+func TestNewReplayFeedFromFileUnsupported(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.txt")
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewReplayFeedFromFile(path, 1); err == nil {
+		t.Error("expected an error for a .txt file")
+	}
+}
@@ -0,0 +1,116 @@
+package models
+
+import (
+	"time"
+)
+
+// ChannelPlatform is a platform hosting private channels for subscribers
+type ChannelPlatform string
+
+const (
+	ChannelPlatformTelegram ChannelPlatform = "TELEGRAM" // Private Telegram group or channel
+	ChannelPlatformDiscord  ChannelPlatform = "DISCORD"  // Role in the Discord guild
+)
+
+// PackageChannel is a private Telegram chat or Discord guild role the subscribers
+// of a package get access to. Several packages may share a channel.
+type PackageChannel struct {
+	ID        int64           `json:"id"`
+	PackageID int64           `json:"package_id"`
+	Platform  ChannelPlatform `json:"platform"`
+	TargetID  string          `json:"target_id"` // Telegram chat ID or Discord role ID
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// PackageChannelCreate represents the data needed to give a package's
+// subscribers access to a channel
+type PackageChannelCreate struct {
+	Platform ChannelPlatform `json:"platform" validate:"required,oneof=TELEGRAM DISCORD"`
+	TargetID string          `json:"target_id" validate:"required,max=64"`
+}
+
+// MembershipStatus is the state of a user's access to a channel
+type MembershipStatus string
+
+const (
+	MembershipStatusPending MembershipStatus = "PENDING" // Invited, granted once the user joins
+	MembershipStatusActive  MembershipStatus = "ACTIVE"
+	MembershipStatusRemoved MembershipStatus = "REMOVED"
+)
+
+// ChannelMembership is a user's access to a private chat or guild role
+type ChannelMembership struct {
+	ID              int64            `json:"id"`
+	UserID          int64            `json:"user_id"`
+	Platform        ChannelPlatform  `json:"platform"`
+	TargetID        string           `json:"target_id"`
+	MemberID        string           `json:"member_id"` // Telegram or Discord user ID
+	Status          MembershipStatus `json:"status"`
+	InviteLink      *string          `json:"invite_link,omitempty"` // Personal, while pending
+	InviteExpiresAt *time.Time       `json:"invite_expires_at,omitempty"`
+	CreatedAt       time.Time        `json:"created_at"`
+	UpdatedAt       time.Time        `json:"updated_at"`
+}
+
+// MembershipAction is an action recorded in the membership audit trail
+type MembershipAction string
+
+const (
+	MembershipActionInvited MembershipAction = "INVITED" // Invite link created
+	MembershipActionGranted MembershipAction = "GRANTED" // Joined the chat or given the role
+	MembershipActionRemoved MembershipAction = "REMOVED" // Kicked from the chat or role removed
+	MembershipActionFailed  MembershipAction = "FAILED"  // Retried on the next reconciliation
+)
+
+// MembershipTrigger is what started a membership action
+type MembershipTrigger string
+
+const (
+	MembershipTriggerSchedule  MembershipTrigger = "SCHEDULE"
+	MembershipTriggerAdmin     MembershipTrigger = "ADMIN"
+	MembershipTriggerSubscribe MembershipTrigger = "SUBSCRIBE"
+	MembershipTriggerUser      MembershipTrigger = "USER"
+)
+
+// MembershipEvent is an entry of the membership audit trail
+type MembershipEvent struct {
+	ID           int64             `json:"id"`
+	MembershipID *int64            `json:"membership_id"`
+	UserID       int64             `json:"user_id"`
+	Platform     ChannelPlatform   `json:"platform"`
+	TargetID     string            `json:"target_id"`
+	Action       MembershipAction  `json:"action"`
+	TriggeredBy  MembershipTrigger `json:"triggered_by"`
+	Detail       *string           `json:"detail,omitempty"`
+	AdminID      *int64            `json:"admin_id,omitempty"` // Admin who started the reconciliation
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// MembershipEventFilter narrows down the membership audit trail
+type MembershipEventFilter struct {
+	UserID   *int64            `json:"user_id" validate:"omitempty,gt=0"`
+	Platform *ChannelPlatform  `json:"platform" validate:"omitempty,oneof=TELEGRAM DISCORD"`
+	Action   *MembershipAction `json:"action" validate:"omitempty,oneof=INVITED GRANTED REMOVED FAILED"`
+}
+
+// ReconcileResult summarizes a reconciliation of memberships with subscriptions
+type ReconcileResult struct {
+	Deactivated int64 `json:"deactivated"` // Expired subscriptions deactivated
+	Invited     int   `json:"invited"`
+	Granted     int   `json:"granted"`
+	Removed     int   `json:"removed"`
+	Failed      int   `json:"failed"`
+}
+
+// DiscordLink is the Discord account a user linked to their account
+type DiscordLink struct {
+	UserID        int64     `json:"user_id"`
+	DiscordUserID string    `json:"discord_user_id"`
+	Username      *string   `json:"username,omitempty"`
+	LinkedAt      time.Time `json:"linked_at"`
+}
+
+// DiscordLinkRequest carries the code of the Discord OAuth flow
+type DiscordLinkRequest struct {
+	Code string `json:"code" validate:"required"`
+}
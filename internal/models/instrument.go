@@ -0,0 +1,65 @@
+package models
+
+import "time"
+
+// Instrument is a tradable symbol in the catalog. Signals must reference the
+// canonical symbol of an active instrument in their asset class.
+type Instrument struct {
+	ID            int64      `json:"id" db:"id"`
+	Symbol        string     `json:"symbol" db:"symbol"`
+	AssetClass    AssetClass `json:"asset_class" db:"asset_class"`
+	DisplayName   string     `json:"display_name" db:"display_name"`
+	TickSize      float64    `json:"tick_size" db:"tick_size"`
+	PipSize       *float64   `json:"pip_size,omitempty" db:"pip_size"` // FOREX only
+	LotSize       float64    `json:"lot_size" db:"lot_size"`
+	QuoteCurrency string     `json:"quote_currency" db:"quote_currency"`
+	Sector        *string    `json:"sector,omitempty" db:"sector"` // PSX only
+	IsActive      bool       `json:"is_active" db:"is_active"`
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// InstrumentCreate represents the data needed to add an instrument to the catalog
+type InstrumentCreate struct {
+	Symbol        string     `json:"symbol" validate:"required,max=50"`
+	AssetClass    AssetClass `json:"asset_class" validate:"required,oneof=FOREX CRYPTO PSX"`
+	DisplayName   string     `json:"display_name" validate:"required,max=100"`
+	TickSize      float64    `json:"tick_size" validate:"required,gt=0"`
+	PipSize       *float64   `json:"pip_size,omitempty" validate:"omitempty,gt=0"`     // Defaults from the symbol for FOREX
+	LotSize       float64    `json:"lot_size" validate:"omitempty,gt=0"`               // Defaults to 1
+	QuoteCurrency string     `json:"quote_currency" validate:"omitempty,min=3,max=10"` // Required for CRYPTO
+	Sector        *string    `json:"sector,omitempty" validate:"omitempty,max=100"`
+}
+
+// InstrumentUpdate represents the data needed to update an instrument
+type InstrumentUpdate struct {
+	DisplayName   *string  `json:"display_name" validate:"omitempty,max=100"`
+	TickSize      *float64 `json:"tick_size" validate:"omitempty,gt=0"`
+	PipSize       *float64 `json:"pip_size" validate:"omitempty,gt=0"`
+	LotSize       *float64 `json:"lot_size" validate:"omitempty,gt=0"`
+	QuoteCurrency *string  `json:"quote_currency" validate:"omitempty,min=3,max=10"`
+	Sector        *string  `json:"sector" validate:"omitempty,max=100"`
+	IsActive      *bool    `json:"is_active"`
+}
+
+// InstrumentFilter narrows instrument listings. Nil and empty fields are not applied.
+type InstrumentFilter struct {
+	AssetClass *AssetClass `json:"asset_class" validate:"omitempty,oneof=FOREX CRYPTO PSX"`
+	Search     string      `json:"search" validate:"omitempty,max=100"` // Matches symbol prefix or display name
+	ActiveOnly bool        `json:"active_only"`
+}
+
+// InstrumentImportResult summarises a bulk CSV import
+type InstrumentImportResult struct {
+	Created int                     `json:"created"`
+	Updated int                     `json:"updated"`
+	Skipped int                     `json:"skipped,omitempty"`
+	Errors  []InstrumentImportError `json:"errors"`
+}
+
+// InstrumentImportError describes a CSV row that was not imported
+type InstrumentImportError struct {
+	Line    int    `json:"line"`
+	Symbol  string `json:"symbol,omitempty"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+)
+
+// NotificationDeliveryStatus tracks the delivery of a notification through one channel
+type NotificationDeliveryStatus string
+
+const (
+	NotificationDeliveryPending NotificationDeliveryStatus = "PENDING" // Waiting for its next attempt
+	NotificationDeliverySent    NotificationDeliveryStatus = "SENT"
+	NotificationDeliveryDead    NotificationDeliveryStatus = "DEAD" // Gave up after the maximum number of attempts
+)
+
+// NotificationDelivery is a notification from the outbox on its way to a single channel
+type NotificationDelivery struct {
+	ID            int64                      `json:"id" db:"id"`
+	OutboxID      int64                      `json:"outbox_id" db:"outbox_id"`
+	SignalID      int64                      `json:"signal_id" db:"signal_id"`
+	Event         NotificationEvent          `json:"event" db:"event"`
+	Channel       string                     `json:"channel" db:"channel"`
+	Status        NotificationDeliveryStatus `json:"status" db:"status"`
+	Attempts      int                        `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time                  `json:"next_attempt_at" db:"next_attempt_at"`
+	LastError     *string                    `json:"last_error" db:"last_error"`
+	SentAt        *time.Time                 `json:"sent_at" db:"sent_at"`
+	CreatedAt     time.Time                  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time                  `json:"updated_at" db:"updated_at"`
+
+	// Notification is only loaded for deliveries claimed for sending
+	Notification *SignalNotification `json:"-"`
+}
+
+// NotificationDeliveryFilter narrows delivery listings. Nil and empty fields are not applied.
+type NotificationDeliveryFilter struct {
+	Status   *NotificationDeliveryStatus `json:"status" validate:"omitempty,oneof=PENDING SENT DEAD"`
+	Channel  string                      `json:"channel" validate:"omitempty,max=50"`
+	SignalID *int64                      `json:"signal_id" validate:"omitempty,gt=0"`
+}
+
+// OutboxNotification is a notification claimed from the outbox to be fanned out
+// to the channels of its audience
+type OutboxNotification struct {
+	ID         int64
+	Event      NotificationEvent
+	Tier       *PackageTier // Nil for every channel
+	FreeForAll bool         // Whether the signal is open to everyone
+}
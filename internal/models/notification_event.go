@@ -0,0 +1,50 @@
+package models
+
+// NotificationEvent is the kind of change on a trading signal a notification announces
+type NotificationEvent string
+
+const (
+	NotificationEventCreated   NotificationEvent = "created"
+	NotificationEventUpdated   NotificationEvent = "updated" // Edited, or an analyst update was posted
+	NotificationEventTargetHit NotificationEvent = "target_hit"
+	NotificationEventClosed    NotificationEvent = "closed" // TP hit, SL hit or closed manually, with a result
+	NotificationEventCancelled NotificationEvent = "cancelled"
+	NotificationEventExpired   NotificationEvent = "expired"
+)
+
+// NotificationEvents lists every event channels can subscribe to
+var NotificationEvents = []NotificationEvent{
+	NotificationEventCreated,
+	NotificationEventUpdated,
+	NotificationEventTargetHit,
+	NotificationEventClosed,
+	NotificationEventCancelled,
+	NotificationEventExpired,
+}
+
+// IsValid reports whether e is a known event
+func (e NotificationEvent) IsValid() bool {
+	for _, event := range NotificationEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// SignalNotification is an event on a trading signal sent to the notification
+// channels. Besides the signal only the fields of its event are set.
+type SignalNotification struct {
+	Event  NotificationEvent `json:"event"`
+	Signal *TradingSignal    `json:"signal"`
+
+	// Target is the take-profit target reached, for target_hit
+	Target *SignalTarget `json:"target,omitempty"`
+
+	// Update is the analyst update posted, or Changes the fields edited, for updated
+	Update  *SignalUpdate          `json:"update,omitempty"`
+	Changes map[string]FieldChange `json:"changes,omitempty"`
+
+	// Reason explains an edit or a cancellation
+	Reason string `json:"reason,omitempty"`
+}
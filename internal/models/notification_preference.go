@@ -0,0 +1,153 @@
+package models
+
+import (
+	"slices"
+	"time"
+	_ "time/tzdata" // Preferences name IANA zones, which must resolve without system zoneinfo
+)
+
+// NotificationChannel is a channel through which a user is notified personally
+type NotificationChannel string
+
+const (
+	NotificationChannelPush     NotificationChannel = "push" // Expo push to the mobile app
+	NotificationChannelEmail    NotificationChannel = "email"
+	NotificationChannelTelegram NotificationChannel = "telegram" // Direct message from the bot
+)
+
+// NotificationMode is how a user's notifications are delivered
+type NotificationMode string
+
+const (
+	NotificationModeInstant NotificationMode = "INSTANT"
+	NotificationModeDigest  NotificationMode = "DIGEST" // Collected and sent once a day at the digest time
+)
+
+// NotificationPreferences are the choices of a user on which signals they are
+// notified about, where and when
+type NotificationPreferences struct {
+	AssetClasses    []AssetClass          `json:"asset_classes"`     // Empty for every asset class
+	DurationTypes   []DurationType        `json:"duration_types"`    // Empty for every duration
+	Channels        []NotificationChannel `json:"channels"`          // Empty to mute every personal notification
+	QuietHoursStart *string               `json:"quiet_hours_start"` // "HH:MM" in Timezone, nil for no quiet hours
+	QuietHoursEnd   *string               `json:"quiet_hours_end"`
+	Timezone        string                `json:"timezone"` // IANA zone, e.g. "Asia/Karachi"
+	Mode            NotificationMode      `json:"mode"`
+	DigestTime      string                `json:"digest_time"` // "HH:MM" in Timezone the daily digest is sent at
+	UpdatedAt       *time.Time            `json:"updated_at"`  // Nil until the user saves their preferences
+}
+
+// NotificationPreferencesUpdate replaces a user's notification preferences
+type NotificationPreferencesUpdate struct {
+	AssetClasses    []AssetClass          `json:"asset_classes" validate:"dive,oneof=FOREX CRYPTO PSX"`
+	DurationTypes   []DurationType        `json:"duration_types" validate:"dive,oneof=SHORT_TERM LONG_TERM"`
+	Channels        []NotificationChannel `json:"channels" validate:"dive,oneof=push email telegram"`
+	QuietHoursStart *string               `json:"quiet_hours_start" validate:"required_with=QuietHoursEnd,omitempty,len=5"`
+	QuietHoursEnd   *string               `json:"quiet_hours_end" validate:"required_with=QuietHoursStart,omitempty,len=5"`
+	Timezone        string                `json:"timezone" validate:"required,max=64"`
+	Mode            NotificationMode      `json:"mode" validate:"required,oneof=INSTANT DIGEST"`
+	DigestTime      string                `json:"digest_time" validate:"omitempty,len=5"`
+}
+
+// DefaultDigestTime is the digest time of users who have not picked one
+const DefaultDigestTime = "08:00"
+
+// DefaultNotificationPreferences are the preferences of users who have not saved
+// any: every signal they are entitled to, instantly, pushed and sent to their
+// Telegram chat once they link one
+func DefaultNotificationPreferences() *NotificationPreferences {
+	return &NotificationPreferences{
+		AssetClasses:  []AssetClass{},
+		DurationTypes: []DurationType{},
+		Channels:      []NotificationChannel{NotificationChannelPush, NotificationChannelTelegram},
+		Timezone:      "UTC",
+		Mode:          NotificationModeInstant,
+		DigestTime:    DefaultDigestTime,
+	}
+}
+
+// Matches reports whether the user wants to hear about signal
+func (p *NotificationPreferences) Matches(signal *TradingSignal) bool {
+	return (len(p.AssetClasses) == 0 || slices.Contains(p.AssetClasses, signal.AssetClass)) &&
+		(len(p.DurationTypes) == 0 || slices.Contains(p.DurationTypes, signal.DurationType))
+}
+
+// HasChannel reports whether the user wants to be notified through channel
+func (p *NotificationPreferences) HasChannel(channel NotificationChannel) bool {
+	return slices.Contains(p.Channels, channel)
+}
+
+// Location returns the user's time zone, UTC if it cannot be loaded
+func (p *NotificationPreferences) Location() *time.Location {
+	location, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// DeliverAt returns when a notification raised at now reaches the user: now for
+// instant delivery, the next digest time for digests, and in either case no
+// earlier than the end of the quiet hours it falls in
+func (p *NotificationPreferences) DeliverAt(now time.Time) time.Time {
+	at := now.In(p.Location())
+	if p.Mode == NotificationModeDigest {
+		if minutes, ok := ParseClock(p.DigestTime); ok {
+			at = nextClock(at, minutes)
+		}
+	}
+	if p.QuietHoursStart == nil || p.QuietHoursEnd == nil {
+		return at
+	}
+
+	start, okStart := ParseClock(*p.QuietHoursStart)
+	end, okEnd := ParseClock(*p.QuietHoursEnd)
+	if !okStart || !okEnd || start == end {
+		return at
+	}
+	minute := at.Hour()*60 + at.Minute()
+	inQuietHours := (start < end && minute >= start && minute < end) ||
+		(start > end && (minute >= start || minute < end)) // Quiet hours spanning midnight
+	if inQuietHours {
+		return nextClock(at, end)
+	}
+	return at
+}
+
+// ParseClock parses an "HH:MM" time of day into minutes after midnight
+func ParseClock(clock string) (int, bool) {
+	parsed, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, false
+	}
+	return parsed.Hour()*60 + parsed.Minute(), true
+}
+
+// nextClock returns the first time at or after t, in t's location, whose time of
+// day is minutes after midnight
+func nextClock(t time.Time, minutes int) time.Time {
+	next := time.Date(t.Year(), t.Month(), t.Day(), minutes/60, minutes%60, 0, 0, t.Location())
+	if next.Before(t) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// NotificationRecipient is a user entitled to a signal, along with their preferences
+type NotificationRecipient struct {
+	UserID      int64
+	Email       string
+	Name        string
+	Preferences *NotificationPreferences
+}
+
+// NotificationDigestItem is a personal notification held back for a user's digest
+// or until their quiet hours end
+type NotificationDigestItem struct {
+	ID        int64               `db:"id"`
+	UserID    int64               `db:"user_id"`
+	Channel   NotificationChannel `db:"channel"`
+	Title     string              `db:"title"`
+	Body      string              `db:"body"`
+	CreatedAt time.Time           `db:"created_at"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNotificationPreferencesDeliverAt(t *testing.T) {
+	karachi, err := time.LoadLocation("Asia/Karachi")
+	if err != nil {
+		t.Fatalf("LoadLocation: %v", err)
+	}
+	local := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 3, day, hour, minute, 0, 0, karachi)
+	}
+	clock := func(value string) *string { return &value }
+
+	overnight := func(mode NotificationMode, digestTime string) *NotificationPreferences {
+		return &NotificationPreferences{
+			Timezone:        "Asia/Karachi",
+			Mode:            mode,
+			DigestTime:      digestTime,
+			QuietHoursStart: clock("22:00"),
+			QuietHoursEnd:   clock("07:00"),
+		}
+	}
+
+	tests := []struct {
+		name        string
+		preferences *NotificationPreferences
+		now         time.Time
+		want        time.Time
+	}{
+		{"before overnight quiet hours", overnight(NotificationModeInstant, ""), local(4, 21, 59), local(4, 21, 59)},
+		{"start of overnight quiet hours", overnight(NotificationModeInstant, ""), local(4, 22, 0), local(5, 7, 0)},
+		{"before midnight", overnight(NotificationModeInstant, ""), local(4, 23, 30), local(5, 7, 0)},
+		{"after midnight", overnight(NotificationModeInstant, ""), local(5, 2, 0), local(5, 7, 0)},
+		{"end of overnight quiet hours", overnight(NotificationModeInstant, ""), local(5, 7, 0), local(5, 7, 0)},
+		{
+			"daytime quiet hours",
+			&NotificationPreferences{Timezone: "Asia/Karachi", Mode: NotificationModeInstant, QuietHoursStart: clock("13:00"), QuietHoursEnd: clock("14:00")},
+			local(4, 13, 30),
+			local(4, 14, 0),
+		},
+		{"digest after quiet hours", overnight(NotificationModeDigest, "08:00"), local(4, 23, 0), local(5, 8, 0)},
+		{"digest inside quiet hours", overnight(NotificationModeDigest, "06:00"), local(4, 23, 0), local(5, 7, 0)},
+		{"digest later today", overnight(NotificationModeDigest, "18:00"), local(4, 9, 0), local(4, 18, 0)},
+		{
+			"no quiet hours",
+			&NotificationPreferences{Timezone: "Asia/Karachi", Mode: NotificationModeInstant},
+			local(4, 23, 30),
+			local(4, 23, 30),
+		},
+		{
+			"unknown time zone falls back to UTC",
+			&NotificationPreferences{Timezone: "Mars/Olympus", Mode: NotificationModeInstant, QuietHoursStart: clock("22:00"), QuietHoursEnd: clock("07:00")},
+			time.Date(2024, 3, 4, 23, 0, 0, 0, time.UTC),
+			time.Date(2024, 3, 5, 7, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The time is passed in UTC, as the service does
+			got := tt.preferences.DeliverAt(tt.now.UTC())
+			if !got.Equal(tt.want) {
+				t.Errorf("DeliverAt(%v) = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	tests := []struct {
+		clock  string
+		want   int
+		wantOK bool
+	}{
+		{"00:00", 0, true},
+		{"07:30", 450, true},
+		{"23:59", 1439, true},
+		{"24:00", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseClock(tt.clock)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("ParseClock(%q) = %d, %v; want %d, %v", tt.clock, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// TemplateChannel is a notification channel whose messages can be templated.
+// A template applies to the regular and the VIP chat or webhook alike.
+type TemplateChannel string
+
+const (
+	TemplateChannelTelegram TemplateChannel = "telegram"
+	TemplateChannelDiscord  TemplateChannel = "discord"
+)
+
+// NotificationTemplate is a Go text/template replacing the built-in message of a
+// channel for one event. Telegram templates render MarkdownV2 messages; Discord
+// templates render the embed's title and description.
+type NotificationTemplate struct {
+	Channel   TemplateChannel   `json:"channel"`
+	Event     NotificationEvent `json:"event"`
+	Title     *string           `json:"title,omitempty"` // Discord only; the built-in title is kept when nil
+	Body      string            `json:"body"`
+	UpdatedBy *int64            `json:"updated_by"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// NotificationTemplateUpdate represents the data needed to save a template
+type NotificationTemplateUpdate struct {
+	Title *string `json:"title" validate:"omitempty,max=2000"`
+	Body  string  `json:"body" validate:"required,max=10000"`
+}
+
+// NotificationTemplateDraft is a template checked or previewed before it is saved
+type NotificationTemplateDraft struct {
+	Channel TemplateChannel   `json:"channel" validate:"required,oneof=telegram discord"`
+	Event   NotificationEvent `json:"event" validate:"required,oneof=created updated target_hit closed cancelled expired"`
+	NotificationTemplateUpdate
+}
+
+// RenderedNotification is a template rendered for a notification
+type RenderedNotification struct {
+	Title     string `json:"title,omitempty"`
+	Body      string `json:"body"`
+	ParseMode string `json:"parse_mode,omitempty"` // Telegram only
+}
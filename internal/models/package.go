@@ -12,12 +12,33 @@ const (
 	BillingCycleYearly     BillingCycle = "YEARLY"
 )
 
+// PackageTier sets how early subscribers of a package see new signals, see PackageTiers
+type PackageTier string
+
+const (
+	PackageTierVIP      PackageTier = "VIP"
+	PackageTierStandard PackageTier = "STANDARD"
+)
+
+// PackageTiers lists the tiers by priority, highest first. Notifications for a new
+// signal are fanned out in this order.
+var PackageTiers = []PackageTier{PackageTierVIP, PackageTierStandard}
+
+// SignalTierRelease is a scheduled new signal notification for the subscribers of
+// a tier with a release delay
+type SignalTierRelease struct {
+	SignalID  int64       `json:"signal_id"`
+	Tier      PackageTier `json:"tier"`
+	ReleaseAt time.Time   `json:"release_at"`
+}
+
 type Package struct {
 	ID           int64        `json:"id" db:"id"`
 	Name         string       `json:"name" db:"name" validate:"required"`
 	AssetClass   AssetClass   `json:"asset_class" db:"asset_class" validate:"required,oneof=FOREX CRYPTO PSX"`
 	DurationType DurationType `json:"duration_type" db:"duration_type" validate:"required,oneof=SHORT_TERM LONG_TERM"`
 	BillingCycle BillingCycle `json:"billing_cycle" db:"billing_cycle" validate:"required,oneof=MONTHLY SIX_MONTHS YEARLY"`
+	Tier         PackageTier  `json:"tier" db:"tier" validate:"required,oneof=VIP STANDARD"`
 	DurationDays int          `json:"duration_days" db:"duration_days" validate:"required,gt=0"`
 	Price        float64      `json:"price" db:"price" validate:"required,gte=0"`
 	Description  *string      `json:"description,omitempty" db:"description"`
@@ -32,6 +53,7 @@ type PackageCreate struct {
 	AssetClass   AssetClass   `json:"asset_class" validate:"required,oneof=FOREX CRYPTO PSX"`
 	DurationType DurationType `json:"duration_type" validate:"required,oneof=SHORT_TERM LONG_TERM"`
 	BillingCycle BillingCycle `json:"billing_cycle" validate:"required,oneof=MONTHLY SIX_MONTHS YEARLY"`
+	Tier         PackageTier  `json:"tier" validate:"omitempty,oneof=VIP STANDARD"` // Defaults to STANDARD
 	DurationDays int          `json:"duration_days" validate:"required,gt=0"`
 	Price        float64      `json:"price" validate:"required,gte=0"`
 	Description  *string      `json:"description,omitempty"`
@@ -43,6 +65,7 @@ type PackageUpdate struct {
 	AssetClass   *AssetClass   `json:"asset_class" validate:"omitempty,oneof=FOREX CRYPTO PSX"`
 	DurationType *DurationType `json:"duration_type" validate:"omitempty,oneof=SHORT_TERM LONG_TERM"`
 	BillingCycle *BillingCycle `json:"billing_cycle" validate:"omitempty,oneof=MONTHLY SIX_MONTHS YEARLY"`
+	Tier         *PackageTier  `json:"tier" validate:"omitempty,oneof=VIP STANDARD"`
 	DurationDays *int          `json:"duration_days" validate:"omitempty,gt=0"`
 	Price        *float64      `json:"price" validate:"omitempty,gte=0"`
 	Description  *string       `json:"description,omitempty"`
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPageLimit = 50
+	MaxPageLimit     = 100
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// PageRequest selects a page of a list ordered by (created_at, id). Pages are
+// addressed by offset, or by cursor when After is set.
+type PageRequest struct {
+	Limit  int
+	Offset int         // Ignored when After is set
+	After  *PageCursor // Position of the last item of the previous page
+}
+
+// Normalize applies the default and maximum page size
+func (p *PageRequest) Normalize() {
+	if p.Limit <= 0 {
+		p.Limit = DefaultPageLimit
+	}
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+	if p.Offset < 0 || p.After != nil {
+		p.Offset = 0
+	}
+}
+
+// Lookahead returns a copy of the request that fetches one extra item, used to
+// detect whether another page follows
+func (p PageRequest) Lookahead() PageRequest {
+	p.Limit++
+	return p
+}
+
+// PageCursor is the keyset position of an item in a (created_at, id) ordered list
+type PageCursor struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+// Encode returns the opaque cursor string handed to clients
+func (c PageCursor) Encode() string {
+	raw := fmt.Sprintf("%d:%d", c.CreatedAt.UnixNano(), c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// DecodePageCursor parses a cursor produced by PageCursor.Encode
+func DecodePageCursor(value string) (*PageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	parts := strings.SplitN(string(raw), ":", 2)
+	if len(parts) != 2 {
+		return nil, ErrInvalidCursor
+	}
+	nanos, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil || id <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &PageCursor{CreatedAt: time.Unix(0, nanos).UTC(), ID: id}, nil
+}
@@ -0,0 +1,83 @@
+package models
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestPageCursorRoundTrip(t *testing.T) {
+	cursors := []PageCursor{
+		{CreatedAt: time.Date(2024, 3, 4, 9, 30, 15, 123456789, time.UTC), ID: 42},
+		{CreatedAt: time.Date(1999, 12, 31, 23, 59, 59, 0, time.UTC), ID: 1},
+		{CreatedAt: time.Date(2024, 3, 4, 14, 30, 0, 0, time.FixedZone("PKT", 5*60*60)), ID: 9000000000},
+	}
+	for _, cursor := range cursors {
+		encoded := cursor.Encode()
+		decoded, err := DecodePageCursor(encoded)
+		if err != nil {
+			t.Fatalf("DecodePageCursor(%q): %v", encoded, err)
+		}
+		if !decoded.CreatedAt.Equal(cursor.CreatedAt) || decoded.ID != cursor.ID {
+			t.Errorf("round trip of %+v = %+v", cursor, decoded)
+		}
+		if decoded.CreatedAt.Location() != time.UTC {
+			t.Errorf("decoded time %v is not in UTC", decoded.CreatedAt)
+		}
+	}
+}
+
+func TestDecodePageCursorInvalid(t *testing.T) {
+	encode := func(raw string) string { return base64.RawURLEncoding.EncodeToString([]byte(raw)) }
+
+	tests := []struct {
+		name  string
+		value string
+	}{
+		{"empty", ""},
+		{"malformed base64", "not*base64!"},
+		{"padded base64", base64.URLEncoding.EncodeToString([]byte("1709544615000000000:42"))},
+		{"missing separator", encode("1709544615000000000")},
+		{"non-numeric time", encode("yesterday:42")},
+		{"non-numeric id", encode("1709544615000000000:abc")},
+		{"zero id", encode("1709544615000000000:0")},
+		{"negative id", encode("1709544615000000000:-3")},
+		{"extra part", encode("1709544615000000000:42:7")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cursor, err := DecodePageCursor(tt.value)
+			if !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("DecodePageCursor(%q) = %+v, %v; want ErrInvalidCursor", tt.value, cursor, err)
+			}
+		})
+	}
+}
+
+func TestPageRequestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		page PageRequest
+		want PageRequest
+	}{
+		{"defaults", PageRequest{}, PageRequest{Limit: DefaultPageLimit}},
+		{"caps limit", PageRequest{Limit: 500, Offset: 20}, PageRequest{Limit: MaxPageLimit, Offset: 20}},
+		{"negative offset", PageRequest{Limit: 10, Offset: -5}, PageRequest{Limit: 10}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.page.Normalize()
+			if tt.page != tt.want {
+				t.Errorf("Normalize() = %+v, want %+v", tt.page, tt.want)
+			}
+		})
+	}
+
+	cursor := &PageCursor{ID: 1}
+	page := PageRequest{Limit: 10, Offset: 30, After: cursor}
+	page.Normalize()
+	if page.Offset != 0 {
+		t.Errorf("Offset = %d with a cursor, want 0", page.Offset)
+	}
+}
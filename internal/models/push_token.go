@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// PushToken is the Expo push token of a user's device
+type PushToken struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    int64     `json:"user_id" db:"user_id"`
+	DeviceID  string    `json:"device_id" db:"device_id"`
+	Token     string    `json:"token" db:"token"`
+	Platform  *string   `json:"platform" db:"platform"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// PushTokenRegister is sent by the mobile app whenever it obtains a push token.
+// A device has one token, so registering replaces the device's previous one.
+type PushTokenRegister struct {
+	Token    string  `json:"token" validate:"required,max=255"`
+	DeviceID string  `json:"device_id" validate:"required,max=255"`
+	Platform *string `json:"platform" validate:"omitempty,oneof=ios android"`
+}
+
+// PushTicket is Expo's acknowledgement of a push message, redeemed for a receipt later
+type PushTicket struct {
+	ID    string `db:"id"`
+	Token string `db:"token"`
+}
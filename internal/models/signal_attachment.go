@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Attachment variants served through signed URLs
+const (
+	AttachmentVariantOriginal  = "original"
+	AttachmentVariantThumbnail = "thumbnail"
+)
+
+// SignalAttachment is a chart image attached to a trading signal. The image and
+// its thumbnail are kept in file storage and only reachable through signed URLs.
+type SignalAttachment struct {
+	ID           int64     `json:"id" db:"id"`
+	SignalID     int64     `json:"signal_id" db:"signal_id"`
+	FileName     string    `json:"file_name" db:"file_name"`
+	ContentType  string    `json:"content_type" db:"content_type"`
+	SizeBytes    int64     `json:"size_bytes" db:"size_bytes"`
+	Width        int       `json:"width" db:"width"`
+	Height       int       `json:"height" db:"height"`
+	StorageKey   string    `json:"-" db:"storage_key"`
+	ThumbnailKey string    `json:"-" db:"thumbnail_key"`
+	UploadedBy   int64     `json:"uploaded_by" db:"uploaded_by"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+
+	// Signed URLs are set when the attachment is returned to a client
+	URL          string     `json:"url,omitempty"`
+	ThumbnailURL string     `json:"thumbnail_url,omitempty"`
+	URLExpiresAt *time.Time `json:"url_expires_at,omitempty"`
+}
@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+)
+
+// Sort fields accepted by TradingSignalFilter
+const (
+	SignalSortCreatedAt = "created_at"
+	SignalSortReturn    = "return"
+)
+
+// TradingSignalFilter narrows and orders trading signal listings.
+// Nil and empty fields are not applied.
+type TradingSignalFilter struct {
+	AssetClass   *AssetClass   `json:"asset_class" validate:"omitempty,oneof=FOREX CRYPTO PSX"`
+	DurationType *DurationType `json:"duration_type" validate:"omitempty,oneof=SHORT_TERM LONG_TERM"`
+	Symbol       string        `json:"symbol" validate:"omitempty,max=50"` // Case-insensitive prefix match
+	Type         *SignalType   `json:"type" validate:"omitempty,oneof=LONG SHORT"`
+	Result       *SignalResult `json:"result" validate:"omitempty,oneof=WIN LOSS BREAKEVEN"`
+	Status       *SignalStatus `json:"status" validate:"omitempty,oneof=PENDING ACTIVE TP_HIT SL_HIT CLOSED_MANUALLY CANCELLED EXPIRED"`
+	FreeForAll   *bool         `json:"free_for_all"`
+	CreatedBy    *int64        `json:"created_by" validate:"omitempty,gt=0"`
+	CreatedFrom  *time.Time    `json:"created_from"` // Inclusive
+	CreatedTo    *time.Time    `json:"created_to"`   // Exclusive
+
+	// IncludeLocked lists published signals the user has no access to as teasers.
+	// Only applies to user listings.
+	IncludeLocked bool `json:"include_locked"`
+
+	SortBy    string `json:"sort_by" validate:"omitempty,oneof=created_at return"` // Defaults to created_at
+	SortOrder string `json:"sort_order" validate:"omitempty,oneof=asc desc"`       // Defaults to desc
+}
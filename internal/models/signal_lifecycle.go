@@ -0,0 +1,109 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+type SignalStatus string
+
+const (
+	SignalStatusPending        SignalStatus = "PENDING"
+	SignalStatusActive         SignalStatus = "ACTIVE"
+	SignalStatusTPHit          SignalStatus = "TP_HIT"
+	SignalStatusSLHit          SignalStatus = "SL_HIT"
+	SignalStatusClosedManually SignalStatus = "CLOSED_MANUALLY"
+	SignalStatusCancelled      SignalStatus = "CANCELLED"
+	SignalStatusExpired        SignalStatus = "EXPIRED"
+)
+
+// signalStatusTransitions lists the statuses each status may move to
+var signalStatusTransitions = map[SignalStatus][]SignalStatus{
+	SignalStatusPending: {SignalStatusActive, SignalStatusCancelled, SignalStatusExpired},
+	SignalStatusActive:  {SignalStatusTPHit, SignalStatusSLHit, SignalStatusClosedManually, SignalStatusCancelled},
+}
+
+// IsTerminal reports whether no further transitions are allowed from this status
+func (s SignalStatus) IsTerminal() bool {
+	return len(signalStatusTransitions[s]) == 0
+}
+
+// IsOpen reports whether the signal is still waiting for entry or running
+func (s SignalStatus) IsOpen() bool {
+	return s == SignalStatusPending || s == SignalStatusActive
+}
+
+// CanTransitionTo reports whether moving from s to next is a valid lifecycle step
+func (s SignalStatus) CanTransitionTo(next SignalStatus) bool {
+	for _, allowed := range signalStatusTransitions[s] {
+		if allowed == next {
+			return true
+		}
+	}
+	return false
+}
+
+// ProducesResult reports whether reaching this status closes a trade with a result
+func (s SignalStatus) ProducesResult() bool {
+	return s == SignalStatusTPHit || s == SignalStatusSLHit || s == SignalStatusClosedManually
+}
+
+// PercentMove returns the direction-aware percentage move from entry to the given price,
+// rounded to two decimals. Positive values are profitable for the signal's direction.
+func (s *TradingSignal) PercentMove(price float64) float64 {
+	return math.Round(s.percentMove(price)*100) / 100
+}
+
+func (s *TradingSignal) percentMove(price float64) float64 {
+	if s.EntryPrice == 0 {
+		return 0
+	}
+	move := (price - s.EntryPrice) / s.EntryPrice * 100
+	if s.Type == SignalTypeShort {
+		move = -move
+	}
+	return move
+}
+
+// ResultForReturn derives the signal result from a realised return
+func ResultForReturn(ret float64) SignalResult {
+	switch {
+	case ret > 0:
+		return SignalResultWin
+	case ret < 0:
+		return SignalResultLoss
+	default:
+		return SignalResultBreakeven
+	}
+}
+
+// SignalStatusTransition represents a stored lifecycle change of a trading signal
+type SignalStatusTransition struct {
+	ID         int64         `json:"id" db:"id"`
+	SignalID   int64         `json:"signal_id" db:"signal_id"`
+	FromStatus *SignalStatus `json:"from_status" db:"from_status"`
+	ToStatus   SignalStatus  `json:"to_status" db:"to_status"`
+	Price      *float64      `json:"price,omitempty" db:"price"`
+	Note       *string       `json:"note,omitempty" db:"note"`
+	ChangedBy  *int64        `json:"changed_by,omitempty" db:"changed_by"`
+	CreatedAt  time.Time     `json:"created_at" db:"created_at"`
+}
+
+// SignalStatusUpdate represents a request to move a signal to a new lifecycle status
+type SignalStatusUpdate struct {
+	Status SignalStatus `json:"status" validate:"required,oneof=ACTIVE TP_HIT SL_HIT CLOSED_MANUALLY CANCELLED EXPIRED"`
+	Price  *float64     `json:"price" validate:"omitempty,gt=0"` // Fill or exit price; required for CLOSED_MANUALLY
+	Note   *string      `json:"note,omitempty"`
+}
+
+// SignalStatusChange is the fully resolved status change persisted by the repository
+type SignalStatusChange struct {
+	From      SignalStatus
+	To        SignalStatus
+	Price     *float64
+	ExitPrice *float64
+	Result    *SignalResult
+	Return    *float64
+	Note      *string
+	ChangedBy *int64
+}
@@ -0,0 +1,53 @@
+package models
+
+import "testing"
+
+func TestSignalStatusCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from SignalStatus
+		to   SignalStatus
+		want bool
+	}{
+		{SignalStatusPending, SignalStatusActive, true},
+		{SignalStatusPending, SignalStatusCancelled, true},
+		{SignalStatusPending, SignalStatusExpired, true},
+		{SignalStatusActive, SignalStatusTPHit, true},
+		{SignalStatusActive, SignalStatusSLHit, true},
+		{SignalStatusActive, SignalStatusClosedManually, true},
+		{SignalStatusActive, SignalStatusCancelled, true},
+
+		{SignalStatusPending, SignalStatusTPHit, false},
+		{SignalStatusPending, SignalStatusSLHit, false},
+		{SignalStatusPending, SignalStatusClosedManually, false},
+		{SignalStatusPending, SignalStatusPending, false},
+		{SignalStatusActive, SignalStatusPending, false},
+		{SignalStatusActive, SignalStatusExpired, false},
+		{SignalStatusActive, SignalStatusActive, false},
+		{SignalStatusTPHit, SignalStatusActive, false},
+		{SignalStatusSLHit, SignalStatusClosedManually, false},
+		{SignalStatusClosedManually, SignalStatusActive, false},
+		{SignalStatusCancelled, SignalStatusPending, false},
+		{SignalStatusExpired, SignalStatusActive, false},
+		{SignalStatus("UNKNOWN"), SignalStatusActive, false},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.from)+"->"+string(tt.to), func(t *testing.T) {
+			if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+				t.Errorf("%s.CanTransitionTo(%s) = %v, want %v", tt.from, tt.to, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignalStatusIsTerminal(t *testing.T) {
+	for _, status := range []SignalStatus{SignalStatusTPHit, SignalStatusSLHit, SignalStatusClosedManually, SignalStatusCancelled, SignalStatusExpired} {
+		if !status.IsTerminal() {
+			t.Errorf("%s.IsTerminal() = false, want true", status)
+		}
+	}
+	for _, status := range []SignalStatus{SignalStatusPending, SignalStatusActive} {
+		if status.IsTerminal() {
+			t.Errorf("%s.IsTerminal() = true, want false", status)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"time"
+)
+
+// SignalRevision is an immutable record of an edit made to a trading signal
+type SignalRevision struct {
+	ID        int64                  `json:"id" db:"id"`
+	SignalID  int64                  `json:"signal_id" db:"signal_id"`
+	Revision  int                    `json:"revision" db:"revision"` // 1 for the first edit
+	Changes   map[string]FieldChange `json:"changes" db:"changes"`
+	Reason    string                 `json:"reason" db:"reason"`
+	EditedBy  *int64                 `json:"edited_by" db:"edited_by"`
+	CreatedAt time.Time              `json:"created_at" db:"created_at"`
+}
+
+// FieldChange holds the previous and new value of an edited field
+type FieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// DiffTradingSignals returns the editable fields that differ between two
+// versions of a signal, keyed by their JSON names
+func DiffTradingSignals(before, after *TradingSignal) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	record := func(field string, from, to interface{}, changed bool) {
+		if changed {
+			changes[field] = FieldChange{From: from, To: to}
+		}
+	}
+
+	record("symbol", before.Symbol, after.Symbol, before.Symbol != after.Symbol)
+	record("asset_class", before.AssetClass, after.AssetClass, before.AssetClass != after.AssetClass)
+	record("duration_type", before.DurationType, after.DurationType, before.DurationType != after.DurationType)
+	record("type", before.Type, after.Type, before.Type != after.Type)
+	record("entry_price", before.EntryPrice, after.EntryPrice, before.EntryPrice != after.EntryPrice)
+	record("stop_loss_price", before.StopLossPrice, after.StopLossPrice, before.StopLossPrice != after.StopLossPrice)
+	record("take_profit_price", before.TakeProfitPrice, after.TakeProfitPrice, before.TakeProfitPrice != after.TakeProfitPrice)
+	record("free_for_all", before.FreeForAll, after.FreeForAll, before.FreeForAll != after.FreeForAll)
+	record("comments", before.Comments, after.Comments, !equalStringPtr(before.Comments, after.Comments))
+	record("valid_until", before.ValidUntil, after.ValidUntil, !equalTimePtr(before.ValidUntil, after.ValidUntil))
+
+	beforeTargets, afterTargets := targetLevels(before.Targets), targetLevels(after.Targets)
+	record("targets", beforeTargets, afterTargets, !equalTargetLevels(beforeTargets, afterTargets))
+
+	return changes
+}
+
+// targetLevels strips a signal's targets down to the editable values
+func targetLevels(targets []SignalTarget) []SignalTargetInput {
+	levels := make([]SignalTargetInput, len(targets))
+	for i, target := range targets {
+		levels[i] = SignalTargetInput{Price: target.Price, AllocationPercent: target.AllocationPercent}
+	}
+	return levels
+}
+
+func equalTargetLevels(a, b []SignalTargetInput) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func equalTimePtr(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
@@ -0,0 +1,35 @@
+package models
+
+import "github.com/omarshah0/rest-api-with-social-auth/internal/risk"
+
+// RiskPosition returns the signal's price levels for use with the risk package
+func (s *TradingSignal) RiskPosition() risk.Position {
+	position := risk.Position{
+		AssetClass: string(s.AssetClass),
+		Symbol:     s.Symbol,
+		Short:      s.Type == SignalTypeShort,
+		Entry:      s.EntryPrice,
+		StopLoss:   s.StopLossPrice,
+	}
+	if s.PipSize != nil {
+		position.PipSize = *s.PipSize
+	}
+
+	if len(s.Targets) == 0 {
+		position.Targets = []risk.Target{{Price: s.TakeProfitPrice, AllocationPercent: 100}}
+		return position
+	}
+	for _, target := range s.Targets {
+		position.Targets = append(position.Targets, risk.Target{
+			Price:             target.Price,
+			AllocationPercent: target.AllocationPercent,
+		})
+	}
+	return position
+}
+
+// AttachMetrics computes the signal's derived risk metrics from its current levels
+func (s *TradingSignal) AttachMetrics() {
+	metrics := risk.Calculate(s.RiskPosition())
+	s.Metrics = &metrics
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// SignalTarget represents one take-profit level of a trading signal
+type SignalTarget struct {
+	ID                int64      `json:"id" db:"id"`
+	SignalID          int64      `json:"signal_id" db:"signal_id"`
+	Position          int        `json:"position" db:"position"` // 1 for TP1, 2 for TP2, ...
+	Price             float64    `json:"price" db:"price"`
+	AllocationPercent float64    `json:"allocation_percent" db:"allocation_percent"`
+	HitAt             *time.Time `json:"hit_at" db:"hit_at"`
+}
+
+// SignalTargetInput represents a take-profit level supplied when creating or updating a signal
+type SignalTargetInput struct {
+	Price             float64 `json:"price" validate:"required,gt=0"`
+	AllocationPercent float64 `json:"allocation_percent" validate:"required,gt=0,lte=100"`
+}
+
+// IsHit reports whether the target has been reached
+func (t *SignalTarget) IsHit() bool {
+	return t.HitAt != nil
+}
+
+// HitTargetCount returns how many of the signal's targets have been reached
+func (s *TradingSignal) HitTargetCount() int {
+	count := 0
+	for i := range s.Targets {
+		if s.Targets[i].IsHit() {
+			count++
+		}
+	}
+	return count
+}
+
+// BlendedReturn returns the return of the whole position, rounded to two decimals.
+// Allocations of targets already hit are closed at their target price, partial
+// closes at their own price, and the remaining position is closed at exitPrice.
+// A partial close shrinks the allocations of the targets hit after it in
+// proportion. Signals without targets are treated as a single full-size position.
+func (s *TradingSignal) BlendedReturn(exitPrice float64) float64 {
+	if len(s.Targets) == 0 && len(s.PartialCloses) == 0 {
+		return s.PercentMove(exitPrice)
+	}
+
+	// Closes are replayed in the order they happened; targets hit at the same
+	// time as a partial close count first
+	type positionClose struct {
+		at      time.Time
+		target  *SignalTarget
+		partial *SignalPartialClose
+	}
+	var closes []positionClose
+	for i := range s.Targets {
+		if s.Targets[i].IsHit() {
+			closes = append(closes, positionClose{at: *s.Targets[i].HitAt, target: &s.Targets[i]})
+		}
+	}
+	for i := range s.PartialCloses {
+		closes = append(closes, positionClose{at: s.PartialCloses[i].ClosedAt, partial: &s.PartialCloses[i]})
+	}
+	sort.SliceStable(closes, func(i, j int) bool {
+		return closes[i].at.Before(closes[j].at)
+	})
+
+	open, scale := 100.0, 1.0
+	var blended float64
+	for _, c := range closes {
+		if c.target != nil {
+			size := math.Min(c.target.AllocationPercent*scale, open)
+			blended += size * s.percentMove(c.target.Price)
+			open -= size
+			continue
+		}
+		size := open * c.partial.Percent / 100
+		blended += size * s.percentMove(c.partial.Price)
+		open -= size
+		scale *= 1 - c.partial.Percent/100
+	}
+	if open > 0 {
+		blended += open * s.percentMove(exitPrice)
+	}
+
+	return math.Round(blended) / 100
+}
@@ -0,0 +1,102 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTradingSignalBlendedReturn(t *testing.T) {
+	t1 := time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)
+	t2 := t1.Add(time.Hour)
+	hit := func(at time.Time) *time.Time { return &at }
+
+	tests := []struct {
+		name     string
+		signal   TradingSignal
+		exit     float64
+		expected float64
+	}{
+		{
+			name:     "long without targets",
+			signal:   TradingSignal{Type: SignalTypeLong, EntryPrice: 100},
+			exit:     110,
+			expected: 10,
+		},
+		{
+			name:     "short without targets",
+			signal:   TradingSignal{Type: SignalTypeShort, EntryPrice: 100},
+			exit:     105,
+			expected: -5,
+		},
+		{
+			name: "long with every target hit",
+			signal: TradingSignal{Type: SignalTypeLong, EntryPrice: 100, Targets: []SignalTarget{
+				{Position: 1, Price: 110, AllocationPercent: 50, HitAt: hit(t1)},
+				{Position: 2, Price: 120, AllocationPercent: 50, HitAt: hit(t2)},
+			}},
+			exit:     120,
+			expected: 15,
+		},
+		{
+			name: "long stopped at breakeven after TP1",
+			signal: TradingSignal{Type: SignalTypeLong, EntryPrice: 100, Targets: []SignalTarget{
+				{Position: 1, Price: 110, AllocationPercent: 50, HitAt: hit(t1)},
+				{Position: 2, Price: 120, AllocationPercent: 50},
+			}},
+			exit:     100,
+			expected: 5,
+		},
+		{
+			name: "long partial close shrinks later targets",
+			signal: TradingSignal{
+				Type:       SignalTypeLong,
+				EntryPrice: 100,
+				Targets: []SignalTarget{
+					{Position: 1, Price: 110, AllocationPercent: 50, HitAt: hit(t2)},
+					{Position: 2, Price: 120, AllocationPercent: 50},
+				},
+				PartialCloses: []SignalPartialClose{{Percent: 50, Price: 105, ClosedAt: t1}},
+			},
+			// 50% at +5%, 25% at +10%, 25% at +20%
+			exit:     120,
+			expected: 10,
+		},
+		{
+			name: "short target then partial close",
+			signal: TradingSignal{
+				Type:       SignalTypeShort,
+				EntryPrice: 100,
+				Targets: []SignalTarget{
+					{Position: 1, Price: 90, AllocationPercent: 50, HitAt: hit(t1)},
+					{Position: 2, Price: 80, AllocationPercent: 50},
+				},
+				PartialCloses: []SignalPartialClose{{Percent: 50, Price: 95, ClosedAt: t2}},
+			},
+			// 50% at +10%, 25% at +5%, 25% at -2%
+			exit:     102,
+			expected: 5.75,
+		},
+		{
+			name: "target hit with a partial close counts first",
+			signal: TradingSignal{
+				Type:       SignalTypeLong,
+				EntryPrice: 100,
+				Targets: []SignalTarget{
+					{Position: 1, Price: 110, AllocationPercent: 50, HitAt: hit(t1)},
+					{Position: 2, Price: 120, AllocationPercent: 50},
+				},
+				PartialCloses: []SignalPartialClose{{Percent: 50, Price: 104, ClosedAt: t1}},
+			},
+			// 50% at +10%, 25% at +4%, 25% at 0%
+			exit:     100,
+			expected: 6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.signal.BlendedReturn(tt.exit); got != tt.expected {
+				t.Errorf("BlendedReturn(%v) = %v, want %v", tt.exit, got, tt.expected)
+			}
+		})
+	}
+}
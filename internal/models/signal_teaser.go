@@ -0,0 +1,35 @@
+package models
+
+import "encoding/json"
+
+// Lock turns the signal into a teaser for a user without access to it. The
+// trading levels, targets and comments are cleared so they cannot leak through
+// any response; unlockPackageIDs are the packages that would give access.
+func (s *TradingSignal) Lock(unlockPackageIDs []int64) {
+	s.Locked = true
+	s.UnlockPackageIDs = unlockPackageIDs
+	s.EntryPrice = 0
+	s.StopLossPrice = 0
+	s.TakeProfitPrice = 0
+	s.ExitPrice = nil
+	s.Comments = nil
+	s.Targets = nil
+	s.Metrics = nil
+	s.StatusHistory = nil
+	s.PartialCloses = nil
+}
+
+// MarshalJSON renders the cleared levels of a locked signal as null rather than 0
+func (s TradingSignal) MarshalJSON() ([]byte, error) {
+	type signalJSON TradingSignal
+	if !s.Locked {
+		return json.Marshal(signalJSON(s))
+	}
+
+	return json.Marshal(struct {
+		signalJSON
+		StopLossPrice   *float64 `json:"stop_loss_price"`
+		EntryPrice      *float64 `json:"entry_price"`
+		TakeProfitPrice *float64 `json:"take_profit_price"`
+	}{signalJSON: signalJSON(s)})
+}
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// SignalUpdate is an analyst post on the timeline of a trading signal, such as
+// "move SL to breakeven" or "close 50% now". Updates are append-only; their
+// optional actions are applied to the signal when the update is posted.
+type SignalUpdate struct {
+	ID           int64     `json:"id" db:"id"`
+	SignalID     int64     `json:"signal_id" db:"signal_id"`
+	Message      string    `json:"message" db:"message"`
+	NewStopLoss  *float64  `json:"new_stop_loss,omitempty" db:"new_stop_loss"`
+	ClosePercent *float64  `json:"close_percent,omitempty" db:"close_percent"`
+	ClosePrice   *float64  `json:"close_price,omitempty" db:"close_price"`
+	CreatedBy    *int64    `json:"created_by" db:"created_by"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// SignalUpdateCreate represents the data needed to post a signal update
+type SignalUpdateCreate struct {
+	Message string `json:"message" validate:"required,max=2000"`
+
+	// NewStopLoss moves the signal's stop loss, e.g. to the entry price for breakeven
+	NewStopLoss *float64 `json:"new_stop_loss" validate:"omitempty,gt=0"`
+
+	// ClosePercent closes that share of the still open position at ClosePrice.
+	// Only possible while the signal is active.
+	ClosePercent *float64 `json:"close_percent" validate:"omitempty,gt=0,lt=100"`
+	ClosePrice   *float64 `json:"close_price" validate:"omitempty,gt=0"`
+}
+
+// SignalPartialClose is a share of an open position closed by a signal update
+type SignalPartialClose struct {
+	Percent  float64   `json:"percent"` // Share of the position still open at the time
+	Price    float64   `json:"price"`
+	ClosedAt time.Time `json:"closed_at"`
+}
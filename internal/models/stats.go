@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+)
+
+// Equity curve bucket sizes
+const (
+	EquityIntervalDaily  = "daily"
+	EquityIntervalWeekly = "weekly"
+)
+
+// StatsFilter limits statistics to signals created within a date range and,
+// optionally, to the signals covered by an asset class, duration or package.
+// Nil fields are not applied.
+type StatsFilter struct {
+	From         *time.Time    `json:"from"` // Inclusive
+	To           *time.Time    `json:"to"`   // Exclusive
+	AssetClass   *AssetClass   `json:"asset_class,omitempty" validate:"omitempty,oneof=FOREX CRYPTO PSX"`
+	DurationType *DurationType `json:"duration_type,omitempty" validate:"omitempty,oneof=SHORT_TERM LONG_TERM"`
+
+	// PackageID selects the asset class and duration covered by a package
+	PackageID *int64 `json:"package_id,omitempty" validate:"omitempty,gt=0"`
+}
+
+// TradeSummary identifies a single closed trade in statistics
+type TradeSummary struct {
+	SignalID int64   `json:"signal_id"`
+	Symbol   string  `json:"symbol"`
+	Return   float64 `json:"return"`
+}
+
+// PerformanceStats are the track record figures of a group of signals
+type PerformanceStats struct {
+	AssetClass           AssetClass    `json:"asset_class,omitempty"`
+	DurationType         DurationType  `json:"duration_type,omitempty"`
+	TotalSignals         int           `json:"total_signals"`  // All signals, including open and cancelled ones
+	ClosedSignals        int           `json:"closed_signals"` // Signals with a result
+	Wins                 int           `json:"wins"`
+	Losses               int           `json:"losses"`
+	Breakevens           int           `json:"breakevens"`
+	WinRate              float64       `json:"win_rate"`       // Percent of closed signals
+	AverageReturn        float64       `json:"average_return"` // Percent per closed signal
+	ProfitFactor         *float64      `json:"profit_factor"`  // Gross profit over gross loss; null without losses
+	Expectancy           float64       `json:"expectancy"`     // Average result in R (multiples of the risk taken)
+	AverageRiskReward    float64       `json:"average_risk_reward"`
+	BestTrade            *TradeSummary `json:"best_trade"`
+	WorstTrade           *TradeSummary `json:"worst_trade"`
+	MaxConsecutiveLosses int           `json:"max_consecutive_losses"`
+}
+
+// PerformanceReport groups performance statistics by asset class and duration
+type PerformanceReport struct {
+	StatsFilter
+	Overall     PerformanceStats   `json:"overall"`
+	Groups      []PerformanceStats `json:"groups"`
+	GeneratedAt time.Time          `json:"generated_at"`
+}
+
+// EquityCurve is the cumulative return of following every closed signal, with
+// one point per bucket. Returns are added rather than compounded, so each
+// signal counts as an equal-sized position; drawdowns are in percentage points.
+type EquityCurve struct {
+	StatsFilter
+	Interval          string          `json:"interval"`
+	Trades            int             `json:"trades"`
+	TotalReturn       float64         `json:"total_return"`
+	MaxDrawdown       float64         `json:"max_drawdown"`
+	MaxDrawdownPeak   *time.Time      `json:"max_drawdown_peak"`   // When the equity peaked before the deepest drawdown
+	MaxDrawdownTrough *time.Time      `json:"max_drawdown_trough"` // When the deepest drawdown bottomed out
+	MaxDrawdownDays   float64         `json:"max_drawdown_days"`   // Longest time spent below a previous peak
+	CurrentDrawdown   float64         `json:"current_drawdown"`
+	Points            []EquityPoint   `json:"points"`
+	MonthlyReturns    []MonthlyReturn `json:"monthly_returns"`
+	GeneratedAt       time.Time       `json:"generated_at"`
+}
+
+// EquityPoint is the state of the equity curve at the end of a bucket
+type EquityPoint struct {
+	Date             time.Time `json:"date"` // Start of the bucket
+	Return           float64   `json:"return"`
+	CumulativeReturn float64   `json:"cumulative_return"`
+	Drawdown         float64   `json:"drawdown"`
+	Trades           int       `json:"trades"`
+}
+
+// MonthlyReturn is the summed return of the signals closed in a calendar month
+type MonthlyReturn struct {
+	Month  string  `json:"month"` // YYYY-MM
+	Return float64 `json:"return"`
+	Trades int     `json:"trades"`
+	Wins   int     `json:"wins"`
+}
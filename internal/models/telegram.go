@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+)
+
+// TelegramLink is the Telegram chat a user linked to their account, which
+// receives their signals as direct messages from the bot
+type TelegramLink struct {
+	UserID   int64     `json:"user_id"`
+	ChatID   int64     `json:"chat_id"`
+	Username *string   `json:"username,omitempty"`
+	LinkedAt time.Time `json:"linked_at"`
+}
+
+// TelegramLinkToken is a one-time token linking the Telegram chat that sends it
+// to the bot to the user it was issued to
+type TelegramLinkToken struct {
+	Token     string    `json:"token"`
+	URL       string    `json:"url"` // Deep link starting the bot with the token
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// TelegramUpdate is an incoming bot update. Only the fields the bot handles are
+// decoded.
+type TelegramUpdate struct {
+	UpdateID        int64                    `json:"update_id"`
+	Message         *TelegramMessage         `json:"message"`
+	ChatJoinRequest *TelegramChatJoinRequest `json:"chat_join_request"`
+}
+
+// TelegramMessage is a message sent to the bot
+type TelegramMessage struct {
+	MessageID int64         `json:"message_id"`
+	From      *TelegramUser `json:"from"`
+	Chat      TelegramChat  `json:"chat"`
+	Text      string        `json:"text"`
+}
+
+// TelegramChatJoinRequest is a request to join a private chat through one of
+// the bot's invite links
+type TelegramChatJoinRequest struct {
+	Chat TelegramChat `json:"chat"`
+	From TelegramUser `json:"from"`
+}
+
+// TelegramUser is the sender of a message
+type TelegramUser struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username"`
+}
+
+// TelegramChat is the chat a message was sent in
+type TelegramChat struct {
+	ID   int64  `json:"id"`
+	Type string `json:"type"` // private, group, supergroup or channel
+}
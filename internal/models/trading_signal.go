@@ -2,6 +2,8 @@ package models
 
 import (
 	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/risk"
 )
 
 type SignalType string
@@ -40,28 +42,65 @@ type TradingSignal struct {
 	EntryPrice      float64       `json:"entry_price" db:"entry_price" validate:"required,gt=0"`
 	TakeProfitPrice float64       `json:"take_profit_price" db:"take_profit_price" validate:"required,gt=0"`
 	Type            SignalType    `json:"type" db:"type" validate:"required,oneof=LONG SHORT"`
+	Status          SignalStatus  `json:"status" db:"status"`
+	ExitPrice       *float64      `json:"exit_price" db:"exit_price"`
 	Result          *SignalResult `json:"result" db:"result" validate:"omitempty,oneof=WIN LOSS BREAKEVEN"`
 	Return          *float64      `json:"return" db:"return"`
 	FreeForAll      bool          `json:"free_for_all" db:"free_for_all"`
 	Comments        *string       `json:"comments,omitempty" db:"comments"`
 	CreatedBy       int64         `json:"created_by" db:"created_by"`
+	ActivatedAt     *time.Time    `json:"activated_at" db:"activated_at"`
+	ClosedAt        *time.Time    `json:"closed_at" db:"closed_at"`
+	PublishAt       *time.Time    `json:"publish_at" db:"publish_at"`     // Scheduled publication time, nil when published on creation
+	PublishedAt     *time.Time    `json:"published_at" db:"published_at"` // Nil while the signal is scheduled and hidden from subscribers
+	ValidUntil      *time.Time    `json:"valid_until" db:"valid_until"`   // A pending signal expires if entry is not reached by then
 	CreatedAt       time.Time     `json:"created_at" db:"created_at"`
 	UpdatedAt       time.Time     `json:"updated_at" db:"updated_at"`
+
+	// PipSize is the pip size of the signal's instrument in the catalog, nil when
+	// it is not listed or has none; metrics then derive it from the symbol
+	PipSize *float64 `json:"pip_size,omitempty" db:"pip_size"`
+
+	Targets []SignalTarget `json:"targets"`
+
+	// Metrics are derived from the price levels and are not stored
+	Metrics *risk.Metrics `json:"metrics,omitempty"`
+
+	// StatusHistory is only populated when a single signal is retrieved
+	StatusHistory []SignalStatusTransition `json:"status_history,omitempty"`
+
+	// PartialCloses made through signal updates; loaded with a single signal and
+	// whenever its return is computed
+	PartialCloses []SignalPartialClose `json:"partial_closes,omitempty"`
+
+	// Locked marks a teaser of a signal the user has no access to, see Lock
+	Locked           bool    `json:"locked,omitempty"`
+	UnlockPackageIDs []int64 `json:"unlock_package_ids,omitempty"`
 }
 
 // TradingSignalCreate represents the data needed to create a new trading signal
 type TradingSignalCreate struct {
-	Symbol          string        `json:"symbol" validate:"required"`
-	AssetClass      AssetClass    `json:"asset_class" validate:"required,oneof=FOREX CRYPTO PSX"`
-	DurationType    DurationType  `json:"duration_type" validate:"required,oneof=SHORT_TERM LONG_TERM"`
-	StopLossPrice   float64       `json:"stop_loss_price" validate:"required,gt=0"`
-	EntryPrice      float64       `json:"entry_price" validate:"required,gt=0"`
-	TakeProfitPrice float64       `json:"take_profit_price" validate:"required,gt=0"`
-	Type            SignalType    `json:"type" validate:"required,oneof=LONG SHORT"`
-	Result          *SignalResult `json:"result" validate:"omitempty,oneof=WIN LOSS BREAKEVEN"`
-	Return          *float64      `json:"return"`
-	FreeForAll      bool          `json:"free_for_all"`
-	Comments        *string       `json:"comments,omitempty"`
+	Symbol          string       `json:"symbol" validate:"required"`
+	AssetClass      AssetClass   `json:"asset_class" validate:"required,oneof=FOREX CRYPTO PSX"`
+	DurationType    DurationType `json:"duration_type" validate:"required,oneof=SHORT_TERM LONG_TERM"`
+	StopLossPrice   float64      `json:"stop_loss_price" validate:"required,gt=0"`
+	EntryPrice      float64      `json:"entry_price" validate:"required,gt=0"`
+	TakeProfitPrice float64      `json:"take_profit_price" validate:"omitempty,gt=0"` // Required unless targets are given
+	Type            SignalType   `json:"type" validate:"required,oneof=LONG SHORT"`
+	Status          SignalStatus `json:"status" validate:"omitempty,oneof=PENDING ACTIVE"` // Defaults to PENDING
+	FreeForAll      bool         `json:"free_for_all"`
+	Comments        *string      `json:"comments,omitempty"`
+
+	// PublishAt schedules publication; the signal is hidden and subscribers are not
+	// notified until then. Omitted or past times publish immediately.
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+
+	// ValidUntil expires the signal if entry has not been reached by then
+	ValidUntil *time.Time `json:"valid_until,omitempty"`
+
+	// Targets are the ordered take-profit levels (TP1, TP2, ...). The last target
+	// becomes the signal's take_profit_price.
+	Targets []SignalTargetInput `json:"targets,omitempty" validate:"omitempty,max=10,dive"`
 }
 
 // TradingSignalUpdate represents the data needed to update a trading signal
@@ -73,9 +112,20 @@ type TradingSignalUpdate struct {
 	EntryPrice      *float64      `json:"entry_price" validate:"omitempty,gt=0"`
 	TakeProfitPrice *float64      `json:"take_profit_price" validate:"omitempty,gt=0"`
 	Type            *SignalType   `json:"type" validate:"omitempty,oneof=LONG SHORT"`
-	Result          *SignalResult `json:"result" validate:"omitempty,oneof=WIN LOSS BREAKEVEN"`
-	Return          *float64      `json:"return"`
 	FreeForAll      *bool         `json:"free_for_all"`
 	Comments        *string       `json:"comments,omitempty"`
+	ValidUntil      *time.Time    `json:"valid_until,omitempty"` // Only while the signal is pending
+
+	// Targets replaces all take-profit levels when provided
+	Targets []SignalTargetInput `json:"targets,omitempty" validate:"omitempty,max=10,dive"`
+
+	// Reason optionally explains the edit to subscribers and is stored with the
+	// revision, empty when not given
+	Reason string `json:"reason" validate:"omitempty,max=500"`
 }
 
+// SignalCategoryKey identifies the asset class and duration combination that
+// packages are sold for, e.g. "FOREX_SHORT_TERM"
+func SignalCategoryKey(assetClass AssetClass, durationType DurationType) string {
+	return string(assetClass) + "_" + string(durationType)
+}
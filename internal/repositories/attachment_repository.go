@@ -0,0 +1,124 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+type AttachmentRepository struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+// attachmentColumns is the column list selected for every attachment query
+const attachmentColumns = `id, signal_id, file_name, content_type, size_bytes, width, height,
+	storage_key, thumbnail_key, uploaded_by, created_at`
+
+// scanAttachment scans a row selected with attachmentColumns
+func scanAttachment(row rowScanner) (*models.SignalAttachment, error) {
+	var attachment models.SignalAttachment
+	err := row.Scan(
+		&attachment.ID,
+		&attachment.SignalID,
+		&attachment.FileName,
+		&attachment.ContentType,
+		&attachment.SizeBytes,
+		&attachment.Width,
+		&attachment.Height,
+		&attachment.StorageKey,
+		&attachment.ThumbnailKey,
+		&attachment.UploadedBy,
+		&attachment.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &attachment, nil
+}
+
+// Create records an attachment whose files have been stored
+func (r *AttachmentRepository) Create(attachment *models.SignalAttachment) (*models.SignalAttachment, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO signal_attachments (signal_id, file_name, content_type, size_bytes, width, height, storage_key, thumbnail_key, uploaded_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING %s
+	`, attachmentColumns)
+
+	newAttachment, err := scanAttachment(r.db.QueryRow(
+		query,
+		attachment.SignalID,
+		attachment.FileName,
+		attachment.ContentType,
+		attachment.SizeBytes,
+		attachment.Width,
+		attachment.Height,
+		attachment.StorageKey,
+		attachment.ThumbnailKey,
+		attachment.UploadedBy,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	return newAttachment, nil
+}
+
+// GetByID retrieves an attachment by ID
+func (r *AttachmentRepository) GetByID(id int64) (*models.SignalAttachment, error) {
+	query := fmt.Sprintf(`SELECT %s FROM signal_attachments WHERE id = $1`, attachmentColumns)
+
+	attachment, err := scanAttachment(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return attachment, nil
+}
+
+// GetBySignal retrieves the attachments of a signal in upload order
+func (r *AttachmentRepository) GetBySignal(signalID int64) ([]models.SignalAttachment, error) {
+	query := fmt.Sprintf(`SELECT %s FROM signal_attachments WHERE signal_id = $1 ORDER BY id`, attachmentColumns)
+
+	rows, err := r.db.Query(query, signalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
+	}
+	defer rows.Close()
+
+	attachments := []models.SignalAttachment{}
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, *attachment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate attachments: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// Delete removes an attachment record. It reports whether the attachment existed.
+func (r *AttachmentRepository) Delete(id int64) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM signal_attachments WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
@@ -0,0 +1,361 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+// membershipReconcileLock is the advisory lock key held while memberships are
+// reconciled, so instances never act on the same membership twice
+const membershipReconcileLock = 240027
+
+// ChannelMembershipRepository stores the access of users to private chats and
+// roles, along with its audit trail
+type ChannelMembershipRepository struct {
+	db *sql.DB
+}
+
+func NewChannelMembershipRepository(db *sql.DB) *ChannelMembershipRepository {
+	return &ChannelMembershipRepository{db: db}
+}
+
+// membershipColumns is the column list selected for every membership query
+const membershipColumns = `id, user_id, platform, target_id, member_id, status, invite_link, invite_expires_at, created_at, updated_at`
+
+// scanMembership scans a row selected with membershipColumns
+func scanMembership(row rowScanner) (*models.ChannelMembership, error) {
+	var membership models.ChannelMembership
+	err := row.Scan(
+		&membership.ID,
+		&membership.UserID,
+		&membership.Platform,
+		&membership.TargetID,
+		&membership.MemberID,
+		&membership.Status,
+		&membership.InviteLink,
+		&membership.InviteExpiresAt,
+		&membership.CreatedAt,
+		&membership.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// desiredMemberships selects the memberships users should have: one per active
+// subscriber who is not blocked and channel of their packages, with the account
+// they linked on the channel's platform, NULL if none
+const desiredMemberships = `
+	SELECT DISTINCT s.user_id, pc.platform, pc.target_id,
+		CASE pc.platform WHEN 'TELEGRAM' THEN tl.chat_id::text ELSE dl.discord_user_id END AS member_id
+	FROM user_subscriptions s
+	JOIN users u ON u.id = s.user_id
+	JOIN package_channels pc ON pc.package_id = s.package_id
+	LEFT JOIN telegram_links tl ON tl.user_id = s.user_id
+	LEFT JOIN discord_links dl ON dl.user_id = s.user_id
+	WHERE s.is_active = true AND s.expires_at > CURRENT_TIMESTAMP AND u.blocked = false
+`
+
+// queryMemberships runs a query selecting membershipColumns
+func (r *ChannelMembershipRepository) queryMemberships(query string, args ...interface{}) ([]models.ChannelMembership, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel memberships: %w", err)
+	}
+	defer rows.Close()
+
+	memberships := []models.ChannelMembership{}
+	for rows.Next() {
+		membership, err := scanMembership(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan channel membership: %w", err)
+		}
+		memberships = append(memberships, *membership)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate channel memberships: %w", err)
+	}
+	return memberships, nil
+}
+
+// GetMissing retrieves the memberships on platforms that users should have but
+// do not, of every user or only of userID. Only the user, channel and member are
+// set.
+func (r *ChannelMembershipRepository) GetMissing(platforms []string, userID *int64) ([]models.ChannelMembership, error) {
+	conditions := []string{
+		"d.member_id IS NOT NULL",
+		"d.platform = ANY($1)",
+		`NOT EXISTS (
+			SELECT 1 FROM channel_memberships m
+			WHERE m.user_id = d.user_id AND m.platform = d.platform AND m.target_id = d.target_id AND m.status <> 'REMOVED'
+		)`,
+	}
+	args := []interface{}{pq.Array(platforms)}
+	if userID != nil {
+		args = append(args, *userID)
+		conditions = append(conditions, fmt.Sprintf("d.user_id = $%d", len(args)))
+	}
+
+	query := fmt.Sprintf(`
+		SELECT d.user_id, d.platform, d.target_id, d.member_id
+		FROM (%s) d
+		%s
+		ORDER BY d.user_id, d.platform, d.target_id
+	`, desiredMemberships, whereClause(conditions))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get missing channel memberships: %w", err)
+	}
+	defer rows.Close()
+
+	var memberships []models.ChannelMembership
+	for rows.Next() {
+		var membership models.ChannelMembership
+		if err := rows.Scan(&membership.UserID, &membership.Platform, &membership.TargetID, &membership.MemberID); err != nil {
+			return nil, fmt.Errorf("failed to scan missing channel membership: %w", err)
+		}
+		memberships = append(memberships, membership)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate missing channel memberships: %w", err)
+	}
+	return memberships, nil
+}
+
+// GetStale retrieves the memberships on platforms users no longer should have:
+// their subscription ended, they were blocked, the channel was taken off their
+// package or they linked another account
+func (r *ChannelMembershipRepository) GetStale(platforms []string) ([]models.ChannelMembership, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM channel_memberships m
+		WHERE m.status <> 'REMOVED' AND m.platform = ANY($1)
+		AND NOT EXISTS (
+			SELECT 1 FROM (%s) d
+			WHERE d.user_id = m.user_id AND d.platform = m.platform AND d.target_id = m.target_id AND d.member_id = m.member_id
+		)
+		ORDER BY m.id
+	`, membershipColumns, desiredMemberships)
+
+	return r.queryMemberships(query, pq.Array(platforms))
+}
+
+// GetPending retrieves the memberships on platforms waiting for the user to join
+func (r *ChannelMembershipRepository) GetPending(platforms []string) ([]models.ChannelMembership, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM channel_memberships
+		WHERE status = 'PENDING' AND platform = ANY($1)
+		ORDER BY id
+	`, membershipColumns)
+
+	return r.queryMemberships(query, pq.Array(platforms))
+}
+
+// GetByUserID retrieves the current memberships of a user
+func (r *ChannelMembershipRepository) GetByUserID(userID int64) ([]models.ChannelMembership, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM channel_memberships
+		WHERE user_id = $1 AND status <> 'REMOVED'
+		ORDER BY id
+	`, membershipColumns)
+
+	return r.queryMemberships(query, userID)
+}
+
+// GetByID retrieves a membership by ID
+func (r *ChannelMembershipRepository) GetByID(id int64) (*models.ChannelMembership, error) {
+	query := fmt.Sprintf(`SELECT %s FROM channel_memberships WHERE id = $1`, membershipColumns)
+
+	membership, err := scanMembership(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel membership: %w", err)
+	}
+	return membership, nil
+}
+
+// GetByMember retrieves the current membership of the account memberID in a
+// channel, nil if it has none
+func (r *ChannelMembershipRepository) GetByMember(platform models.ChannelPlatform, targetID, memberID string) (*models.ChannelMembership, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM channel_memberships
+		WHERE platform = $1 AND target_id = $2 AND member_id = $3 AND status <> 'REMOVED'
+		ORDER BY id
+		LIMIT 1
+	`, membershipColumns)
+
+	membership, err := scanMembership(r.db.QueryRow(query, string(platform), targetID, memberID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel membership: %w", err)
+	}
+	return membership, nil
+}
+
+// Save stores the state of a user's access to a channel
+func (r *ChannelMembershipRepository) Save(membership *models.ChannelMembership) (*models.ChannelMembership, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO channel_memberships (user_id, platform, target_id, member_id, status, invite_link, invite_expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, platform, target_id) DO UPDATE
+		SET member_id = EXCLUDED.member_id,
+			status = EXCLUDED.status,
+			invite_link = EXCLUDED.invite_link,
+			invite_expires_at = EXCLUDED.invite_expires_at,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING %s
+	`, membershipColumns)
+
+	saved, err := scanMembership(r.db.QueryRow(
+		query,
+		membership.UserID,
+		membership.Platform,
+		membership.TargetID,
+		membership.MemberID,
+		membership.Status,
+		membership.InviteLink,
+		membership.InviteExpiresAt,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save channel membership: %w", err)
+	}
+	return saved, nil
+}
+
+// LogEvent appends an entry to the audit trail
+func (r *ChannelMembershipRepository) LogEvent(event *models.MembershipEvent) error {
+	query := `
+		INSERT INTO channel_membership_events (membership_id, user_id, platform, target_id, action, triggered_by, detail, admin_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.db.Exec(
+		query,
+		event.MembershipID,
+		event.UserID,
+		event.Platform,
+		event.TargetID,
+		event.Action,
+		event.TriggeredBy,
+		event.Detail,
+		event.AdminID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to log channel membership event: %w", err)
+	}
+	return nil
+}
+
+// eventFilterConditions returns the WHERE conditions of filter, appending their
+// values to args
+func eventFilterConditions(filter *models.MembershipEventFilter, args []interface{}) ([]string, []interface{}) {
+	var conditions []string
+	if filter.UserID != nil {
+		args = append(args, *filter.UserID)
+		conditions = append(conditions, fmt.Sprintf("user_id = $%d", len(args)))
+	}
+	if filter.Platform != nil {
+		args = append(args, *filter.Platform)
+		conditions = append(conditions, fmt.Sprintf("platform = $%d", len(args)))
+	}
+	if filter.Action != nil {
+		args = append(args, *filter.Action)
+		conditions = append(conditions, fmt.Sprintf("action = $%d", len(args)))
+	}
+	return conditions, args
+}
+
+// GetEvents retrieves audit trail entries matching filter, newest first
+func (r *ChannelMembershipRepository) GetEvents(filter *models.MembershipEventFilter, limit, offset int) ([]models.MembershipEvent, error) {
+	conditions, args := eventFilterConditions(filter, nil)
+	args = append(args, limit, offset)
+
+	query := fmt.Sprintf(`
+		SELECT id, membership_id, user_id, platform, target_id, action, triggered_by, detail, admin_id, created_at
+		FROM channel_membership_events
+		%s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, whereClause(conditions), len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel membership events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.MembershipEvent{}
+	for rows.Next() {
+		var event models.MembershipEvent
+		err := rows.Scan(
+			&event.ID,
+			&event.MembershipID,
+			&event.UserID,
+			&event.Platform,
+			&event.TargetID,
+			&event.Action,
+			&event.TriggeredBy,
+			&event.Detail,
+			&event.AdminID,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan channel membership event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate channel membership events: %w", err)
+	}
+	return events, nil
+}
+
+// CountEvents returns the number of audit trail entries matching filter
+func (r *ChannelMembershipRepository) CountEvents(filter *models.MembershipEventFilter) (int64, error) {
+	conditions, args := eventFilterConditions(filter, nil)
+	query := `SELECT COUNT(*) FROM channel_membership_events ` + whereClause(conditions)
+
+	var count int64
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count channel membership events: %w", err)
+	}
+	return count, nil
+}
+
+// Lock takes the reconciliation lock. It returns false if another instance holds
+// it, otherwise the function releasing it.
+func (r *ChannelMembershipRepository) Lock(ctx context.Context) (func(), bool, error) {
+	conn, err := r.db.Conn(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get connection: %w", err)
+	}
+
+	var locked bool
+	if err := conn.QueryRowContext(ctx, `SELECT pg_try_advisory_lock($1)`, membershipReconcileLock).Scan(&locked); err != nil {
+		conn.Close()
+		return nil, false, fmt.Errorf("failed to take reconciliation lock: %w", err)
+	}
+	if !locked {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, membershipReconcileLock)
+		conn.Close()
+	}
+	return unlock, true, nil
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+// DiscordLinkRepository stores the Discord accounts users linked to their account
+type DiscordLinkRepository struct {
+	db *sql.DB
+}
+
+func NewDiscordLinkRepository(db *sql.DB) *DiscordLinkRepository {
+	return &DiscordLinkRepository{db: db}
+}
+
+// discordLinkColumns is the column list selected for every link query
+const discordLinkColumns = `user_id, discord_user_id, username, linked_at`
+
+// scanDiscordLink scans a row selected with discordLinkColumns
+func scanDiscordLink(row rowScanner) (*models.DiscordLink, error) {
+	var link models.DiscordLink
+	if err := row.Scan(&link.UserID, &link.DiscordUserID, &link.Username, &link.LinkedAt); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// Link links a Discord account to a user, replacing the user's previous account.
+// An account linked to another user before is moved over.
+func (r *DiscordLinkRepository) Link(userID int64, discordUserID string, username *string) (*models.DiscordLink, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM discord_links WHERE discord_user_id = $1 AND user_id <> $2`, discordUserID, userID); err != nil {
+		return nil, fmt.Errorf("failed to release discord account: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO discord_links (user_id, discord_user_id, username)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET discord_user_id = EXCLUDED.discord_user_id, username = EXCLUDED.username, linked_at = CURRENT_TIMESTAMP
+		RETURNING %s
+	`, discordLinkColumns)
+
+	link, err := scanDiscordLink(tx.QueryRow(query, userID, discordUserID, username))
+	if err != nil {
+		return nil, fmt.Errorf("failed to link discord account: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit discord link: %w", err)
+	}
+	return link, nil
+}
+
+// GetByUserID retrieves the Discord account linked to a user, nil if none is
+func (r *DiscordLinkRepository) GetByUserID(userID int64) (*models.DiscordLink, error) {
+	query := fmt.Sprintf(`SELECT %s FROM discord_links WHERE user_id = $1`, discordLinkColumns)
+
+	link, err := scanDiscordLink(r.db.QueryRow(query, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get discord link: %w", err)
+	}
+	return link, nil
+}
+
+// Delete unlinks the Discord account of a user. It returns false if none is linked.
+func (r *DiscordLinkRepository) Delete(userID int64) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM discord_links WHERE user_id = $1`, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete discord link: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
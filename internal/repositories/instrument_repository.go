@@ -0,0 +1,332 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/marketdata"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+type InstrumentRepository struct {
+	db *sql.DB
+}
+
+func NewInstrumentRepository(db *sql.DB) *InstrumentRepository {
+	return &InstrumentRepository{db: db}
+}
+
+// instrumentColumns is the column list selected for every instrument query
+const instrumentColumns = `id, symbol, asset_class, display_name, tick_size, pip_size, lot_size, quote_currency,
+	sector, is_active, created_at, updated_at`
+
+// scanInstrument scans a row selected with instrumentColumns
+func scanInstrument(row rowScanner) (*models.Instrument, error) {
+	var instrument models.Instrument
+	err := row.Scan(
+		&instrument.ID,
+		&instrument.Symbol,
+		&instrument.AssetClass,
+		&instrument.DisplayName,
+		&instrument.TickSize,
+		&instrument.PipSize,
+		&instrument.LotSize,
+		&instrument.QuoteCurrency,
+		&instrument.Sector,
+		&instrument.IsActive,
+		&instrument.CreatedAt,
+		&instrument.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &instrument, nil
+}
+
+// Create adds an instrument to the catalog
+func (r *InstrumentRepository) Create(instrument *models.InstrumentCreate) (*models.Instrument, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO instruments (symbol, asset_class, display_name, tick_size, pip_size, lot_size, quote_currency, sector)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING %s
+	`, instrumentColumns)
+
+	newInstrument, err := scanInstrument(r.db.QueryRow(
+		query,
+		instrument.Symbol,
+		instrument.AssetClass,
+		instrument.DisplayName,
+		instrument.TickSize,
+		instrument.PipSize,
+		instrument.LotSize,
+		instrument.QuoteCurrency,
+		instrument.Sector,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create instrument: %w", err)
+	}
+
+	return newInstrument, nil
+}
+
+// Upsert creates an instrument or, when its symbol already exists in the asset
+// class, replaces its details and reactivates it. It reports whether a new row was created.
+func (r *InstrumentRepository) Upsert(instrument *models.InstrumentCreate) (*models.Instrument, bool, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO instruments (symbol, asset_class, display_name, tick_size, pip_size, lot_size, quote_currency, sector)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (asset_class, symbol) DO UPDATE SET
+			display_name = EXCLUDED.display_name,
+			tick_size = EXCLUDED.tick_size,
+			pip_size = EXCLUDED.pip_size,
+			lot_size = EXCLUDED.lot_size,
+			quote_currency = EXCLUDED.quote_currency,
+			sector = EXCLUDED.sector,
+			is_active = true,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING %s, (xmax = 0) AS created
+	`, instrumentColumns)
+
+	var upserted models.Instrument
+	var created bool
+	err := r.db.QueryRow(
+		query,
+		instrument.Symbol,
+		instrument.AssetClass,
+		instrument.DisplayName,
+		instrument.TickSize,
+		instrument.PipSize,
+		instrument.LotSize,
+		instrument.QuoteCurrency,
+		instrument.Sector,
+	).Scan(
+		&upserted.ID,
+		&upserted.Symbol,
+		&upserted.AssetClass,
+		&upserted.DisplayName,
+		&upserted.TickSize,
+		&upserted.PipSize,
+		&upserted.LotSize,
+		&upserted.QuoteCurrency,
+		&upserted.Sector,
+		&upserted.IsActive,
+		&upserted.CreatedAt,
+		&upserted.UpdatedAt,
+		&created,
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to upsert instrument: %w", err)
+	}
+
+	return &upserted, created, nil
+}
+
+// CreateIfMissing adds an instrument unless its symbol already exists in the
+// asset class, leaving existing rows untouched. It reports whether a row was created.
+func (r *InstrumentRepository) CreateIfMissing(instrument *models.InstrumentCreate) (bool, error) {
+	query := `
+		INSERT INTO instruments (symbol, asset_class, display_name, tick_size, pip_size, lot_size, quote_currency, sector)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (asset_class, symbol) DO NOTHING
+	`
+
+	result, err := r.db.Exec(
+		query,
+		instrument.Symbol,
+		instrument.AssetClass,
+		instrument.DisplayName,
+		instrument.TickSize,
+		instrument.PipSize,
+		instrument.LotSize,
+		instrument.QuoteCurrency,
+		instrument.Sector,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to create instrument: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to create instrument: %w", err)
+	}
+
+	return rows > 0, nil
+}
+
+// GetByID retrieves an instrument by ID
+func (r *InstrumentRepository) GetByID(id int64) (*models.Instrument, error) {
+	query := fmt.Sprintf(`SELECT %s FROM instruments WHERE id = $1`, instrumentColumns)
+
+	instrument, err := scanInstrument(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instrument: %w", err)
+	}
+
+	return instrument, nil
+}
+
+// GetBySymbol retrieves an instrument by its canonical symbol within an asset class
+func (r *InstrumentRepository) GetBySymbol(assetClass models.AssetClass, symbol string) (*models.Instrument, error) {
+	query := fmt.Sprintf(`SELECT %s FROM instruments WHERE asset_class = $1 AND symbol = $2`, instrumentColumns)
+
+	instrument, err := scanInstrument(r.db.QueryRow(query, assetClass, symbol))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instrument: %w", err)
+	}
+
+	return instrument, nil
+}
+
+// GetAll retrieves the instruments matching filter ordered by asset class and symbol
+func (r *InstrumentRepository) GetAll(filter *models.InstrumentFilter, limit, offset int) ([]models.Instrument, error) {
+	conditions, args := instrumentFilterConditions(filter)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM instruments
+		%s
+		ORDER BY asset_class, symbol
+	`, instrumentColumns, whereClause(conditions))
+
+	if limit > 0 {
+		args = append(args, limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	if offset > 0 {
+		args = append(args, offset)
+		query += fmt.Sprintf(" OFFSET $%d", len(args))
+	}
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get instruments: %w", err)
+	}
+	defer rows.Close()
+
+	instruments := []models.Instrument{}
+	for rows.Next() {
+		instrument, err := scanInstrument(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan instrument: %w", err)
+		}
+		instruments = append(instruments, *instrument)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate instruments: %w", err)
+	}
+
+	return instruments, nil
+}
+
+// Count returns the total count of instruments matching filter
+func (r *InstrumentRepository) Count(filter *models.InstrumentFilter) (int64, error) {
+	conditions, args := instrumentFilterConditions(filter)
+
+	var count int64
+	query := `SELECT COUNT(*) FROM instruments ` + whereClause(conditions)
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count instruments: %w", err)
+	}
+	return count, nil
+}
+
+// instrumentFilterConditions returns the WHERE conditions and arguments for filter
+func instrumentFilterConditions(filter *models.InstrumentFilter) ([]string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+	if filter == nil {
+		return conditions, args
+	}
+
+	if filter.AssetClass != nil {
+		args = append(args, *filter.AssetClass)
+		conditions = append(conditions, fmt.Sprintf("asset_class = $%d", len(args)))
+	}
+	if filter.Search != "" {
+		args = append(args, escapeLike(marketdata.NormalizeSymbol(filter.Search))+"%", "%"+escapeLike(strings.ToUpper(filter.Search))+"%")
+		conditions = append(conditions, fmt.Sprintf(`(symbol LIKE $%d ESCAPE '\' OR UPPER(display_name) LIKE $%d ESCAPE '\')`, len(args)-1, len(args)))
+	}
+	if filter.ActiveOnly {
+		conditions = append(conditions, "is_active = true")
+	}
+	return conditions, args
+}
+
+// Update updates an instrument's details. Symbol and asset class cannot change
+// as existing signals refer to them.
+func (r *InstrumentRepository) Update(id int64, update *models.InstrumentUpdate) (*models.Instrument, error) {
+	var setClauses []string
+	var args []interface{}
+	set := func(column string, value interface{}) {
+		args = append(args, value)
+		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+
+	if update.DisplayName != nil {
+		set("display_name", *update.DisplayName)
+	}
+	if update.TickSize != nil {
+		set("tick_size", *update.TickSize)
+	}
+	if update.PipSize != nil {
+		set("pip_size", *update.PipSize)
+	}
+	if update.LotSize != nil {
+		set("lot_size", *update.LotSize)
+	}
+	if update.QuoteCurrency != nil {
+		set("quote_currency", *update.QuoteCurrency)
+	}
+	if update.Sector != nil {
+		set("sector", *update.Sector)
+	}
+	if update.IsActive != nil {
+		set("is_active", *update.IsActive)
+	}
+
+	if len(setClauses) == 0 {
+		return r.GetByID(id)
+	}
+
+	setClauses = append(setClauses, "updated_at = CURRENT_TIMESTAMP")
+	args = append(args, id)
+
+	query := fmt.Sprintf(`
+		UPDATE instruments
+		SET %s
+		WHERE id = $%d
+		RETURNING %s
+	`, strings.Join(setClauses, ", "), len(args), instrumentColumns)
+
+	instrument, err := scanInstrument(r.db.QueryRow(query, args...))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update instrument: %w", err)
+	}
+
+	return instrument, nil
+}
+
+// Delete removes an instrument from the catalog. It reports whether the instrument existed.
+func (r *InstrumentRepository) Delete(id int64) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM instruments WHERE id = $1`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete instrument: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	return rows > 0, nil
+}
@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+// NotificationDigestRepository holds personal notifications back until a user's
+// digest time or the end of their quiet hours
+type NotificationDigestRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationDigestRepository(db *sql.DB) *NotificationDigestRepository {
+	return &NotificationDigestRepository{db: db}
+}
+
+// Queue holds a notification for a user until delay has passed
+func (r *NotificationDigestRepository) Queue(item *models.NotificationDigestItem, delay time.Duration) error {
+	query := `
+		INSERT INTO notification_digest_items (user_id, channel, title, body, deliver_at)
+		VALUES ($1, $2, $3, $4, CURRENT_TIMESTAMP + make_interval(secs => $5))
+	`
+
+	if _, err := r.db.Exec(query, item.UserID, item.Channel, item.Title, item.Body, delay.Seconds()); err != nil {
+		return fmt.Errorf("failed to queue digest item: %w", err)
+	}
+	return nil
+}
+
+// ClaimDue claims every due item of up to limit user and channel pairs, oldest
+// first. The items are pushed out by lease, so other instances skip them while the
+// digests are being sent and pick them up again if this one stops before deleting
+// them.
+func (r *NotificationDigestRepository) ClaimDue(limit int, lease time.Duration) ([]models.NotificationDigestItem, error) {
+	query := `
+		UPDATE notification_digest_items
+		SET deliver_at = CURRENT_TIMESTAMP + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id FROM notification_digest_items
+			WHERE deliver_at <= CURRENT_TIMESTAMP
+			AND (user_id, channel) IN (
+				SELECT DISTINCT user_id, channel FROM notification_digest_items
+				WHERE deliver_at <= CURRENT_TIMESTAMP
+				ORDER BY user_id, channel
+				LIMIT $1
+			)
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, channel, title, body, created_at
+	`
+
+	rows, err := r.db.Query(query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim digest items: %w", err)
+	}
+	defer rows.Close()
+
+	var items []models.NotificationDigestItem
+	for rows.Next() {
+		var item models.NotificationDigestItem
+		if err := rows.Scan(&item.ID, &item.UserID, &item.Channel, &item.Title, &item.Body, &item.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan digest item: %w", err)
+		}
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate digest items: %w", err)
+	}
+	return items, nil
+}
+
+// Delete removes items that have been delivered
+func (r *NotificationDigestRepository) Delete(ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if _, err := r.db.Exec(`DELETE FROM notification_digest_items WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to delete digest items: %w", err)
+	}
+	return nil
+}
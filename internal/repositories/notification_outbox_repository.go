@@ -0,0 +1,334 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+// NotificationOutboxRepository stores notifications until every channel has received them
+type NotificationOutboxRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationOutboxRepository(db *sql.DB) *NotificationOutboxRepository {
+	return &NotificationOutboxRepository{db: db}
+}
+
+// execer is implemented by both *sql.DB and *sql.Tx
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertNotification queues a notification for the channels of tier, or every
+// channel when tier is nil. Pass a transaction to queue it atomically with the
+// change it announces.
+func insertNotification(exec execer, notification *models.SignalNotification, tier *models.PackageTier) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification: %w", err)
+	}
+
+	query := `
+		INSERT INTO notification_outbox (signal_id, event, tier, payload)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	if _, err := exec.Exec(query, notification.Signal.ID, notification.Event, tier, string(payload)); err != nil {
+		return fmt.Errorf("failed to queue notification: %w", err)
+	}
+	return nil
+}
+
+// Dispatch claims up to limit queued notifications and creates a pending delivery
+// for each channel returned by channels. It returns the number of notifications
+// dispatched.
+func (r *NotificationOutboxRepository) Dispatch(limit int, channels func(*models.OutboxNotification) []string) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT id, event, tier, COALESCE((payload->'signal'->>'free_for_all')::boolean, false)
+		FROM notification_outbox
+		WHERE dispatched_at IS NULL
+		ORDER BY id
+		LIMIT $1
+		FOR UPDATE SKIP LOCKED
+	`
+
+	rows, err := tx.Query(query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim queued notifications: %w", err)
+	}
+	var notifications []models.OutboxNotification
+	for rows.Next() {
+		var notification models.OutboxNotification
+		if err := rows.Scan(&notification.ID, &notification.Event, &notification.Tier, &notification.FreeForAll); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan queued notification: %w", err)
+		}
+		notifications = append(notifications, notification)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to iterate queued notifications: %w", err)
+	}
+	if len(notifications) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, len(notifications))
+	for i := range notifications {
+		ids[i] = notifications[i].ID
+		for _, channel := range channels(&notifications[i]) {
+			query := `
+				INSERT INTO notification_deliveries (outbox_id, channel)
+				VALUES ($1, $2)
+				ON CONFLICT (outbox_id, channel) DO NOTHING
+			`
+			if _, err := tx.Exec(query, notifications[i].ID, channel); err != nil {
+				return 0, fmt.Errorf("failed to create notification delivery: %w", err)
+			}
+		}
+	}
+
+	query = `UPDATE notification_outbox SET dispatched_at = CURRENT_TIMESTAMP WHERE id = ANY($1)`
+	if _, err := tx.Exec(query, pq.Array(ids)); err != nil {
+		return 0, fmt.Errorf("failed to mark notifications as dispatched: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit dispatched notifications: %w", err)
+	}
+	return len(notifications), nil
+}
+
+// deliveryColumns is the column list selected for every delivery query, from
+// notification_deliveries d joined with notification_outbox o
+const deliveryColumns = `d.id, d.outbox_id, o.signal_id, o.event, d.channel, d.status, d.attempts,
+	d.next_attempt_at, d.last_error, d.sent_at, d.created_at, d.updated_at`
+
+// scanDelivery scans a row selected with deliveryColumns, followed by extra columns
+func scanDelivery(row rowScanner, extra ...interface{}) (*models.NotificationDelivery, error) {
+	var delivery models.NotificationDelivery
+	dest := []interface{}{
+		&delivery.ID,
+		&delivery.OutboxID,
+		&delivery.SignalID,
+		&delivery.Event,
+		&delivery.Channel,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.NextAttemptAt,
+		&delivery.LastError,
+		&delivery.SentAt,
+		&delivery.CreatedAt,
+		&delivery.UpdatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		return nil, err
+	}
+	return &delivery, nil
+}
+
+// ClaimDueDeliveries claims up to limit pending deliveries due for an attempt along
+// with their notification. The attempt is counted and the next one pushed out by
+// lease, so other instances skip the deliveries while they are being sent and
+// pick them up again if this one stops before recording the outcome.
+func (r *NotificationOutboxRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]models.NotificationDelivery, error) {
+	query := `
+		UPDATE notification_deliveries d
+		SET attempts = d.attempts + 1,
+			next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $2),
+			updated_at = CURRENT_TIMESTAMP
+		FROM notification_outbox o
+		WHERE o.id = d.outbox_id
+		AND d.id IN (
+			SELECT id FROM notification_deliveries
+			WHERE status = 'PENDING'
+			AND next_attempt_at <= CURRENT_TIMESTAMP
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + deliveryColumns + `, o.payload`
+
+	rows, err := r.db.Query(query, limit, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []models.NotificationDelivery
+	for rows.Next() {
+		var payload []byte
+		delivery, err := scanDelivery(rows, &payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification delivery: %w", err)
+		}
+		if err := json.Unmarshal(payload, &delivery.Notification); err != nil {
+			return nil, fmt.Errorf("failed to decode notification %d: %w", delivery.OutboxID, err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate notification deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// MarkDeliverySent records that a delivery went through
+func (r *NotificationOutboxRepository) MarkDeliverySent(id int64) error {
+	query := `
+		UPDATE notification_deliveries
+		SET status = 'SENT', sent_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	if _, err := r.db.Exec(query, id); err != nil {
+		return fmt.Errorf("failed to mark delivery as sent: %w", err)
+	}
+	return nil
+}
+
+// MarkDeliveryFailed records a failed attempt. The delivery is retried after
+// retryIn, or dead-lettered when dead is set.
+func (r *NotificationOutboxRepository) MarkDeliveryFailed(id int64, lastError string, retryIn time.Duration, dead bool) error {
+	status := models.NotificationDeliveryPending
+	if dead {
+		status = models.NotificationDeliveryDead
+	}
+
+	query := `
+		UPDATE notification_deliveries
+		SET status = $2,
+			last_error = $3,
+			next_attempt_at = CURRENT_TIMESTAMP + make_interval(secs => $4),
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+	`
+
+	if _, err := r.db.Exec(query, id, status, lastError, retryIn.Seconds()); err != nil {
+		return fmt.Errorf("failed to record delivery failure: %w", err)
+	}
+	return nil
+}
+
+// deliveryFilterConditions builds the WHERE conditions for filter, appending their arguments to args
+func deliveryFilterConditions(filter *models.NotificationDeliveryFilter, args []interface{}) ([]string, []interface{}) {
+	var conditions []string
+	if filter.Status != nil {
+		args = append(args, *filter.Status)
+		conditions = append(conditions, fmt.Sprintf("d.status = $%d", len(args)))
+	}
+	if filter.Channel != "" {
+		args = append(args, filter.Channel)
+		conditions = append(conditions, fmt.Sprintf("d.channel = $%d", len(args)))
+	}
+	if filter.SignalID != nil {
+		args = append(args, *filter.SignalID)
+		conditions = append(conditions, fmt.Sprintf("o.signal_id = $%d", len(args)))
+	}
+	return conditions, args
+}
+
+// GetDeliveries retrieves deliveries matching filter, most recently updated first
+func (r *NotificationOutboxRepository) GetDeliveries(filter *models.NotificationDeliveryFilter, limit, offset int) ([]models.NotificationDelivery, error) {
+	conditions, args := deliveryFilterConditions(filter, nil)
+	args = append(args, limit, offset)
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM notification_deliveries d
+		JOIN notification_outbox o ON o.id = d.outbox_id
+		%s
+		ORDER BY d.updated_at DESC, d.id DESC
+		LIMIT $%d OFFSET $%d
+	`, deliveryColumns, whereClause(conditions), len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	deliveries := []models.NotificationDelivery{}
+	for rows.Next() {
+		delivery, err := scanDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification delivery: %w", err)
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate notification deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// CountDeliveries counts deliveries matching filter
+func (r *NotificationOutboxRepository) CountDeliveries(filter *models.NotificationDeliveryFilter) (int64, error) {
+	conditions, args := deliveryFilterConditions(filter, nil)
+	query := `
+		SELECT COUNT(*)
+		FROM notification_deliveries d
+		JOIN notification_outbox o ON o.id = d.outbox_id
+	` + whereClause(conditions)
+
+	var count int64
+	if err := r.db.QueryRow(query, args...).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count notification deliveries: %w", err)
+	}
+	return count, nil
+}
+
+// GetDelivery retrieves a delivery by ID
+func (r *NotificationOutboxRepository) GetDelivery(id int64) (*models.NotificationDelivery, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM notification_deliveries d
+		JOIN notification_outbox o ON o.id = d.outbox_id
+		WHERE d.id = $1
+	`, deliveryColumns)
+
+	delivery, err := scanDelivery(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification delivery: %w", err)
+	}
+	return delivery, nil
+}
+
+// RetryDelivery puts a dead-lettered delivery back in the queue with a fresh set of
+// attempts. It returns nil if the delivery does not exist or is not dead-lettered.
+func (r *NotificationOutboxRepository) RetryDelivery(id int64) (*models.NotificationDelivery, error) {
+	query := `
+		UPDATE notification_deliveries d
+		SET status = 'PENDING',
+			attempts = 0,
+			next_attempt_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		FROM notification_outbox o
+		WHERE o.id = d.outbox_id
+		AND d.id = $1
+		AND d.status = 'DEAD'
+		RETURNING ` + deliveryColumns
+
+	delivery, err := scanDelivery(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to retry notification delivery: %w", err)
+	}
+	return delivery, nil
+}
@@ -0,0 +1,201 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+// NotificationPreferenceRepository stores the personal notification preferences of users
+type NotificationPreferenceRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationPreferenceRepository(db *sql.DB) *NotificationPreferenceRepository {
+	return &NotificationPreferenceRepository{db: db}
+}
+
+// preferenceColumns is the column list selected for every preference query, from
+// notification_preferences np. Left joined columns are NULL for users without
+// saved preferences.
+const preferenceColumns = `np.asset_classes, np.duration_types, np.channels, np.quiet_hours_start,
+	np.quiet_hours_end, np.timezone, np.mode, np.digest_time, np.updated_at`
+
+// scanPreferences scans leading columns followed by preferenceColumns. Users
+// without saved preferences get the defaults.
+func scanPreferences(row rowScanner, leading ...interface{}) (*models.NotificationPreferences, error) {
+	var assetClasses, durationTypes, channels []string
+	var quietHoursStart, quietHoursEnd *string
+	var timezone, mode, digestTime sql.NullString
+	var updatedAt *time.Time
+
+	dest := append(leading,
+		pq.Array(&assetClasses),
+		pq.Array(&durationTypes),
+		pq.Array(&channels),
+		&quietHoursStart,
+		&quietHoursEnd,
+		&timezone,
+		&mode,
+		&digestTime,
+		&updatedAt,
+	)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	if !timezone.Valid {
+		return models.DefaultNotificationPreferences(), nil
+	}
+
+	preferences := &models.NotificationPreferences{
+		AssetClasses:    make([]models.AssetClass, len(assetClasses)),
+		DurationTypes:   make([]models.DurationType, len(durationTypes)),
+		Channels:        make([]models.NotificationChannel, len(channels)),
+		QuietHoursStart: quietHoursStart,
+		QuietHoursEnd:   quietHoursEnd,
+		Timezone:        timezone.String,
+		Mode:            models.NotificationMode(mode.String),
+		DigestTime:      digestTime.String,
+		UpdatedAt:       updatedAt,
+	}
+	for i, assetClass := range assetClasses {
+		preferences.AssetClasses[i] = models.AssetClass(assetClass)
+	}
+	for i, durationType := range durationTypes {
+		preferences.DurationTypes[i] = models.DurationType(durationType)
+	}
+	for i, channel := range channels {
+		preferences.Channels[i] = models.NotificationChannel(channel)
+	}
+	return preferences, nil
+}
+
+// GetByUserID retrieves a user's preferences, the defaults if they saved none
+func (r *NotificationPreferenceRepository) GetByUserID(userID int64) (*models.NotificationPreferences, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM users u
+		LEFT JOIN notification_preferences np ON np.user_id = u.id
+		WHERE u.id = $1
+	`, preferenceColumns)
+
+	preferences, err := scanPreferences(r.db.QueryRow(query, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification preferences: %w", err)
+	}
+	return preferences, nil
+}
+
+// Upsert replaces a user's preferences
+func (r *NotificationPreferenceRepository) Upsert(userID int64, update *models.NotificationPreferencesUpdate) (*models.NotificationPreferences, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO notification_preferences AS np
+			(user_id, asset_classes, duration_types, channels, quiet_hours_start, quiet_hours_end, timezone, mode, digest_time)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		ON CONFLICT (user_id) DO UPDATE
+		SET asset_classes = EXCLUDED.asset_classes,
+			duration_types = EXCLUDED.duration_types,
+			channels = EXCLUDED.channels,
+			quiet_hours_start = EXCLUDED.quiet_hours_start,
+			quiet_hours_end = EXCLUDED.quiet_hours_end,
+			timezone = EXCLUDED.timezone,
+			mode = EXCLUDED.mode,
+			digest_time = EXCLUDED.digest_time,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING %s
+	`, preferenceColumns)
+
+	preferences, err := scanPreferences(r.db.QueryRow(
+		query,
+		userID,
+		pq.Array(update.AssetClasses),
+		pq.Array(update.DurationTypes),
+		pq.Array(update.Channels),
+		update.QuietHoursStart,
+		update.QuietHoursEnd,
+		update.Timezone,
+		update.Mode,
+		update.DigestTime,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save notification preferences: %w", err)
+	}
+	return preferences, nil
+}
+
+// GetRecipients retrieves the users of tier allowed to see signal, along with their
+// preferences: those whose highest tier among their active subscriptions for its
+// asset class and duration is tier. For free-for-all signals users without such a
+// subscription belong to the lowest tier. Blocked users are left out.
+func (r *NotificationPreferenceRepository) GetRecipients(signal *models.TradingSignal, tier models.PackageTier) ([]models.NotificationRecipient, error) {
+	query := fmt.Sprintf(`
+		SELECT u.id, u.email, u.name, %s
+		FROM users u
+		LEFT JOIN notification_preferences np ON np.user_id = u.id
+		WHERE u.blocked = false
+		AND $4::text = COALESCE((
+			SELECT p.tier
+			FROM user_subscriptions us
+			JOIN packages p ON us.package_id = p.id
+			WHERE us.user_id = u.id
+			AND us.is_active = true
+			AND us.expires_at > CURRENT_TIMESTAMP
+			AND p.asset_class = $2
+			AND p.duration_type = $3
+			ORDER BY array_position($5::text[], p.tier::text)
+			LIMIT 1
+		)::text, CASE WHEN $1 THEN $6::text END)
+		ORDER BY u.id
+	`, preferenceColumns)
+
+	tiers := make([]string, len(models.PackageTiers))
+	for i, t := range models.PackageTiers {
+		tiers[i] = string(t)
+	}
+	lowest := tiers[len(tiers)-1]
+
+	return r.queryRecipients(query, signal.FreeForAll, signal.AssetClass, signal.DurationType, tier, pq.Array(tiers), lowest)
+}
+
+// GetRecipientsByIDs retrieves users along with their preferences
+func (r *NotificationPreferenceRepository) GetRecipientsByIDs(userIDs []int64) ([]models.NotificationRecipient, error) {
+	query := fmt.Sprintf(`
+		SELECT u.id, u.email, u.name, %s
+		FROM users u
+		LEFT JOIN notification_preferences np ON np.user_id = u.id
+		WHERE u.id = ANY($1)
+		AND u.blocked = false
+		ORDER BY u.id
+	`, preferenceColumns)
+
+	return r.queryRecipients(query, pq.Array(userIDs))
+}
+
+// queryRecipients runs a query selecting u.id, u.email, u.name and preferenceColumns
+func (r *NotificationPreferenceRepository) queryRecipients(query string, args ...interface{}) ([]models.NotificationRecipient, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification recipients: %w", err)
+	}
+	defer rows.Close()
+
+	var recipients []models.NotificationRecipient
+	for rows.Next() {
+		var recipient models.NotificationRecipient
+		recipient.Preferences, err = scanPreferences(rows, &recipient.UserID, &recipient.Email, &recipient.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification recipient: %w", err)
+		}
+		recipients = append(recipients, recipient)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate notification recipients: %w", err)
+	}
+	return recipients, nil
+}
@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+// NotificationTemplateRepository stores the message templates of notification channels
+type NotificationTemplateRepository struct {
+	db *sql.DB
+}
+
+func NewNotificationTemplateRepository(db *sql.DB) *NotificationTemplateRepository {
+	return &NotificationTemplateRepository{db: db}
+}
+
+// templateColumns is the column list selected for every template query
+const templateColumns = `channel, event, title, body, updated_by, created_at, updated_at`
+
+// scanTemplate scans a row selected with templateColumns
+func scanTemplate(row rowScanner) (*models.NotificationTemplate, error) {
+	var template models.NotificationTemplate
+	err := row.Scan(
+		&template.Channel,
+		&template.Event,
+		&template.Title,
+		&template.Body,
+		&template.UpdatedBy,
+		&template.CreatedAt,
+		&template.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetAll retrieves every stored template
+func (r *NotificationTemplateRepository) GetAll() ([]models.NotificationTemplate, error) {
+	query := fmt.Sprintf(`SELECT %s FROM notification_templates ORDER BY channel, event`, templateColumns)
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification templates: %w", err)
+	}
+	defer rows.Close()
+
+	templates := []models.NotificationTemplate{}
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan notification template: %w", err)
+		}
+		templates = append(templates, *template)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate notification templates: %w", err)
+	}
+	return templates, nil
+}
+
+// Get retrieves the template of a channel for an event, nil if none is stored
+func (r *NotificationTemplateRepository) Get(channel models.TemplateChannel, event models.NotificationEvent) (*models.NotificationTemplate, error) {
+	query := fmt.Sprintf(`SELECT %s FROM notification_templates WHERE channel = $1 AND event = $2`, templateColumns)
+
+	template, err := scanTemplate(r.db.QueryRow(query, channel, event))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification template: %w", err)
+	}
+	return template, nil
+}
+
+// Upsert stores the template of a channel for an event, replacing the previous one
+func (r *NotificationTemplateRepository) Upsert(channel models.TemplateChannel, event models.NotificationEvent, update *models.NotificationTemplateUpdate, adminID int64) (*models.NotificationTemplate, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO notification_templates (channel, event, title, body, updated_by)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (channel, event) DO UPDATE
+		SET title = EXCLUDED.title,
+			body = EXCLUDED.body,
+			updated_by = EXCLUDED.updated_by,
+			updated_at = CURRENT_TIMESTAMP
+		RETURNING %s
+	`, templateColumns)
+
+	template, err := scanTemplate(r.db.QueryRow(query, channel, event, update.Title, update.Body, adminID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to save notification template: %w", err)
+	}
+	return template, nil
+}
+
+// Delete removes the template of a channel for an event. It returns false if
+// none is stored.
+func (r *NotificationTemplateRepository) Delete(channel models.TemplateChannel, event models.NotificationEvent) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM notification_templates WHERE channel = $1 AND event = $2`, channel, event)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete notification template: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+// PackageChannelRepository stores the private chats and roles each package gives
+// access to
+type PackageChannelRepository struct {
+	db *sql.DB
+}
+
+func NewPackageChannelRepository(db *sql.DB) *PackageChannelRepository {
+	return &PackageChannelRepository{db: db}
+}
+
+// packageChannelColumns is the column list selected for every package channel query
+const packageChannelColumns = `id, package_id, platform, target_id, created_at`
+
+// scanPackageChannel scans a row selected with packageChannelColumns
+func scanPackageChannel(row rowScanner) (*models.PackageChannel, error) {
+	var channel models.PackageChannel
+	if err := row.Scan(&channel.ID, &channel.PackageID, &channel.Platform, &channel.TargetID, &channel.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// Create maps a channel to a package. It returns nil if the package already
+// gives access to the channel.
+func (r *PackageChannelRepository) Create(packageID int64, create *models.PackageChannelCreate) (*models.PackageChannel, error) {
+	query := fmt.Sprintf(`
+		INSERT INTO package_channels (package_id, platform, target_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (package_id, platform, target_id) DO NOTHING
+		RETURNING %s
+	`, packageChannelColumns)
+
+	channel, err := scanPackageChannel(r.db.QueryRow(query, packageID, create.Platform, create.TargetID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create package channel: %w", err)
+	}
+	return channel, nil
+}
+
+// GetByPackageID retrieves the channels a package gives access to
+func (r *PackageChannelRepository) GetByPackageID(packageID int64) ([]models.PackageChannel, error) {
+	query := fmt.Sprintf(`SELECT %s FROM package_channels WHERE package_id = $1 ORDER BY id`, packageChannelColumns)
+
+	rows, err := r.db.Query(query, packageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package channels: %w", err)
+	}
+	defer rows.Close()
+
+	channels := []models.PackageChannel{}
+	for rows.Next() {
+		channel, err := scanPackageChannel(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan package channel: %w", err)
+		}
+		channels = append(channels, *channel)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate package channels: %w", err)
+	}
+	return channels, nil
+}
+
+// Delete removes a channel from a package. It returns false if the package has
+// no such channel.
+func (r *PackageChannelRepository) Delete(packageID, id int64) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM package_channels WHERE id = $1 AND package_id = $2`, id, packageID)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete package channel: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
@@ -19,9 +19,9 @@ func NewPackageRepository(db *sql.DB) *PackageRepository {
 // Create creates a new package
 func (r *PackageRepository) Create(pkg *models.PackageCreate) (*models.Package, error) {
 	query := `
-		INSERT INTO packages (name, asset_class, duration_type, billing_cycle, duration_days, price, description)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
-		RETURNING id, name, asset_class, duration_type, billing_cycle, duration_days, price, description, is_active, created_at, updated_at
+		INSERT INTO packages (name, asset_class, duration_type, billing_cycle, duration_days, price, description, tier)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, name, asset_class, duration_type, billing_cycle, duration_days, price, description, tier, is_active, created_at, updated_at
 	`
 
 	var newPackage models.Package
@@ -34,6 +34,7 @@ func (r *PackageRepository) Create(pkg *models.PackageCreate) (*models.Package,
 		pkg.DurationDays,
 		pkg.Price,
 		pkg.Description,
+		pkg.Tier,
 	).Scan(
 		&newPackage.ID,
 		&newPackage.Name,
@@ -43,6 +44,7 @@ func (r *PackageRepository) Create(pkg *models.PackageCreate) (*models.Package,
 		&newPackage.DurationDays,
 		&newPackage.Price,
 		&newPackage.Description,
+		&newPackage.Tier,
 		&newPackage.IsActive,
 		&newPackage.CreatedAt,
 		&newPackage.UpdatedAt,
@@ -58,7 +60,7 @@ func (r *PackageRepository) Create(pkg *models.PackageCreate) (*models.Package,
 // GetByID retrieves a package by ID
 func (r *PackageRepository) GetByID(id int64) (*models.Package, error) {
 	query := `
-		SELECT id, name, asset_class, duration_type, billing_cycle, duration_days, price, description, is_active, created_at, updated_at
+		SELECT id, name, asset_class, duration_type, billing_cycle, duration_days, price, description, tier, is_active, created_at, updated_at
 		FROM packages
 		WHERE id = $1
 	`
@@ -73,6 +75,7 @@ func (r *PackageRepository) GetByID(id int64) (*models.Package, error) {
 		&pkg.DurationDays,
 		&pkg.Price,
 		&pkg.Description,
+		&pkg.Tier,
 		&pkg.IsActive,
 		&pkg.CreatedAt,
 		&pkg.UpdatedAt,
@@ -91,7 +94,7 @@ func (r *PackageRepository) GetByID(id int64) (*models.Package, error) {
 // GetAll retrieves all packages
 func (r *PackageRepository) GetAll(activeOnly bool, limit, offset int) ([]models.Package, error) {
 	query := `
-		SELECT id, name, asset_class, duration_type, billing_cycle, duration_days, price, description, is_active, created_at, updated_at
+		SELECT id, name, asset_class, duration_type, billing_cycle, duration_days, price, description, tier, is_active, created_at, updated_at
 		FROM packages
 	`
 
@@ -135,6 +138,7 @@ func (r *PackageRepository) GetAll(activeOnly bool, limit, offset int) ([]models
 			&pkg.DurationDays,
 			&pkg.Price,
 			&pkg.Description,
+			&pkg.Tier,
 			&pkg.IsActive,
 			&pkg.CreatedAt,
 			&pkg.UpdatedAt,
@@ -163,7 +167,7 @@ func (r *PackageRepository) GetByIDs(ids []int64) ([]models.Package, error) {
 	}
 
 	query := fmt.Sprintf(`
-		SELECT id, name, asset_class, duration_type, billing_cycle, duration_days, price, description, is_active, created_at, updated_at
+		SELECT id, name, asset_class, duration_type, billing_cycle, duration_days, price, description, tier, is_active, created_at, updated_at
 		FROM packages
 		WHERE id IN (%s)
 	`, strings.Join(placeholders, ", "))
@@ -186,6 +190,7 @@ func (r *PackageRepository) GetByIDs(ids []int64) ([]models.Package, error) {
 			&pkg.DurationDays,
 			&pkg.Price,
 			&pkg.Description,
+			&pkg.Tier,
 			&pkg.IsActive,
 			&pkg.CreatedAt,
 			&pkg.UpdatedAt,
@@ -225,6 +230,11 @@ func (r *PackageRepository) Update(id int64, update *models.PackageUpdate) (*mod
 		args = append(args, *update.BillingCycle)
 		argPosition++
 	}
+	if update.Tier != nil {
+		setClauses = append(setClauses, fmt.Sprintf("tier = $%d", argPosition))
+		args = append(args, *update.Tier)
+		argPosition++
+	}
 	if update.DurationDays != nil {
 		setClauses = append(setClauses, fmt.Sprintf("duration_days = $%d", argPosition))
 		args = append(args, *update.DurationDays)
@@ -257,7 +267,7 @@ func (r *PackageRepository) Update(id int64, update *models.PackageUpdate) (*mod
 		UPDATE packages
 		SET %s
 		WHERE id = $%d
-		RETURNING id, name, asset_class, duration_type, billing_cycle, duration_days, price, description, is_active, created_at, updated_at
+		RETURNING id, name, asset_class, duration_type, billing_cycle, duration_days, price, description, tier, is_active, created_at, updated_at
 	`, strings.Join(setClauses, ", "), argPosition)
 
 	var pkg models.Package
@@ -270,6 +280,7 @@ func (r *PackageRepository) Update(id int64, update *models.PackageUpdate) (*mod
 		&pkg.DurationDays,
 		&pkg.Price,
 		&pkg.Description,
+		&pkg.Tier,
 		&pkg.IsActive,
 		&pkg.CreatedAt,
 		&pkg.UpdatedAt,
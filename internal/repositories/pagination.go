@@ -0,0 +1,21 @@
+package repositories
+
+import (
+	"fmt"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+// keysetCondition restricts a (created_at, id) ordered query to the rows after
+// cursor. prefix is the table alias including its dot, or empty. The cursor
+// values are appended to args and the condition uses the next placeholders.
+func keysetCondition(prefix string, cursor *models.PageCursor, ascending bool, args []interface{}) (string, []interface{}) {
+	operator := "<"
+	if ascending {
+		operator = ">"
+	}
+
+	args = append(args, cursor.CreatedAt, cursor.ID)
+	condition := fmt.Sprintf("(%screated_at, %sid) %s ($%d, $%d)", prefix, prefix, operator, len(args)-1, len(args))
+	return condition, args
+}
@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
 )
@@ -96,16 +97,25 @@ func (r *PaymentRepository) GetByID(id int64) (*models.Payment, error) {
 }
 
 // GetByUserID retrieves all payments for a user
-func (r *PaymentRepository) GetByUserID(userID int64, limit, offset int) ([]models.Payment, error) {
-	query := `
+func (r *PaymentRepository) GetByUserID(userID int64, page models.PageRequest) ([]models.Payment, error) {
+	conditions := []string{"user_id = $1"}
+	args := []interface{}{userID}
+	if page.After != nil {
+		var condition string
+		condition, args = keysetCondition("", page.After, false, args)
+		conditions = append(conditions, condition)
+	}
+	args = append(args, page.Limit, page.Offset)
+
+	query := fmt.Sprintf(`
 		SELECT id, user_id, package_id, amount, payment_method, payment_status, transaction_id, metadata, created_at
 		FROM payment_history
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, strings.Join(conditions, " AND "), len(args)-1, len(args))
 
-	rows, err := r.db.Query(query, userID, limit, offset)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get payments: %w", err)
 	}
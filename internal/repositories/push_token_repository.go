@@ -0,0 +1,208 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+// PushTokenRepository stores the Expo push tokens of users' devices and the push
+// tickets waiting for their receipt
+type PushTokenRepository struct {
+	db *sql.DB
+}
+
+func NewPushTokenRepository(db *sql.DB) *PushTokenRepository {
+	return &PushTokenRepository{db: db}
+}
+
+// pushTokenColumns is the column list selected for every push token query
+const pushTokenColumns = `id, user_id, device_id, token, platform, created_at, updated_at`
+
+// scanPushToken scans a row selected with pushTokenColumns
+func scanPushToken(row rowScanner) (*models.PushToken, error) {
+	var token models.PushToken
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.DeviceID,
+		&token.Token,
+		&token.Platform,
+		&token.CreatedAt,
+		&token.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// Register stores the push token of a user's device, replacing the device's
+// previous token. A token registered before by another user or device (e.g. after
+// switching accounts) is moved over, so a device is never notified twice.
+func (r *PushTokenRepository) Register(userID int64, register *models.PushTokenRegister) (*models.PushToken, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `DELETE FROM expo_push_tokens WHERE token = $1 AND NOT (user_id = $2 AND device_id = $3)`
+	if _, err := tx.Exec(query, register.Token, userID, register.DeviceID); err != nil {
+		return nil, fmt.Errorf("failed to release push token: %w", err)
+	}
+
+	query = fmt.Sprintf(`
+		INSERT INTO expo_push_tokens (user_id, device_id, token, platform)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (user_id, device_id) DO UPDATE
+		SET token = EXCLUDED.token, platform = EXCLUDED.platform, updated_at = CURRENT_TIMESTAMP
+		RETURNING %s
+	`, pushTokenColumns)
+
+	token, err := scanPushToken(tx.QueryRow(query, userID, register.DeviceID, register.Token, register.Platform))
+	if err != nil {
+		return nil, fmt.Errorf("failed to register push token: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit push token: %w", err)
+	}
+	return token, nil
+}
+
+// Unregister removes the push token of a user's device. It returns false if the
+// device has no token.
+func (r *PushTokenRepository) Unregister(userID int64, deviceID string) (bool, error) {
+	result, err := r.db.Exec(`DELETE FROM expo_push_tokens WHERE user_id = $1 AND device_id = $2`, userID, deviceID)
+	if err != nil {
+		return false, fmt.Errorf("failed to unregister push token: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows > 0, nil
+}
+
+// GetTokensByUserIDs retrieves the push tokens of every device of users
+func (r *PushTokenRepository) GetTokensByUserIDs(userIDs []int64) ([]string, error) {
+	query := `SELECT token FROM expo_push_tokens WHERE user_id = ANY($1) ORDER BY id`
+
+	rows, err := r.db.Query(query, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get push tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var tokens []string
+	for rows.Next() {
+		var token string
+		if err := rows.Scan(&token); err != nil {
+			return nil, fmt.Errorf("failed to scan push token: %w", err)
+		}
+		tokens = append(tokens, token)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate push tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// DeleteTokens removes push tokens Expo reported as no longer registered
+func (r *PushTokenRepository) DeleteTokens(tokens []string) (int64, error) {
+	if len(tokens) == 0 {
+		return 0, nil
+	}
+
+	result, err := r.db.Exec(`DELETE FROM expo_push_tokens WHERE token = ANY($1)`, pq.Array(tokens))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete push tokens: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows, nil
+}
+
+// SaveTickets stores push tickets until their receipts are checked
+func (r *PushTokenRepository) SaveTickets(tickets []models.PushTicket) error {
+	if len(tickets) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(tickets))
+	tokens := make([]string, len(tickets))
+	for i := range tickets {
+		ids[i] = tickets[i].ID
+		tokens[i] = tickets[i].Token
+	}
+
+	query := `
+		INSERT INTO expo_push_tickets (id, token)
+		SELECT * FROM unnest($1::text[], $2::text[])
+		ON CONFLICT (id) DO NOTHING
+	`
+	if _, err := r.db.Exec(query, pq.Array(ids), pq.Array(tokens)); err != nil {
+		return fmt.Errorf("failed to save push tickets: %w", err)
+	}
+	return nil
+}
+
+// GetDueTickets retrieves up to limit push tickets older than age, oldest first
+func (r *PushTokenRepository) GetDueTickets(age time.Duration, limit int) ([]models.PushTicket, error) {
+	query := `
+		SELECT id, token
+		FROM expo_push_tickets
+		WHERE created_at <= CURRENT_TIMESTAMP - make_interval(secs => $1)
+		ORDER BY created_at
+		LIMIT $2
+	`
+
+	rows, err := r.db.Query(query, age.Seconds(), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get push tickets: %w", err)
+	}
+	defer rows.Close()
+
+	var tickets []models.PushTicket
+	for rows.Next() {
+		var ticket models.PushTicket
+		if err := rows.Scan(&ticket.ID, &ticket.Token); err != nil {
+			return nil, fmt.Errorf("failed to scan push ticket: %w", err)
+		}
+		tickets = append(tickets, ticket)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate push tickets: %w", err)
+	}
+	return tickets, nil
+}
+
+// DeleteTickets removes push tickets whose receipt has been checked
+func (r *PushTokenRepository) DeleteTickets(ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if _, err := r.db.Exec(`DELETE FROM expo_push_tickets WHERE id = ANY($1)`, pq.Array(ids)); err != nil {
+		return fmt.Errorf("failed to delete push tickets: %w", err)
+	}
+	return nil
+}
+
+// DeleteStaleTickets removes push tickets older than age, whose receipts Expo no
+// longer keeps
+func (r *PushTokenRepository) DeleteStaleTickets(age time.Duration) error {
+	query := `DELETE FROM expo_push_tickets WHERE created_at <= CURRENT_TIMESTAMP - make_interval(secs => $1)`
+	if _, err := r.db.Exec(query, age.Seconds()); err != nil {
+		return fmt.Errorf("failed to delete stale push tickets: %w", err)
+	}
+	return nil
+}
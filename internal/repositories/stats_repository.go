@@ -0,0 +1,72 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+type StatsRepository struct {
+	db *sql.DB
+}
+
+func NewStatsRepository(db *sql.DB) *StatsRepository {
+	return &StatsRepository{db: db}
+}
+
+// GetSignals retrieves the published signals matching filter, with their targets. A package
+// filter must already have been resolved to its asset class and duration.
+func (r *StatsRepository) GetSignals(filter *models.StatsFilter) ([]models.TradingSignal, error) {
+	conditions := []string{"ts.published_at IS NOT NULL"}
+	var args []interface{}
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.From != nil {
+		add("ts.created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("ts.created_at < $%d", *filter.To)
+	}
+	if filter.AssetClass != nil {
+		add("ts.asset_class = $%d", *filter.AssetClass)
+	}
+	if filter.DurationType != nil {
+		add("ts.duration_type = $%d", *filter.DurationType)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM trading_signals ts
+		%s
+		ORDER BY ts.created_at, ts.id
+	`, tradingSignalColumns, whereClause(conditions))
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signals for stats: %w", err)
+	}
+	defer rows.Close()
+
+	signals, err := scanTradingSignals(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(signals))
+	for i := range signals {
+		ids[i] = signals[i].ID
+	}
+	targets, err := getTargetsForSignals(r.db, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range signals {
+		signals[i].Targets = targets[signals[i].ID]
+	}
+
+	return signals, nil
+}
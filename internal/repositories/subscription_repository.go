@@ -3,6 +3,7 @@ package repositories
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
@@ -114,16 +115,25 @@ func (r *SubscriptionRepository) GetActiveByUserID(userID int64) ([]models.Subsc
 }
 
 // GetAllByUserID retrieves all subscriptions for a user (active and expired)
-func (r *SubscriptionRepository) GetAllByUserID(userID int64, limit, offset int) ([]models.Subscription, error) {
-	query := `
+func (r *SubscriptionRepository) GetAllByUserID(userID int64, page models.PageRequest) ([]models.Subscription, error) {
+	conditions := []string{"user_id = $1"}
+	args := []interface{}{userID}
+	if page.After != nil {
+		var condition string
+		condition, args = keysetCondition("", page.After, false, args)
+		conditions = append(conditions, condition)
+	}
+	args = append(args, page.Limit, page.Offset)
+
+	query := fmt.Sprintf(`
 		SELECT id, user_id, package_id, price_paid, subscribed_at, expires_at, is_active, created_at, updated_at
 		FROM user_subscriptions
-		WHERE user_id = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`
+		WHERE %s
+		ORDER BY created_at DESC, id DESC
+		LIMIT $%d OFFSET $%d
+	`, strings.Join(conditions, " AND "), len(args)-1, len(args))
 
-	rows, err := r.db.Query(query, userID, limit, offset)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get subscriptions: %w", err)
 	}
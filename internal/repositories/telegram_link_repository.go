@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+// TelegramLinkRepository stores the Telegram chats users linked to their account
+// and the one-time tokens they link them with
+type TelegramLinkRepository struct {
+	db *sql.DB
+}
+
+func NewTelegramLinkRepository(db *sql.DB) *TelegramLinkRepository {
+	return &TelegramLinkRepository{db: db}
+}
+
+// telegramLinkColumns is the column list selected for every link query
+const telegramLinkColumns = `user_id, chat_id, username, linked_at`
+
+// scanTelegramLink scans a row selected with telegramLinkColumns
+func scanTelegramLink(row rowScanner) (*models.TelegramLink, error) {
+	var link models.TelegramLink
+	if err := row.Scan(&link.UserID, &link.ChatID, &link.Username, &link.LinkedAt); err != nil {
+		return nil, err
+	}
+	return &link, nil
+}
+
+// CreateToken stores a link token for a user, valid for ttl, and clears the
+// user's expired tokens. It returns when the token expires.
+func (r *TelegramLinkRepository) CreateToken(userID int64, token string, ttl time.Duration) (time.Time, error) {
+	if _, err := r.db.Exec(`DELETE FROM telegram_link_tokens WHERE user_id = $1 AND expires_at <= CURRENT_TIMESTAMP`, userID); err != nil {
+		return time.Time{}, fmt.Errorf("failed to delete expired telegram link tokens: %w", err)
+	}
+
+	query := `
+		INSERT INTO telegram_link_tokens (token, user_id, expires_at)
+		VALUES ($1, $2, CURRENT_TIMESTAMP + make_interval(secs => $3))
+		RETURNING expires_at
+	`
+
+	var expiresAt time.Time
+	if err := r.db.QueryRow(query, token, userID, ttl.Seconds()).Scan(&expiresAt); err != nil {
+		return time.Time{}, fmt.Errorf("failed to create telegram link token: %w", err)
+	}
+	return expiresAt, nil
+}
+
+// Link consumes a link token and links the chat to the user it was issued to,
+// replacing the user's previous chat. A chat linked to another account before is
+// moved over. Telegram direct messages are turned back on in the user's saved
+// preferences. It returns nil if the token is unknown, used or expired.
+func (r *TelegramLinkRepository) Link(token string, chatID int64, username *string) (*models.TelegramLink, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var userID int64
+	query := `DELETE FROM telegram_link_tokens WHERE token = $1 AND expires_at > CURRENT_TIMESTAMP RETURNING user_id`
+	err = tx.QueryRow(query, token).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume telegram link token: %w", err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM telegram_link_tokens WHERE user_id = $1`, userID); err != nil {
+		return nil, fmt.Errorf("failed to delete telegram link tokens: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM telegram_links WHERE chat_id = $1 AND user_id <> $2`, chatID, userID); err != nil {
+		return nil, fmt.Errorf("failed to release telegram chat: %w", err)
+	}
+
+	query = fmt.Sprintf(`
+		INSERT INTO telegram_links (user_id, chat_id, username)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id) DO UPDATE
+		SET chat_id = EXCLUDED.chat_id, username = EXCLUDED.username, linked_at = CURRENT_TIMESTAMP
+		RETURNING %s
+	`, telegramLinkColumns)
+
+	link, err := scanTelegramLink(tx.QueryRow(query, userID, chatID, username))
+	if err != nil {
+		return nil, fmt.Errorf("failed to link telegram chat: %w", err)
+	}
+
+	query = `
+		UPDATE notification_preferences
+		SET channels = array_append(channels, $2), updated_at = CURRENT_TIMESTAMP
+		WHERE user_id = $1 AND NOT $2 = ANY(channels)
+	`
+	if _, err := tx.Exec(query, userID, string(models.NotificationChannelTelegram)); err != nil {
+		return nil, fmt.Errorf("failed to enable telegram notifications: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit telegram link: %w", err)
+	}
+	return link, nil
+}
+
+// GetByUserID retrieves the chat linked to a user, nil if none is
+func (r *TelegramLinkRepository) GetByUserID(userID int64) (*models.TelegramLink, error) {
+	query := fmt.Sprintf(`SELECT %s FROM telegram_links WHERE user_id = $1`, telegramLinkColumns)
+
+	link, err := scanTelegramLink(r.db.QueryRow(query, userID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get telegram link: %w", err)
+	}
+	return link, nil
+}
+
+// GetByChatID retrieves the link of a chat, nil if the chat is not linked
+func (r *TelegramLinkRepository) GetByChatID(chatID int64) (*models.TelegramLink, error) {
+	query := fmt.Sprintf(`SELECT %s FROM telegram_links WHERE chat_id = $1`, telegramLinkColumns)
+
+	link, err := scanTelegramLink(r.db.QueryRow(query, chatID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get telegram link: %w", err)
+	}
+	return link, nil
+}
+
+// GetByUserIDs retrieves the chats linked to users
+func (r *TelegramLinkRepository) GetByUserIDs(userIDs []int64) ([]models.TelegramLink, error) {
+	query := fmt.Sprintf(`SELECT %s FROM telegram_links WHERE user_id = ANY($1) ORDER BY user_id`, telegramLinkColumns)
+
+	rows, err := r.db.Query(query, pq.Array(userIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get telegram links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []models.TelegramLink
+	for rows.Next() {
+		link, err := scanTelegramLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan telegram link: %w", err)
+		}
+		links = append(links, *link)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate telegram links: %w", err)
+	}
+	return links, nil
+}
+
+// DisableMessages turns Telegram direct messages off in the saved preferences of
+// users, keeping their chats linked. Users without saved preferences get the
+// defaults without Telegram. It returns the number of users updated.
+func (r *TelegramLinkRepository) DisableMessages(userIDs []int64) (int64, error) {
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+
+	query := `
+		INSERT INTO notification_preferences AS np (user_id, channels)
+		SELECT unnest($1::int[]), '{push}'
+		ON CONFLICT (user_id) DO UPDATE
+		SET channels = array_remove(np.channels, $2), updated_at = CURRENT_TIMESTAMP
+		WHERE $2 = ANY(np.channels)
+	`
+	result, err := r.db.Exec(query, pq.Array(userIDs), string(models.NotificationChannelTelegram))
+	if err != nil {
+		return 0, fmt.Errorf("failed to disable telegram notifications: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows, nil
+}
+
+// Delete unlinks the chats of users. It returns the number of chats unlinked.
+func (r *TelegramLinkRepository) Delete(userIDs []int64) (int64, error) {
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+
+	result, err := r.db.Exec(`DELETE FROM telegram_links WHERE user_id = ANY($1)`, pq.Array(userIDs))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete telegram links: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows, nil
+}
@@ -2,30 +2,286 @@ package repositories
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
 )
 
 type TradingSignalRepository struct {
-	db *sql.DB
+	db               *sql.DB
+	embargoCondition string
+	releaseCondition string
+	releaseDelays    string
+	tierReleaseDelay map[string]time.Duration
 }
 
-func NewTradingSignalRepository(db *sql.DB) *TradingSignalRepository {
-	return &TradingSignalRepository{db: db}
+// NewTradingSignalRepository creates the repository. premiumEmbargo holds, keyed by
+// models.SignalCategoryKey, how long after publication premium signals become
+// visible to every user; categories without a positive duration stay subscriber-only.
+// tierReleaseDelay holds, keyed by package tier, how long after publication
+// subscribers of that tier see premium signals.
+func NewTradingSignalRepository(db *sql.DB, premiumEmbargo, tierReleaseDelay map[string]time.Duration) *TradingSignalRepository {
+	return &TradingSignalRepository{
+		db:               db,
+		embargoCondition: buildEmbargoCondition(premiumEmbargo),
+		releaseCondition: buildReleaseCondition(tierReleaseDelay),
+		releaseDelays:    buildReleaseDelays(tierReleaseDelay),
+		tierReleaseDelay: tierReleaseDelay,
+	}
+}
+
+// tradingSignalColumns is the column list selected for every trading signal query (aliased as ts)
+const tradingSignalColumns = `ts.id, ts.symbol, ts.asset_class, ts.duration_type, ts.stop_loss_price, ts.entry_price, ts.take_profit_price,
+	ts.type, ts.status, ts.exit_price, ts.result, ts.return, ts.free_for_all, ts.comments, ts.created_by,
+	ts.activated_at, ts.closed_at, ts.publish_at, ts.published_at, ts.valid_until, ts.created_at, ts.updated_at,
+	(SELECT i.pip_size FROM instruments i WHERE i.asset_class = ts.asset_class AND i.symbol = ts.symbol) AS pip_size`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// queryer is implemented by both *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// extraColumnScanner scans columns selected after tradingSignalColumns into extra
+type extraColumnScanner struct {
+	rowScanner
+	extra []interface{}
+}
+
+func (s extraColumnScanner) Scan(dest ...interface{}) error {
+	return s.rowScanner.Scan(append(dest, s.extra...)...)
+}
+
+// scanTradingSignal scans a row selected with tradingSignalColumns
+func scanTradingSignal(row rowScanner) (*models.TradingSignal, error) {
+	var signal models.TradingSignal
+	err := row.Scan(
+		&signal.ID,
+		&signal.Symbol,
+		&signal.AssetClass,
+		&signal.DurationType,
+		&signal.StopLossPrice,
+		&signal.EntryPrice,
+		&signal.TakeProfitPrice,
+		&signal.Type,
+		&signal.Status,
+		&signal.ExitPrice,
+		&signal.Result,
+		&signal.Return,
+		&signal.FreeForAll,
+		&signal.Comments,
+		&signal.CreatedBy,
+		&signal.ActivatedAt,
+		&signal.ClosedAt,
+		&signal.PublishAt,
+		&signal.PublishedAt,
+		&signal.ValidUntil,
+		&signal.CreatedAt,
+		&signal.UpdatedAt,
+		&signal.PipSize,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &signal, nil
+}
+
+// scanTradingSignals scans all rows selected with tradingSignalColumns
+func scanTradingSignals(rows *sql.Rows) ([]models.TradingSignal, error) {
+	var signals []models.TradingSignal
+	for rows.Next() {
+		signal, err := scanTradingSignal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan trading signal: %w", err)
+		}
+		signals = append(signals, *signal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate trading signals: %w", err)
+	}
+	return signals, nil
+}
+
+// visibleToUserCondition is the visibility rule shared by every user-facing query.
+// A published signal is visible when it is free for all, its premium embargo has
+// passed, or the user, whose ID is in placeholder userParam, has an active
+// subscription to a package covering its asset class and duration whose tier
+// release delay has passed.
+func (r *TradingSignalRepository) visibleToUserCondition(userParam int) string {
+	return fmt.Sprintf(`(ts.published_at IS NOT NULL AND (
+		ts.free_for_all = true%s
+		OR EXISTS (
+			SELECT 1 FROM user_subscriptions us
+			JOIN packages p ON us.package_id = p.id
+			WHERE us.user_id = $%d
+			AND us.is_active = true
+			AND us.expires_at > CURRENT_TIMESTAMP
+			AND p.asset_class = ts.asset_class
+			AND p.duration_type = ts.duration_type%s
+		)
+	))`, r.embargoCondition, userParam, r.releaseCondition)
+}
+
+// buildEmbargoCondition renders the clause making premium signals public once
+// the embargo for their asset class and duration has passed. The durations are
+// inlined, and only for known categories, so the clause needs no placeholders.
+func buildEmbargoCondition(premiumEmbargo map[string]time.Duration) string {
+	var cases []string
+	for _, assetClass := range []models.AssetClass{models.AssetClassForex, models.AssetClassCrypto, models.AssetClassPSX} {
+		for _, durationType := range []models.DurationType{models.DurationTypeShortTerm, models.DurationTypeLongTerm} {
+			embargo := premiumEmbargo[models.SignalCategoryKey(assetClass, durationType)]
+			if embargo <= 0 {
+				continue
+			}
+			cases = append(cases, fmt.Sprintf("WHEN ts.asset_class = '%s' AND ts.duration_type = '%s' THEN INTERVAL '%d seconds'",
+				assetClass, durationType, int64(embargo.Seconds())))
+		}
+	}
+	if len(cases) == 0 {
+		return ""
+	}
+	return "\n\t\tOR ts.published_at <= CURRENT_TIMESTAMP - CASE " + strings.Join(cases, " ") + " END"
+}
+
+// buildReleaseCondition renders the clause holding premium signals back from
+// subscribers until the release delay of their package tier has passed
+func buildReleaseCondition(tierReleaseDelay map[string]time.Duration) string {
+	var cases []string
+	for _, tier := range models.PackageTiers {
+		if delay := tierReleaseDelay[string(tier)]; delay > 0 {
+			cases = append(cases, fmt.Sprintf("WHEN '%s' THEN INTERVAL '%d seconds'", tier, int64(delay.Seconds())))
+		}
+	}
+	if len(cases) == 0 {
+		return ""
+	}
+	return "\n\t\t\tAND ts.published_at <= CURRENT_TIMESTAMP - CASE p.tier " + strings.Join(cases, " ") + " ELSE INTERVAL '0 seconds' END"
+}
+
+// buildReleaseDelays renders the tiers released after publication as a VALUES list
+// of (tier, delay) rows, empty when every tier sees signals on publication
+func buildReleaseDelays(tierReleaseDelay map[string]time.Duration) string {
+	var rows []string
+	for _, tier := range models.PackageTiers {
+		if delay := tierReleaseDelay[string(tier)]; delay > 0 {
+			rows = append(rows, fmt.Sprintf("('%s', INTERVAL '%d seconds')", tier, int64(delay.Seconds())))
+		}
+	}
+	return strings.Join(rows, ", ")
+}
+
+// signalFilterConditions returns the WHERE conditions for filter. Filter values are
+// appended to args so placeholders continue the numbering of the existing arguments.
+func signalFilterConditions(filter *models.TradingSignalFilter, args []interface{}) ([]string, []interface{}) {
+	var conditions []string
+	if filter == nil {
+		return conditions, args
+	}
+
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.AssetClass != nil {
+		add("ts.asset_class = $%d", *filter.AssetClass)
+	}
+	if filter.DurationType != nil {
+		add("ts.duration_type = $%d", *filter.DurationType)
+	}
+	if filter.Symbol != "" {
+		add(`UPPER(ts.symbol) LIKE $%d ESCAPE '\'`, escapeLike(strings.ToUpper(filter.Symbol))+"%")
+	}
+	if filter.Type != nil {
+		add("ts.type = $%d", *filter.Type)
+	}
+	if filter.Result != nil {
+		add("ts.result = $%d", *filter.Result)
+	}
+	if filter.Status != nil {
+		add("ts.status = $%d", *filter.Status)
+	}
+	if filter.FreeForAll != nil {
+		add("ts.free_for_all = $%d", *filter.FreeForAll)
+	}
+	if filter.CreatedBy != nil {
+		add("ts.created_by = $%d", *filter.CreatedBy)
+	}
+	if filter.CreatedFrom != nil {
+		add("ts.created_at >= $%d", *filter.CreatedFrom)
+	}
+	if filter.CreatedTo != nil {
+		add("ts.created_at < $%d", *filter.CreatedTo)
+	}
+
+	return conditions, args
+}
+
+// signalOrderBy returns the ORDER BY clause for filter. Only whitelisted columns are used.
+func signalOrderBy(filter *models.TradingSignalFilter) string {
+	direction := "DESC"
+	if filter != nil && filter.SortOrder == "asc" {
+		direction = "ASC"
+	}
+
+	if filter != nil && filter.SortBy == models.SignalSortReturn {
+		return fmt.Sprintf("ORDER BY ts.return %s NULLS LAST, ts.id %s", direction, direction)
+	}
+	return fmt.Sprintf("ORDER BY ts.created_at %s, ts.id %s", direction, direction)
+}
+
+// signalKeysetCondition adds the cursor position of page to conditions. Cursors
+// are only valid for lists ordered by creation time.
+func signalKeysetCondition(filter *models.TradingSignalFilter, page models.PageRequest, conditions []string, args []interface{}) ([]string, []interface{}) {
+	if page.After == nil {
+		return conditions, args
+	}
+	ascending := filter != nil && filter.SortOrder == "asc"
+	condition, args := keysetCondition("ts.", page.After, ascending, args)
+	return append(conditions, condition), args
+}
+
+func whereClause(conditions []string) string {
+	if len(conditions) == 0 {
+		return ""
+	}
+	return "WHERE " + strings.Join(conditions, "\n\t\tAND ")
+}
+
+// escapeLike escapes the LIKE wildcards in a user supplied pattern
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
 }
 
-// Create creates a new trading signal
+// Create creates a new trading signal and records its initial status. Signals
+// published right away have their new signal notifications queued in the same
+// transaction.
 func (r *TradingSignalRepository) Create(signal *models.TradingSignalCreate, createdBy int64) (*models.TradingSignal, error) {
+	status := signal.Status
+	if status == "" {
+		status = models.SignalStatusPending
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
-		INSERT INTO trading_signals (symbol, asset_class, duration_type, stop_loss_price, entry_price, take_profit_price, type, result, return, free_for_all, comments, created_by)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
-		RETURNING id, symbol, asset_class, duration_type, stop_loss_price, entry_price, take_profit_price, type, result, return, free_for_all, comments, created_by, created_at, updated_at
-	`
+		INSERT INTO trading_signals AS ts (symbol, asset_class, duration_type, stop_loss_price, entry_price, take_profit_price, type, status, activated_at, free_for_all, comments, created_by, publish_at, published_at, valid_until)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8::varchar, CASE WHEN $8::varchar = 'ACTIVE' THEN CURRENT_TIMESTAMP END, $9, $10, $11,
+			$12::timestamp, CASE WHEN $12::timestamp IS NULL THEN CURRENT_TIMESTAMP END, $13)
+		RETURNING ` + tradingSignalColumns
 
-	var newSignal models.TradingSignal
-	err := r.db.QueryRow(
+	newSignal, err := scanTradingSignal(tx.QueryRow(
 		query,
 		signal.Symbol,
 		signal.AssetClass,
@@ -34,63 +290,51 @@ func (r *TradingSignalRepository) Create(signal *models.TradingSignalCreate, cre
 		signal.EntryPrice,
 		signal.TakeProfitPrice,
 		signal.Type,
-		signal.Result,
-		signal.Return,
+		status,
 		signal.FreeForAll,
 		signal.Comments,
 		createdBy,
-	).Scan(
-		&newSignal.ID,
-		&newSignal.Symbol,
-		&newSignal.AssetClass,
-		&newSignal.DurationType,
-		&newSignal.StopLossPrice,
-		&newSignal.EntryPrice,
-		&newSignal.TakeProfitPrice,
-		&newSignal.Type,
-		&newSignal.Result,
-		&newSignal.Return,
-		&newSignal.FreeForAll,
-		&newSignal.Comments,
-		&newSignal.CreatedBy,
-		&newSignal.CreatedAt,
-		&newSignal.UpdatedAt,
-	)
-
+		signal.PublishAt,
+		signal.ValidUntil,
+	))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create trading signal: %w", err)
 	}
 
-	return &newSignal, nil
+	if err := insertStatusTransition(tx, newSignal.ID, nil, status, nil, nil, &createdBy); err != nil {
+		return nil, err
+	}
+
+	newSignal.Targets, err = replaceTargets(tx, newSignal.ID, signal.Targets)
+	if err != nil {
+		return nil, err
+	}
+
+	if newSignal.PublishedAt != nil {
+		if err := r.scheduleTierReleases(tx, []int64{newSignal.ID}); err != nil {
+			return nil, err
+		}
+		if err := r.enqueuePublished(tx, newSignal); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit trading signal: %w", err)
+	}
+
+	return newSignal, nil
 }
 
 // GetByID retrieves a trading signal by ID
 func (r *TradingSignalRepository) GetByID(id int64) (*models.TradingSignal, error) {
 	query := `
-		SELECT id, symbol, asset_class, duration_type, stop_loss_price, entry_price, take_profit_price, type, result, return, free_for_all, comments, created_by, created_at, updated_at
-		FROM trading_signals
-		WHERE id = $1
+		SELECT ` + tradingSignalColumns + `
+		FROM trading_signals ts
+		WHERE ts.id = $1
 	`
 
-	var signal models.TradingSignal
-	err := r.db.QueryRow(query, id).Scan(
-		&signal.ID,
-		&signal.Symbol,
-		&signal.AssetClass,
-		&signal.DurationType,
-		&signal.StopLossPrice,
-		&signal.EntryPrice,
-		&signal.TakeProfitPrice,
-		&signal.Type,
-		&signal.Result,
-		&signal.Return,
-		&signal.FreeForAll,
-		&signal.Comments,
-		&signal.CreatedBy,
-		&signal.CreatedAt,
-		&signal.UpdatedAt,
-	)
-
+	signal, err := scanTradingSignal(r.db.QueryRow(query, id))
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -98,55 +342,36 @@ func (r *TradingSignalRepository) GetByID(id int64) (*models.TradingSignal, erro
 		return nil, fmt.Errorf("failed to get trading signal: %w", err)
 	}
 
-	return &signal, nil
+	return signal, nil
 }
 
 // GetAll retrieves all trading signals with optional filtering
-func (r *TradingSignalRepository) GetAll(limit, offset int) ([]models.TradingSignal, error) {
-	query := `
-		SELECT id, symbol, asset_class, duration_type, stop_loss_price, entry_price, take_profit_price, type, result, return, free_for_all, comments, created_by, created_at, updated_at
-		FROM trading_signals
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
-	`
+func (r *TradingSignalRepository) GetAll(filter *models.TradingSignalFilter, page models.PageRequest) ([]models.TradingSignal, error) {
+	conditions, args := signalFilterConditions(filter, nil)
+	conditions, args = signalKeysetCondition(filter, page, conditions, args)
+	args = append(args, page.Limit, page.Offset)
 
-	rows, err := r.db.Query(query, limit, offset)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM trading_signals ts
+		%s
+		%s
+		LIMIT $%d OFFSET $%d
+	`, tradingSignalColumns, whereClause(conditions), signalOrderBy(filter), len(args)-1, len(args))
+
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get trading signals: %w", err)
 	}
 	defer rows.Close()
 
-	var signals []models.TradingSignal
-	for rows.Next() {
-		var signal models.TradingSignal
-		err := rows.Scan(
-			&signal.ID,
-			&signal.Symbol,
-			&signal.AssetClass,
-			&signal.DurationType,
-			&signal.StopLossPrice,
-			&signal.EntryPrice,
-			&signal.TakeProfitPrice,
-			&signal.Type,
-			&signal.Result,
-			&signal.Return,
-			&signal.FreeForAll,
-			&signal.Comments,
-			&signal.CreatedBy,
-			&signal.CreatedAt,
-			&signal.UpdatedAt,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan trading signal: %w", err)
-		}
-		signals = append(signals, signal)
-	}
-
-	return signals, nil
+	return scanTradingSignals(rows)
 }
 
-// Update updates a trading signal
-func (r *TradingSignalRepository) Update(id int64, update *models.TradingSignalUpdate) (*models.TradingSignal, error) {
+// Update updates a trading signal and records the edit as a revision, queuing its
+// notification when a running setup changed. It returns nil if the signal does
+// not exist.
+func (r *TradingSignalRepository) Update(id int64, update *models.TradingSignalUpdate, editedBy int64) (*models.TradingSignal, error) {
 	// Build dynamic update query
 	var setClauses []string
 	var args []interface{}
@@ -187,16 +412,6 @@ func (r *TradingSignalRepository) Update(id int64, update *models.TradingSignalU
 		args = append(args, *update.Type)
 		argPosition++
 	}
-	if update.Result != nil {
-		setClauses = append(setClauses, fmt.Sprintf("result = $%d", argPosition))
-		args = append(args, *update.Result)
-		argPosition++
-	}
-	if update.Return != nil {
-		setClauses = append(setClauses, fmt.Sprintf("return = $%d", argPosition))
-		args = append(args, *update.Return)
-		argPosition++
-	}
 	if update.FreeForAll != nil {
 		setClauses = append(setClauses, fmt.Sprintf("free_for_all = $%d", argPosition))
 		args = append(args, *update.FreeForAll)
@@ -207,103 +422,169 @@ func (r *TradingSignalRepository) Update(id int64, update *models.TradingSignalU
 		args = append(args, *update.Comments)
 		argPosition++
 	}
+	if update.ValidUntil != nil {
+		setClauses = append(setClauses, fmt.Sprintf("valid_until = $%d", argPosition))
+		args = append(args, *update.ValidUntil)
+		argPosition++
+	}
 
-	if len(setClauses) == 0 {
+	if len(setClauses) == 0 && update.Targets == nil {
 		return r.GetByID(id)
 	}
 
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	before, err := scanTradingSignal(tx.QueryRow(
+		fmt.Sprintf(`SELECT %s FROM trading_signals ts WHERE ts.id = $1 FOR UPDATE`, tradingSignalColumns), id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trading signal: %w", err)
+	}
+	beforeTargets, err := getTargetsForSignals(tx, []int64{id})
+	if err != nil {
+		return nil, err
+	}
+	before.Targets = beforeTargets[id]
+
 	setClauses = append(setClauses, "updated_at = CURRENT_TIMESTAMP")
 	args = append(args, id)
 
 	query := fmt.Sprintf(`
-		UPDATE trading_signals
+		UPDATE trading_signals ts
 		SET %s
-		WHERE id = $%d
-		RETURNING id, symbol, asset_class, duration_type, stop_loss_price, entry_price, take_profit_price, type, result, return, free_for_all, comments, created_by, created_at, updated_at
-	`, strings.Join(setClauses, ", "), argPosition)
-
-	var signal models.TradingSignal
-	err := r.db.QueryRow(query, args...).Scan(
-		&signal.ID,
-		&signal.Symbol,
-		&signal.AssetClass,
-		&signal.DurationType,
-		&signal.StopLossPrice,
-		&signal.EntryPrice,
-		&signal.TakeProfitPrice,
-		&signal.Type,
-		&signal.Result,
-		&signal.Return,
-		&signal.FreeForAll,
-		&signal.Comments,
-		&signal.CreatedBy,
-		&signal.CreatedAt,
-		&signal.UpdatedAt,
-	)
+		WHERE ts.id = $%d
+		RETURNING %s
+	`, strings.Join(setClauses, ", "), argPosition, tradingSignalColumns)
 
-	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("trading signal not found")
-	}
+	signal, err := scanTradingSignal(tx.QueryRow(query, args...))
 	if err != nil {
 		return nil, fmt.Errorf("failed to update trading signal: %w", err)
 	}
 
-	return &signal, nil
+	signal.Targets = before.Targets
+	if update.Targets != nil {
+		signal.Targets, err = replaceTargets(tx, id, update.Targets)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	changes := models.DiffTradingSignals(before, signal)
+	if len(changes) > 0 {
+		if err := insertRevision(tx, id, changes, update.Reason, editedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	// Only edits of running setups concern subscribers
+	if len(changes) > 0 && signal.Status.IsOpen() {
+		notification := &models.SignalNotification{
+			Event:   models.NotificationEventUpdated,
+			Signal:  signal,
+			Changes: changes,
+			Reason:  update.Reason,
+		}
+		if err := enqueueEvent(tx, notification); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit trading signal: %w", err)
+	}
+
+	return signal, nil
 }
 
-// Delete deletes a trading signal
+// Delete deletes a trading signal. Deleting a running setup withdraws it, so its
+// cancellation is queued in the same transaction.
 func (r *TradingSignalRepository) Delete(id int64) error {
-	query := `DELETE FROM trading_signals WHERE id = $1`
-
-	result, err := r.db.Exec(query, id)
+	tx, err := r.db.Begin()
 	if err != nil {
-		return fmt.Errorf("failed to delete trading signal: %w", err)
+		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
+	defer tx.Rollback()
 
-	rows, err := result.RowsAffected()
+	signal, err := scanTradingSignal(tx.QueryRow(
+		fmt.Sprintf(`SELECT %s FROM trading_signals ts WHERE ts.id = $1 FOR UPDATE`, tradingSignalColumns), id,
+	))
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("trading signal not found")
+	}
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return fmt.Errorf("failed to get trading signal: %w", err)
 	}
 
-	if rows == 0 {
-		return fmt.Errorf("trading signal not found")
+	if signal.Status.IsOpen() {
+		notification := &models.SignalNotification{
+			Event:  models.NotificationEventCancelled,
+			Signal: signal,
+			Reason: "The signal has been withdrawn.",
+		}
+		if err := enqueueEvent(tx, notification); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`DELETE FROM trading_signals WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete trading signal: %w", err)
 	}
 
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit trading signal deletion: %w", err)
+	}
 	return nil
 }
 
 // Count returns the total count of trading signals
-func (r *TradingSignalRepository) Count() (int64, error) {
+func (r *TradingSignalRepository) Count(filter *models.TradingSignalFilter) (int64, error) {
+	conditions, args := signalFilterConditions(filter, nil)
+
 	var count int64
-	query := `SELECT COUNT(*) FROM trading_signals`
-	err := r.db.QueryRow(query).Scan(&count)
+	query := fmt.Sprintf(`SELECT COUNT(*) FROM trading_signals ts %s`, whereClause(conditions))
+	err := r.db.QueryRow(query, args...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count trading signals: %w", err)
 	}
 	return count, nil
 }
 
-// GetSignalsForUser retrieves trading signals visible to a specific user based on their subscriptions
-func (r *TradingSignalRepository) GetSignalsForUser(userID int64, limit, offset int) ([]models.TradingSignal, error) {
-	query := `
-		SELECT DISTINCT ts.id, ts.symbol, ts.asset_class, ts.duration_type, ts.stop_loss_price, ts.entry_price, 
-			ts.take_profit_price, ts.type, ts.result, ts.return, ts.free_for_all, ts.comments, ts.created_by, ts.created_at, ts.updated_at
+// userSignalScope returns the condition selecting the signals listed to the user in
+// placeholder userParam, and the expression telling whether a listed signal is locked.
+// With filter.IncludeLocked every published signal is listed.
+func (r *TradingSignalRepository) userSignalScope(filter *models.TradingSignalFilter, userParam int) (string, string) {
+	visible := r.visibleToUserCondition(userParam)
+	if filter != nil && filter.IncludeLocked {
+		return "ts.published_at IS NOT NULL", "NOT " + visible
+	}
+	return visible, "false"
+}
+
+// GetSignalsForUser retrieves trading signals visible to a specific user based on their
+// subscriptions. Signals included through filter.IncludeLocked are marked Locked.
+func (r *TradingSignalRepository) GetSignalsForUser(userID int64, filter *models.TradingSignalFilter, page models.PageRequest) ([]models.TradingSignal, error) {
+	scope, lockedExpr := r.userSignalScope(filter, 1)
+	conditions, args := signalFilterConditions(filter, []interface{}{userID})
+	conditions = append([]string{scope}, conditions...)
+	conditions, args = signalKeysetCondition(filter, page, conditions, args)
+	args = append(args, page.Limit, page.Offset)
+
+	query := fmt.Sprintf(`
+		SELECT %s, %s AS locked
 		FROM trading_signals ts
-		WHERE ts.free_for_all = true
-		OR EXISTS (
-			SELECT 1 FROM user_subscriptions us
-			JOIN packages p ON us.package_id = p.id
-			WHERE us.user_id = $1
-			AND us.is_active = true
-			AND us.expires_at > CURRENT_TIMESTAMP
-			AND p.asset_class = ts.asset_class
-			AND p.duration_type = ts.duration_type
-		)
-		ORDER BY ts.created_at DESC
-		LIMIT $2 OFFSET $3
-	`
+		%s
+		%s
+		LIMIT $%d OFFSET $%d
+	`, tradingSignalColumns, lockedExpr, whereClause(conditions), signalOrderBy(filter), len(args)-1, len(args))
 
-	rows, err := r.db.Query(query, userID, limit, offset)
+	rows, err := r.db.Query(query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get trading signals for user: %w", err)
 	}
@@ -311,51 +592,37 @@ func (r *TradingSignalRepository) GetSignalsForUser(userID int64, limit, offset
 
 	var signals []models.TradingSignal
 	for rows.Next() {
-		var signal models.TradingSignal
-		err := rows.Scan(
-			&signal.ID,
-			&signal.Symbol,
-			&signal.AssetClass,
-			&signal.DurationType,
-			&signal.StopLossPrice,
-			&signal.EntryPrice,
-			&signal.TakeProfitPrice,
-			&signal.Type,
-			&signal.Result,
-			&signal.Return,
-			&signal.FreeForAll,
-			&signal.Comments,
-			&signal.CreatedBy,
-			&signal.CreatedAt,
-			&signal.UpdatedAt,
-		)
+		var locked bool
+		signal, err := scanTradingSignal(extraColumnScanner{rows, []interface{}{&locked}})
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan trading signal: %w", err)
 		}
-		signals = append(signals, signal)
+		signal.Locked = locked
+		signals = append(signals, *signal)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate trading signals: %w", err)
 	}
-
 	return signals, nil
 }
 
-// CountForUser returns the total count of trading signals visible to a specific user
-func (r *TradingSignalRepository) CountForUser(userID int64) (int64, error) {
+// CountForUser returns the total count of trading signals listed to a specific user
+func (r *TradingSignalRepository) CountForUser(userID int64, filter *models.TradingSignalFilter) (int64, error) {
+	// Counting every published signal does not depend on the user
+	scope, args := "ts.published_at IS NOT NULL", []interface{}(nil)
+	if filter == nil || !filter.IncludeLocked {
+		scope, args = r.visibleToUserCondition(1), []interface{}{userID}
+	}
+	conditions, args := signalFilterConditions(filter, args)
+	conditions = append([]string{scope}, conditions...)
+
 	var count int64
-	query := `
+	query := fmt.Sprintf(`
 		SELECT COUNT(DISTINCT ts.id)
 		FROM trading_signals ts
-		WHERE ts.free_for_all = true
-		OR EXISTS (
-			SELECT 1 FROM user_subscriptions us
-			JOIN packages p ON us.package_id = p.id
-			WHERE us.user_id = $1
-			AND us.is_active = true
-			AND us.expires_at > CURRENT_TIMESTAMP
-			AND p.asset_class = ts.asset_class
-			AND p.duration_type = ts.duration_type
-		)
-	`
-	err := r.db.QueryRow(query, userID).Scan(&count)
+		%s
+	`, whereClause(conditions))
+	err := r.db.QueryRow(query, args...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count trading signals for user: %w", err)
 	}
@@ -368,18 +635,7 @@ func (r *TradingSignalRepository) CheckUserAccess(userID, signalID int64) (bool,
 		SELECT EXISTS (
 			SELECT 1 FROM trading_signals ts
 			WHERE ts.id = $2
-			AND (
-				ts.free_for_all = true
-				OR EXISTS (
-					SELECT 1 FROM user_subscriptions us
-					JOIN packages p ON us.package_id = p.id
-					WHERE us.user_id = $1
-					AND us.is_active = true
-					AND us.expires_at > CURRENT_TIMESTAMP
-					AND p.asset_class = ts.asset_class
-					AND p.duration_type = ts.duration_type
-				)
-			)
+			AND ` + r.visibleToUserCondition(1) + `
 		)
 	`
 	var hasAccess bool
@@ -389,3 +645,846 @@ func (r *TradingSignalRepository) CheckUserAccess(userID, signalID int64) (bool,
 	}
 	return hasAccess, nil
 }
+
+// GetOpenSignals retrieves all published signals that are still pending or active
+func (r *TradingSignalRepository) GetOpenSignals() ([]models.TradingSignal, error) {
+	query := `
+		SELECT ` + tradingSignalColumns + `
+		FROM trading_signals ts
+		WHERE ts.status IN ('PENDING', 'ACTIVE')
+		AND ts.published_at IS NOT NULL
+		ORDER BY ts.created_at
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open trading signals: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTradingSignals(rows)
+}
+
+// GetExpiredPending retrieves published signals still waiting for entry whose
+// validity ended at or before cutoff
+func (r *TradingSignalRepository) GetExpiredPending(cutoff time.Time) ([]models.TradingSignal, error) {
+	query := `
+		SELECT ` + tradingSignalColumns + `
+		FROM trading_signals ts
+		WHERE ts.status = 'PENDING'
+		AND ts.published_at IS NOT NULL
+		AND ts.valid_until <= $1
+		ORDER BY ts.valid_until
+	`
+
+	rows, err := r.db.Query(query, cutoff)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired trading signals: %w", err)
+	}
+	defer rows.Close()
+
+	return scanTradingSignals(rows)
+}
+
+// ClaimDueSignals publishes up to limit scheduled signals whose publish time has
+// passed and returns them. Rows locked by another instance are skipped, so each
+// signal is claimed, and its notifications queued, exactly once. The tier releases
+// of premium signals are scheduled and the notifications of the tiers that see
+// them right away queued in the same transaction.
+func (r *TradingSignalRepository) ClaimDueSignals(limit int) ([]models.TradingSignal, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE trading_signals ts
+		SET published_at = CURRENT_TIMESTAMP
+		WHERE ts.id IN (
+			SELECT id FROM trading_signals
+			WHERE published_at IS NULL
+			AND publish_at <= CURRENT_TIMESTAMP
+			ORDER BY publish_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		AND ts.published_at IS NULL
+		RETURNING ` + tradingSignalColumns
+
+	rows, err := tx.Query(query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim scheduled trading signals: %w", err)
+	}
+	signals, err := scanTradingSignals(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(signals))
+	for i := range signals {
+		ids[i] = signals[i].ID
+	}
+	if err := r.scheduleTierReleases(tx, ids); err != nil {
+		return nil, err
+	}
+
+	targets, err := getTargetsForSignals(tx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for i := range signals {
+		signals[i].Targets = targets[signals[i].ID]
+		if err := r.enqueuePublished(tx, &signals[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit published trading signals: %w", err)
+	}
+	return signals, nil
+}
+
+// scheduleTierReleases records when the subscribers of each delayed tier are to
+// be notified of the just published premium signals in signalIDs
+func (r *TradingSignalRepository) scheduleTierReleases(tx *sql.Tx, signalIDs []int64) error {
+	if r.releaseDelays == "" || len(signalIDs) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO signal_tier_releases (signal_id, tier, release_at)
+		SELECT ts.id, d.tier, ts.published_at + d.delay
+		FROM trading_signals ts
+		CROSS JOIN (VALUES ` + r.releaseDelays + `) AS d(tier, delay)
+		WHERE ts.id = ANY($1)
+		AND ts.free_for_all = false
+		ON CONFLICT (signal_id, tier) DO NOTHING
+	`
+	if _, err := tx.Exec(query, pq.Array(signalIDs)); err != nil {
+		return fmt.Errorf("failed to schedule tier releases: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueTierReleases marks up to limit due tier releases as notified and queues
+// the new signal notifications of their tier in the same transaction. Releases are
+// claimed atomically, so each is announced once; signals closed in the meantime
+// are not announced anymore. It returns the number of releases claimed.
+func (r *TradingSignalRepository) ClaimDueTierReleases(limit int) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE signal_tier_releases str
+		SET notified_at = CURRENT_TIMESTAMP
+		WHERE (str.signal_id, str.tier) IN (
+			SELECT signal_id, tier FROM signal_tier_releases
+			WHERE notified_at IS NULL
+			AND release_at <= CURRENT_TIMESTAMP
+			ORDER BY release_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		AND str.notified_at IS NULL
+		RETURNING str.signal_id, str.tier
+	`
+
+	rows, err := tx.Query(query, limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to claim tier releases: %w", err)
+	}
+	var releases []models.SignalTierRelease
+	for rows.Next() {
+		var release models.SignalTierRelease
+		if err := rows.Scan(&release.SignalID, &release.Tier); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan tier release: %w", err)
+		}
+		releases = append(releases, release)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to iterate tier releases: %w", err)
+	}
+	if len(releases) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int64, len(releases))
+	for i := range releases {
+		ids[i] = releases[i].SignalID
+	}
+	rows, err = tx.Query(`SELECT `+tradingSignalColumns+` FROM trading_signals ts WHERE ts.id = ANY($1)`, pq.Array(ids))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get released trading signals: %w", err)
+	}
+	signals, err := scanTradingSignals(rows)
+	rows.Close()
+	if err != nil {
+		return 0, err
+	}
+	targets, err := getTargetsForSignals(tx, ids)
+	if err != nil {
+		return 0, err
+	}
+
+	signalsByID := make(map[int64]*models.TradingSignal, len(signals))
+	for i := range signals {
+		signals[i].Targets = targets[signals[i].ID]
+		signalsByID[signals[i].ID] = &signals[i]
+	}
+	for _, release := range releases {
+		signal := signalsByID[release.SignalID]
+		if signal == nil || !signal.Status.IsOpen() {
+			continue
+		}
+		tier := release.Tier
+		notification := &models.SignalNotification{Event: models.NotificationEventCreated, Signal: signal}
+		if err := insertNotification(tx, notification, &tier); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit tier releases: %w", err)
+	}
+	return len(releases), nil
+}
+
+// enqueuePublished queues the new signal notifications of the tiers that see a just
+// published signal right away; delayed tiers are queued when their release is
+// claimed. Every tier sees free-for-all signals right away.
+func (r *TradingSignalRepository) enqueuePublished(tx *sql.Tx, signal *models.TradingSignal) error {
+	for _, tier := range models.PackageTiers {
+		if !signal.FreeForAll && r.tierReleaseDelay[string(tier)] > 0 {
+			continue
+		}
+		notification := &models.SignalNotification{Event: models.NotificationEventCreated, Signal: signal}
+		if err := insertNotification(tx, notification, &tier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enqueueEvent queues a notification on a change to a signal in the transaction
+// making the change, so it is sent if and only if the change is committed. The
+// signal's targets are loaded when missing. Signals that were never published
+// are not announced, and tiers still waiting for their release are left out:
+// their new signal notification will carry the signal as it is then.
+func enqueueEvent(tx *sql.Tx, notification *models.SignalNotification) error {
+	signal := notification.Signal
+	if signal.PublishedAt == nil {
+		return nil
+	}
+	if signal.Targets == nil {
+		targets, err := getTargetsForSignals(tx, []int64{signal.ID})
+		if err != nil {
+			return err
+		}
+		signal.Targets = targets[signal.ID]
+	}
+	signal.AttachMetrics()
+
+	held, err := heldTiers(tx, signal.ID)
+	if err != nil {
+		return err
+	}
+	if len(held) == 0 {
+		return insertNotification(tx, notification, nil)
+	}
+	for _, tier := range models.PackageTiers {
+		if held[tier] {
+			continue
+		}
+		if err := insertNotification(tx, notification, &tier); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// heldTiers returns the tiers whose subscribers have not been released a signal yet
+func heldTiers(tx *sql.Tx, signalID int64) (map[models.PackageTier]bool, error) {
+	rows, err := tx.Query(`SELECT tier FROM signal_tier_releases WHERE signal_id = $1 AND notified_at IS NULL`, signalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get pending tier releases: %w", err)
+	}
+	defer rows.Close()
+
+	held := make(map[models.PackageTier]bool)
+	for rows.Next() {
+		var tier models.PackageTier
+		if err := rows.Scan(&tier); err != nil {
+			return nil, fmt.Errorf("failed to scan pending tier release: %w", err)
+		}
+		held[tier] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate pending tier releases: %w", err)
+	}
+	return held, nil
+}
+
+// UpdateStatus applies a lifecycle status change, records it in the status history
+// and queues the notification announcing it. The signal is locked and loaded with
+// its targets and partial closes, and change derives the status change from it,
+// so target hits and partial closes made concurrently cannot leave a stale return
+// on the signal. nil is returned when the signal does not exist.
+func (r *TradingSignalRepository) UpdateStatus(id int64, change func(*models.TradingSignal) (*models.SignalStatusChange, error)) (*models.TradingSignal, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	current, err := scanTradingSignal(tx.QueryRow(
+		fmt.Sprintf(`SELECT %s FROM trading_signals ts WHERE ts.id = $1 FOR UPDATE`, tradingSignalColumns), id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trading signal: %w", err)
+	}
+	targets, err := getTargetsForSignals(tx, []int64{id})
+	if err != nil {
+		return nil, err
+	}
+	current.Targets = targets[id]
+	current.PartialCloses, err = getPartialCloses(tx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	statusChange, err := change(current)
+	if err != nil {
+		return nil, err
+	}
+
+	signal, err := updateStatus(tx, id, statusChange)
+	if err != nil {
+		return nil, err
+	}
+	if signal == nil {
+		return nil, fmt.Errorf("trading signal %d is no longer %s", id, statusChange.From)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit trading signal status: %w", err)
+	}
+
+	return signal, nil
+}
+
+// updateStatus applies a lifecycle status change inside an existing transaction.
+// The update only succeeds if the signal is still in change.From; nil is returned
+// when the status has already moved on.
+func updateStatus(tx *sql.Tx, id int64, change *models.SignalStatusChange) (*models.TradingSignal, error) {
+	query := `
+		UPDATE trading_signals ts
+		SET status = $1::varchar,
+			exit_price = COALESCE($2, ts.exit_price),
+			result = COALESCE($3, ts.result),
+			return = COALESCE($4, ts.return),
+			activated_at = CASE WHEN $1::varchar = 'ACTIVE' THEN CURRENT_TIMESTAMP ELSE ts.activated_at END,
+			closed_at = CASE WHEN $1::varchar NOT IN ('PENDING', 'ACTIVE') THEN CURRENT_TIMESTAMP ELSE ts.closed_at END,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE ts.id = $5 AND ts.status = $6
+		RETURNING ` + tradingSignalColumns
+
+	signal, err := scanTradingSignal(tx.QueryRow(query, change.To, change.ExitPrice, change.Result, change.Return, id, change.From))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update trading signal status: %w", err)
+	}
+
+	if err := insertStatusTransition(tx, id, &change.From, change.To, change.Price, change.Note, change.ChangedBy); err != nil {
+		return nil, err
+	}
+
+	// Reaching the final take profit means every remaining target was hit as well
+	if change.To == models.SignalStatusTPHit {
+		query := `UPDATE trading_signal_targets SET hit_at = CURRENT_TIMESTAMP WHERE signal_id = $1 AND hit_at IS NULL`
+		if _, err := tx.Exec(query, id); err != nil {
+			return nil, fmt.Errorf("failed to mark remaining targets as hit: %w", err)
+		}
+	}
+
+	var notification *models.SignalNotification
+	switch {
+	case change.To.ProducesResult():
+		notification = &models.SignalNotification{Event: models.NotificationEventClosed, Signal: signal}
+	case change.To == models.SignalStatusCancelled:
+		notification = &models.SignalNotification{Event: models.NotificationEventCancelled, Signal: signal}
+		if change.Note != nil {
+			notification.Reason = *change.Note
+		}
+	case change.To == models.SignalStatusExpired:
+		notification = &models.SignalNotification{Event: models.NotificationEventExpired, Signal: signal}
+	}
+	if notification != nil {
+		if err := enqueueEvent(tx, notification); err != nil {
+			return nil, err
+		}
+	}
+
+	return signal, nil
+}
+
+// GetStatusHistory retrieves the lifecycle transitions of a signal in chronological order
+func (r *TradingSignalRepository) GetStatusHistory(signalID int64) ([]models.SignalStatusTransition, error) {
+	query := `
+		SELECT id, signal_id, from_status, to_status, price, note, changed_by, created_at
+		FROM trading_signal_status_history
+		WHERE signal_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.Query(query, signalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status history: %w", err)
+	}
+	defer rows.Close()
+
+	var transitions []models.SignalStatusTransition
+	for rows.Next() {
+		var transition models.SignalStatusTransition
+		err := rows.Scan(
+			&transition.ID,
+			&transition.SignalID,
+			&transition.FromStatus,
+			&transition.ToStatus,
+			&transition.Price,
+			&transition.Note,
+			&transition.ChangedBy,
+			&transition.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan status transition: %w", err)
+		}
+		transitions = append(transitions, transition)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate status history: %w", err)
+	}
+
+	return transitions, nil
+}
+
+// insertStatusTransition records a lifecycle transition inside an existing transaction
+func insertStatusTransition(tx *sql.Tx, signalID int64, from *models.SignalStatus, to models.SignalStatus, price *float64, note *string, changedBy *int64) error {
+	query := `
+		INSERT INTO trading_signal_status_history (signal_id, from_status, to_status, price, note, changed_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+	`
+
+	if _, err := tx.Exec(query, signalID, from, to, price, note, changedBy); err != nil {
+		return fmt.Errorf("failed to record status transition: %w", err)
+	}
+	return nil
+}
+
+// GetRevisions retrieves the edits made to a signal, oldest first
+func (r *TradingSignalRepository) GetRevisions(signalID int64) ([]models.SignalRevision, error) {
+	query := `
+		SELECT id, signal_id, revision, changes, reason, edited_by, created_at
+		FROM trading_signal_revisions
+		WHERE signal_id = $1
+		ORDER BY revision
+	`
+
+	rows, err := r.db.Query(query, signalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signal revisions: %w", err)
+	}
+	defer rows.Close()
+
+	revisions := []models.SignalRevision{}
+	for rows.Next() {
+		var revision models.SignalRevision
+		var changes []byte
+		err := rows.Scan(
+			&revision.ID,
+			&revision.SignalID,
+			&revision.Revision,
+			&changes,
+			&revision.Reason,
+			&revision.EditedBy,
+			&revision.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan signal revision: %w", err)
+		}
+		if err := json.Unmarshal(changes, &revision.Changes); err != nil {
+			return nil, fmt.Errorf("failed to decode signal revision changes: %w", err)
+		}
+		revisions = append(revisions, revision)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate signal revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+// insertRevision records an edit inside an existing transaction. The signal row
+// must be locked so revision numbers are assigned in order.
+func insertRevision(tx *sql.Tx, signalID int64, changes map[string]models.FieldChange, reason string, editedBy int64) error {
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return fmt.Errorf("failed to encode signal revision changes: %w", err)
+	}
+
+	query := `
+		INSERT INTO trading_signal_revisions (signal_id, revision, changes, reason, edited_by)
+		SELECT $1, COALESCE(MAX(revision), 0) + 1, $2, $3, $4
+		FROM trading_signal_revisions
+		WHERE signal_id = $1
+	`
+
+	if _, err := tx.Exec(query, signalID, string(changesJSON), reason, editedBy); err != nil {
+		return fmt.Errorf("failed to record signal revision: %w", err)
+	}
+	return nil
+}
+
+// signalUpdateColumns is the column list selected for every signal update query
+const signalUpdateColumns = `id, signal_id, message, new_stop_loss, close_percent, close_price, created_by, created_at`
+
+// scanSignalUpdate scans a row selected with signalUpdateColumns
+func scanSignalUpdate(row rowScanner) (*models.SignalUpdate, error) {
+	var update models.SignalUpdate
+	err := row.Scan(
+		&update.ID,
+		&update.SignalID,
+		&update.Message,
+		&update.NewStopLoss,
+		&update.ClosePercent,
+		&update.ClosePrice,
+		&update.CreatedBy,
+		&update.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &update, nil
+}
+
+// CreateUpdate posts an update to the timeline of a signal and applies its new
+// stop loss, recording the move as a revision, and queues its notification. Like
+// UpdateStatus it only succeeds while the signal is still in status; nil is
+// returned when the status has moved on.
+func (r *TradingSignalRepository) CreateUpdate(signalID int64, status models.SignalStatus, create *models.SignalUpdateCreate, createdBy int64) (*models.SignalUpdate, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var stopLoss float64
+	err = tx.QueryRow(
+		`SELECT stop_loss_price FROM trading_signals WHERE id = $1 AND status = $2 FOR UPDATE`, signalID, status,
+	).Scan(&stopLoss)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trading signal: %w", err)
+	}
+
+	if create.NewStopLoss != nil && *create.NewStopLoss != stopLoss {
+		query := `UPDATE trading_signals SET stop_loss_price = $1, updated_at = CURRENT_TIMESTAMP WHERE id = $2`
+		if _, err := tx.Exec(query, *create.NewStopLoss, signalID); err != nil {
+			return nil, fmt.Errorf("failed to move stop loss: %w", err)
+		}
+
+		changes := map[string]models.FieldChange{
+			"stop_loss_price": {From: stopLoss, To: *create.NewStopLoss},
+		}
+		if err := insertRevision(tx, signalID, changes, create.Message, createdBy); err != nil {
+			return nil, err
+		}
+	}
+
+	query := fmt.Sprintf(`
+		INSERT INTO signal_updates (signal_id, message, new_stop_loss, close_percent, close_price, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING %s
+	`, signalUpdateColumns)
+
+	update, err := scanSignalUpdate(tx.QueryRow(
+		query,
+		signalID,
+		create.Message,
+		create.NewStopLoss,
+		create.ClosePercent,
+		create.ClosePrice,
+		createdBy,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signal update: %w", err)
+	}
+
+	signal, err := scanTradingSignal(tx.QueryRow(
+		fmt.Sprintf(`SELECT %s FROM trading_signals ts WHERE ts.id = $1`, tradingSignalColumns), signalID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trading signal: %w", err)
+	}
+	notification := &models.SignalNotification{Event: models.NotificationEventUpdated, Signal: signal, Update: update}
+	if err := enqueueEvent(tx, notification); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit signal update: %w", err)
+	}
+
+	return update, nil
+}
+
+// GetUpdates retrieves the timeline of updates posted to a signal, oldest first
+func (r *TradingSignalRepository) GetUpdates(signalID int64) ([]models.SignalUpdate, error) {
+	query := fmt.Sprintf(`SELECT %s FROM signal_updates WHERE signal_id = $1 ORDER BY created_at, id`, signalUpdateColumns)
+
+	rows, err := r.db.Query(query, signalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signal updates: %w", err)
+	}
+	defer rows.Close()
+
+	updates := []models.SignalUpdate{}
+	for rows.Next() {
+		update, err := scanSignalUpdate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan signal update: %w", err)
+		}
+		updates = append(updates, *update)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate signal updates: %w", err)
+	}
+
+	return updates, nil
+}
+
+// GetPartialCloses retrieves the partial closes made by updates of a signal in the order they were made
+func (r *TradingSignalRepository) GetPartialCloses(signalID int64) ([]models.SignalPartialClose, error) {
+	return getPartialCloses(r.db, signalID)
+}
+
+// getPartialCloses retrieves the partial closes of a signal through q
+func getPartialCloses(q queryer, signalID int64) ([]models.SignalPartialClose, error) {
+	query := `
+		SELECT close_percent, close_price, created_at
+		FROM signal_updates
+		WHERE signal_id = $1 AND close_percent IS NOT NULL
+		ORDER BY created_at, id
+	`
+
+	rows, err := q.Query(query, signalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get partial closes: %w", err)
+	}
+	defer rows.Close()
+
+	var closes []models.SignalPartialClose
+	for rows.Next() {
+		var partial models.SignalPartialClose
+		if err := rows.Scan(&partial.Percent, &partial.Price, &partial.ClosedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan partial close: %w", err)
+		}
+		closes = append(closes, partial)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate partial closes: %w", err)
+	}
+
+	return closes, nil
+}
+
+// GetTargets retrieves the take-profit targets of a signal ordered by position
+func (r *TradingSignalRepository) GetTargets(signalID int64) ([]models.SignalTarget, error) {
+	targets, err := r.GetTargetsForSignals([]int64{signalID})
+	if err != nil {
+		return nil, err
+	}
+	return targets[signalID], nil
+}
+
+// GetTargetsForSignals retrieves the take-profit targets of several signals keyed by signal ID
+func (r *TradingSignalRepository) GetTargetsForSignals(signalIDs []int64) (map[int64][]models.SignalTarget, error) {
+	return getTargetsForSignals(r.db, signalIDs)
+}
+
+func getTargetsForSignals(q queryer, signalIDs []int64) (map[int64][]models.SignalTarget, error) {
+	targets := make(map[int64][]models.SignalTarget)
+	if len(signalIDs) == 0 {
+		return targets, nil
+	}
+
+	query := `
+		SELECT id, signal_id, position, price, allocation_percent, hit_at
+		FROM trading_signal_targets
+		WHERE signal_id = ANY($1)
+		ORDER BY signal_id, position
+	`
+
+	rows, err := q.Query(query, pq.Array(signalIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get signal targets: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var target models.SignalTarget
+		err := rows.Scan(
+			&target.ID,
+			&target.SignalID,
+			&target.Position,
+			&target.Price,
+			&target.AllocationPercent,
+			&target.HitAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan signal target: %w", err)
+		}
+		targets[target.SignalID] = append(targets[target.SignalID], target)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate signal targets: %w", err)
+	}
+
+	return targets, nil
+}
+
+// MarkTargetHit records that a target of an ACTIVE signal was reached and queues
+// its notification. Reaching the final target closes the signal as TP_HIT at that
+// target's price in the same transaction. The signal is locked while its status
+// is checked, so a concurrent close cannot slip in between. It returns nil when
+// the signal is not ACTIVE (anymore), and false if the target does not exist or
+// was already hit.
+func (r *TradingSignalRepository) MarkTargetHit(signalID int64, position int, changedBy *int64) (*models.TradingSignal, bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	signal, err := scanTradingSignal(tx.QueryRow(
+		fmt.Sprintf(`SELECT %s FROM trading_signals ts WHERE ts.id = $1 AND ts.status = $2 FOR UPDATE`, tradingSignalColumns),
+		signalID, models.SignalStatusActive,
+	))
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get trading signal: %w", err)
+	}
+
+	query := `
+		UPDATE trading_signal_targets
+		SET hit_at = CURRENT_TIMESTAMP
+		WHERE signal_id = $1 AND position = $2 AND hit_at IS NULL
+	`
+
+	result, err := tx.Exec(query, signalID, position)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to mark target as hit: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return signal, false, nil
+	}
+
+	targets, err := getTargetsForSignals(tx, []int64{signalID})
+	if err != nil {
+		return nil, false, err
+	}
+	signal.Targets = targets[signalID]
+
+	notification := &models.SignalNotification{Event: models.NotificationEventTargetHit, Signal: signal}
+	for i := range signal.Targets {
+		if signal.Targets[i].Position == position {
+			notification.Target = &signal.Targets[i]
+		}
+	}
+	if err := enqueueEvent(tx, notification); err != nil {
+		return nil, false, err
+	}
+
+	if signal.HitTargetCount() == len(signal.Targets) {
+		signal.PartialCloses, err = getPartialCloses(tx, signalID)
+		if err != nil {
+			return nil, false, err
+		}
+
+		exitPrice := notification.Target.Price
+		ret := signal.BlendedReturn(exitPrice)
+		result := models.ResultForReturn(ret)
+		closed, err := updateStatus(tx, signalID, &models.SignalStatusChange{
+			From:      models.SignalStatusActive,
+			To:        models.SignalStatusTPHit,
+			Price:     &exitPrice,
+			ExitPrice: &exitPrice,
+			Return:    &ret,
+			Result:    &result,
+			ChangedBy: changedBy,
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		closed.Targets = signal.Targets
+		signal = closed
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit target hit: %w", err)
+	}
+	return signal, true, nil
+}
+
+// replaceTargets replaces all targets of a signal inside an existing transaction
+func replaceTargets(tx *sql.Tx, signalID int64, inputs []models.SignalTargetInput) ([]models.SignalTarget, error) {
+	if _, err := tx.Exec(`DELETE FROM trading_signal_targets WHERE signal_id = $1`, signalID); err != nil {
+		return nil, fmt.Errorf("failed to clear signal targets: %w", err)
+	}
+
+	query := `
+		INSERT INTO trading_signal_targets (signal_id, position, price, allocation_percent)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, signal_id, position, price, allocation_percent, hit_at
+	`
+
+	targets := make([]models.SignalTarget, 0, len(inputs))
+	for i, input := range inputs {
+		var target models.SignalTarget
+		err := tx.QueryRow(query, signalID, i+1, input.Price, input.AllocationPercent).Scan(
+			&target.ID,
+			&target.SignalID,
+			&target.Position,
+			&target.Price,
+			&target.AllocationPercent,
+			&target.HitAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create signal target: %w", err)
+		}
+		targets = append(targets, target)
+	}
+
+	return targets, nil
+}
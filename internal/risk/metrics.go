@@ -0,0 +1,185 @@
+package risk
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Distance units for each asset class
+const (
+	UnitPips    = "pips"    // FOREX
+	UnitPKR     = "PKR"     // PSX, rupees per share
+	UnitPercent = "percent" // CRYPTO
+)
+
+// Default pip sizes for currency pairs
+const (
+	DefaultPipSize = 0.0001
+	JPYPipSize     = 0.01
+)
+
+// Position describes the price levels of a trade
+type Position struct {
+	AssetClass string // FOREX, CRYPTO or PSX
+	Symbol     string
+	Short      bool
+	Entry      float64
+	StopLoss   float64
+	Targets    []Target // Ordered take-profit levels; the last is the final take profit
+
+	// PipSize overrides the pip size derived from the symbol for FOREX positions
+	PipSize float64
+}
+
+// Target is a take-profit level holding a share of the position
+type Target struct {
+	Price             float64
+	AllocationPercent float64
+}
+
+// Metrics are the derived risk figures of a position. Distances are always
+// positive and expressed in Unit.
+type Metrics struct {
+	RiskReward         float64         `json:"risk_reward"`
+	StopLossPercent    float64         `json:"stop_loss_percent"`
+	TakeProfitPercent  float64         `json:"take_profit_percent"`
+	StopLossDistance   float64         `json:"stop_loss_distance"`
+	TakeProfitDistance float64         `json:"take_profit_distance"`
+	Unit               string          `json:"unit"`
+	Targets            []TargetMetrics `json:"targets,omitempty"`
+}
+
+// TargetMetrics are the derived figures of a single take-profit level
+type TargetMetrics struct {
+	Position   int     `json:"position"`
+	RiskReward float64 `json:"risk_reward"`
+	Percent    float64 `json:"percent"`
+	Distance   float64 `json:"distance"`
+}
+
+// Calculate derives the risk metrics of a position. The overall risk-reward
+// ratio weights each target's reward by its allocation.
+func Calculate(p Position) Metrics {
+	metrics := Metrics{
+		StopLossPercent:  round(PercentDistance(p.Entry, p.StopLoss), 2),
+		StopLossDistance: p.NativeDistance(p.Entry, p.StopLoss),
+		Unit:             Unit(p.AssetClass),
+	}
+	if len(p.Targets) == 0 {
+		return metrics
+	}
+
+	risk := p.Risk()
+	for i, target := range p.Targets {
+		targetMetrics := TargetMetrics{
+			Position: i + 1,
+			Percent:  round(PercentDistance(p.Entry, target.Price), 2),
+			Distance: p.NativeDistance(p.Entry, target.Price),
+		}
+		if risk > 0 {
+			targetMetrics.RiskReward = round(p.Reward(target.Price)/risk, 2)
+		}
+		metrics.Targets = append(metrics.Targets, targetMetrics)
+	}
+
+	final := metrics.Targets[len(metrics.Targets)-1]
+	metrics.TakeProfitPercent = final.Percent
+	metrics.TakeProfitDistance = final.Distance
+	metrics.RiskReward = round(p.RiskReward(), 2)
+
+	return metrics
+}
+
+// RiskReward returns the allocation-weighted reward of the targets divided by
+// the risk to the stop loss, unrounded. It is 0 when the stop loss is not on
+// the losing side of entry.
+func (p Position) RiskReward() float64 {
+	risk := p.Risk()
+	if risk <= 0 {
+		return 0
+	}
+
+	var reward float64
+	for _, target := range p.Targets {
+		reward += target.AllocationPercent / 100 * p.Reward(target.Price)
+	}
+	return reward / risk
+}
+
+// Risk returns the price distance from entry to the stop loss, negative when
+// the stop loss sits on the profitable side of entry
+func (p Position) Risk() float64 {
+	return -p.Reward(p.StopLoss)
+}
+
+// Reward returns how far price lies from entry in the position's profitable direction
+func (p Position) Reward(price float64) float64 {
+	if p.Short {
+		return p.Entry - price
+	}
+	return price - p.Entry
+}
+
+// NativeDistance returns the absolute distance between two prices in the unit
+// used for the position's asset class
+func (p Position) NativeDistance(from, to float64) float64 {
+	switch Unit(p.AssetClass) {
+	case UnitPips:
+		pipSize := p.PipSize
+		if pipSize <= 0 {
+			pipSize = PipSize(p.Symbol)
+		}
+		return round(math.Abs(to-from)/pipSize, 1)
+	case UnitPKR:
+		return round(math.Abs(to-from), 2)
+	default:
+		return round(PercentDistance(from, to), 2)
+	}
+}
+
+// PercentDistance returns the absolute distance from one price to another as a percentage of from
+func PercentDistance(from, to float64) float64 {
+	if from == 0 {
+		return 0
+	}
+	return math.Abs(to-from) / from * 100
+}
+
+// Unit returns the distance unit for an asset class
+func Unit(assetClass string) string {
+	switch assetClass {
+	case "FOREX":
+		return UnitPips
+	case "PSX":
+		return UnitPKR
+	default:
+		return UnitPercent
+	}
+}
+
+// PipSize returns the conventional pip size for a currency pair. Pairs quoted
+// in Japanese yen use two decimal places, all others four.
+func PipSize(symbol string) float64 {
+	if strings.Contains(strings.ToUpper(symbol), "JPY") {
+		return JPYPipSize
+	}
+	return DefaultPipSize
+}
+
+// FormatDistance formats a distance with its unit, e.g. "25.0 pips" or "1.25%"
+func FormatDistance(distance float64, unit string) string {
+	switch unit {
+	case UnitPips:
+		return strconv.FormatFloat(distance, 'f', 1, 64) + " pips"
+	case UnitPKR:
+		return "PKR " + strconv.FormatFloat(distance, 'f', 2, 64)
+	default:
+		return strconv.FormatFloat(distance, 'f', 2, 64) + "%"
+	}
+}
+
+func round(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
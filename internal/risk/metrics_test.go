@@ -0,0 +1,120 @@
+package risk
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPipSize(t *testing.T) {
+	tests := []struct {
+		symbol string
+		want   float64
+	}{
+		{"EURUSD", DefaultPipSize},
+		{"GBPUSD", DefaultPipSize},
+		{"USDJPY", JPYPipSize},
+		{"eurjpy", JPYPipSize},
+		{"GBP/JPY", JPYPipSize},
+	}
+	for _, tt := range tests {
+		t.Run(tt.symbol, func(t *testing.T) {
+			if got := PipSize(tt.symbol); got != tt.want {
+				t.Errorf("PipSize(%q) = %v, want %v", tt.symbol, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	tests := []struct {
+		name     string
+		position Position
+		want     Metrics
+	}{
+		{
+			name:     "forex long",
+			position: Position{AssetClass: "FOREX", Symbol: "EURUSD", Entry: 1.0850, StopLoss: 1.0800, Targets: []Target{{Price: 1.0950, AllocationPercent: 100}}},
+			want:     Metrics{RiskReward: 2, StopLossPercent: 0.46, TakeProfitPercent: 0.92, StopLossDistance: 50, TakeProfitDistance: 100, Unit: UnitPips},
+		},
+		{
+			name:     "forex JPY short",
+			position: Position{AssetClass: "FOREX", Symbol: "USDJPY", Short: true, Entry: 150.00, StopLoss: 150.50, Targets: []Target{{Price: 149.00, AllocationPercent: 100}}},
+			want:     Metrics{RiskReward: 2, StopLossPercent: 0.33, TakeProfitPercent: 0.67, StopLossDistance: 50, TakeProfitDistance: 100, Unit: UnitPips},
+		},
+		{
+			name:     "forex pip size override",
+			position: Position{AssetClass: "FOREX", Symbol: "XAUUSD", Entry: 2000, StopLoss: 1990, Targets: []Target{{Price: 2030, AllocationPercent: 100}}, PipSize: 0.1},
+			want:     Metrics{RiskReward: 3, StopLossPercent: 0.5, TakeProfitPercent: 1.5, StopLossDistance: 100, TakeProfitDistance: 300, Unit: UnitPips},
+		},
+		{
+			name:     "psx in rupees",
+			position: Position{AssetClass: "PSX", Symbol: "OGDC", Entry: 250, StopLoss: 240, Targets: []Target{{Price: 270, AllocationPercent: 100}}},
+			want:     Metrics{RiskReward: 2, StopLossPercent: 4, TakeProfitPercent: 8, StopLossDistance: 10, TakeProfitDistance: 20, Unit: UnitPKR},
+		},
+		{
+			name:     "crypto in percent",
+			position: Position{AssetClass: "CRYPTO", Symbol: "BTCUSDT", Entry: 60000, StopLoss: 57000, Targets: []Target{{Price: 66000, AllocationPercent: 100}}},
+			want:     Metrics{RiskReward: 2, StopLossPercent: 5, TakeProfitPercent: 10, StopLossDistance: 5, TakeProfitDistance: 10, Unit: UnitPercent},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Calculate(tt.position)
+			got.Targets = nil
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Calculate() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateWeightsTargetsByAllocation(t *testing.T) {
+	position := Position{
+		AssetClass: "FOREX",
+		Symbol:     "EURUSD",
+		Entry:      1.1000,
+		StopLoss:   1.0950,
+		Targets: []Target{
+			{Price: 1.1050, AllocationPercent: 50},
+			{Price: 1.1150, AllocationPercent: 50},
+		},
+	}
+
+	metrics := Calculate(position)
+	if metrics.RiskReward != 2 {
+		t.Errorf("RiskReward = %v, want 2", metrics.RiskReward)
+	}
+	if len(metrics.Targets) != 2 {
+		t.Fatalf("got %d target metrics, want 2", len(metrics.Targets))
+	}
+	if metrics.Targets[0].RiskReward != 1 || metrics.Targets[0].Distance != 50 {
+		t.Errorf("TP1 metrics = %+v, want 1:1 at 50 pips", metrics.Targets[0])
+	}
+	if metrics.Targets[1].RiskReward != 3 || metrics.Targets[1].Distance != 150 {
+		t.Errorf("TP2 metrics = %+v, want 1:3 at 150 pips", metrics.Targets[1])
+	}
+}
+
+func TestCalculateStopLossOnProfitableSide(t *testing.T) {
+	position := Position{AssetClass: "CRYPTO", Entry: 100, StopLoss: 105, Targets: []Target{{Price: 110, AllocationPercent: 100}}}
+	if got := Calculate(position).RiskReward; got != 0 {
+		t.Errorf("RiskReward = %v, want 0", got)
+	}
+}
+
+func TestFormatDistance(t *testing.T) {
+	tests := []struct {
+		distance float64
+		unit     string
+		want     string
+	}{
+		{25, UnitPips, "25.0 pips"},
+		{12.5, UnitPKR, "PKR 12.50"},
+		{1.25, UnitPercent, "1.25%"},
+	}
+	for _, tt := range tests {
+		if got := FormatDistance(tt.distance, tt.unit); got != tt.want {
+			t.Errorf("FormatDistance(%v, %q) = %q, want %q", tt.distance, tt.unit, got, tt.want)
+		}
+	}
+}
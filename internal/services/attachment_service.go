@@ -0,0 +1,326 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	_ "image/png" // Registers the PNG decoder
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/config"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/repositories"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/storage"
+)
+
+var (
+	ErrAttachmentNotFound       = errors.New("attachment not found")
+	ErrAttachmentTooLarge       = errors.New("attachment is too large")
+	ErrUnsupportedImage         = errors.New("unsupported image")
+	ErrInvalidAttachmentURL     = errors.New("invalid attachment URL signature")
+	ErrExpiredAttachmentURL     = errors.New("attachment URL has expired")
+	ErrUnknownAttachmentVariant = errors.New("unknown attachment variant")
+)
+
+// maxAttachmentPixels bounds the decoded size of an upload, so small files that
+// expand to huge images cannot exhaust memory
+const maxAttachmentPixels = 25_000_000
+
+// attachmentExtensions maps the accepted image types to their file extension
+var attachmentExtensions = map[string]string{
+	"image/png":  ".png",
+	"image/jpeg": ".jpg",
+}
+
+// AttachmentService stores chart images for trading signals and hands out
+// short-lived signed URLs to them
+type AttachmentService struct {
+	repo       *repositories.AttachmentRepository
+	signalRepo *repositories.TradingSignalRepository
+	storage    storage.Storage
+	cfg        *config.AttachmentsConfig
+}
+
+func NewAttachmentService(repo *repositories.AttachmentRepository, signalRepo *repositories.TradingSignalRepository, store storage.Storage, cfg *config.AttachmentsConfig) *AttachmentService {
+	return &AttachmentService{
+		repo:       repo,
+		signalRepo: signalRepo,
+		storage:    store,
+		cfg:        cfg,
+	}
+}
+
+// Upload validates an image, stores it along with a thumbnail and attaches it to a
+// signal. The content type is sniffed from the data rather than trusted from the client.
+func (s *AttachmentService) Upload(ctx context.Context, signalID int64, fileName string, file io.Reader, uploadedBy int64) (*models.SignalAttachment, error) {
+	signal, err := s.signalRepo.GetByID(signalID)
+	if err != nil {
+		return nil, err
+	}
+	if signal == nil {
+		return nil, ErrTradingSignalNotFound
+	}
+
+	data, err := io.ReadAll(io.LimitReader(file, int64(s.cfg.MaxBytes)+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if len(data) > s.cfg.MaxBytes {
+		return nil, fmt.Errorf("%w: the limit is %d bytes", ErrAttachmentTooLarge, s.cfg.MaxBytes)
+	}
+
+	contentType := http.DetectContentType(data)
+	extension, ok := attachmentExtensions[contentType]
+	if !ok {
+		return nil, fmt.Errorf("%w: only PNG and JPEG images are accepted", ErrUnsupportedImage)
+	}
+
+	imageConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: the image could not be read", ErrUnsupportedImage)
+	}
+	if imageConfig.Width*imageConfig.Height > maxAttachmentPixels {
+		return nil, fmt.Errorf("%w: the image is %dx%d, at most %d pixels are accepted",
+			ErrUnsupportedImage, imageConfig.Width, imageConfig.Height, maxAttachmentPixels)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: the image could not be read", ErrUnsupportedImage)
+	}
+
+	var thumbnail bytes.Buffer
+	if err := jpeg.Encode(&thumbnail, makeThumbnail(img, s.cfg.ThumbnailSize), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, fmt.Errorf("failed to encode thumbnail: %w", err)
+	}
+
+	name, err := randomObjectName()
+	if err != nil {
+		return nil, err
+	}
+	attachment := &models.SignalAttachment{
+		SignalID:     signalID,
+		FileName:     sanitizeFileName(fileName, extension),
+		ContentType:  contentType,
+		SizeBytes:    int64(len(data)),
+		Width:        imageConfig.Width,
+		Height:       imageConfig.Height,
+		StorageKey:   signalStoragePrefix(signalID) + "/" + name + extension,
+		ThumbnailKey: signalStoragePrefix(signalID) + "/" + name + "_thumb.jpg",
+		UploadedBy:   uploadedBy,
+	}
+
+	if err := s.storage.Put(ctx, attachment.StorageKey, bytes.NewReader(data), contentType); err != nil {
+		return nil, err
+	}
+	if err := s.storage.Put(ctx, attachment.ThumbnailKey, &thumbnail, "image/jpeg"); err != nil {
+		s.removeFiles(ctx, attachment)
+		return nil, err
+	}
+
+	created, err := s.repo.Create(attachment)
+	if err != nil {
+		s.removeFiles(ctx, attachment)
+		return nil, err
+	}
+
+	s.sign(created, time.Now())
+	return created, nil
+}
+
+// GetForSignal returns the attachments of a signal with freshly signed URLs.
+// Callers must have checked that the user has access to the signal.
+func (s *AttachmentService) GetForSignal(signalID int64) ([]models.SignalAttachment, error) {
+	attachments, err := s.repo.GetBySignal(signalID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	for i := range attachments {
+		s.sign(&attachments[i], now)
+	}
+	return attachments, nil
+}
+
+// Delete removes an attachment of a signal along with its files
+func (s *AttachmentService) Delete(ctx context.Context, signalID, attachmentID int64) error {
+	attachment, err := s.repo.GetByID(attachmentID)
+	if err != nil {
+		return err
+	}
+	if attachment == nil || attachment.SignalID != signalID {
+		return ErrAttachmentNotFound
+	}
+
+	deleted, err := s.repo.Delete(attachmentID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrAttachmentNotFound
+	}
+
+	s.removeFiles(ctx, attachment)
+	return nil
+}
+
+// DeleteSignalFiles removes the stored files of a deleted signal. The attachment
+// records are removed with the signal itself.
+func (s *AttachmentService) DeleteSignalFiles(ctx context.Context, signalID int64) {
+	if err := s.storage.DeletePrefix(ctx, signalStoragePrefix(signalID)); err != nil {
+		log.Printf("Failed to delete attachment files of signal %d: %v", signalID, err)
+	}
+}
+
+// Open verifies a signed URL and returns the requested variant of the attachment
+// along with its content type and the time the URL expires
+func (s *AttachmentService) Open(ctx context.Context, attachmentID int64, variant string, expires int64, signature string) (io.ReadCloser, string, time.Time, error) {
+	expiresAt := time.Unix(expires, 0)
+	if !hmac.Equal([]byte(signature), []byte(s.signature(attachmentID, variant, expires))) {
+		return nil, "", expiresAt, ErrInvalidAttachmentURL
+	}
+	if time.Now().After(expiresAt) {
+		return nil, "", expiresAt, ErrExpiredAttachmentURL
+	}
+
+	attachment, err := s.repo.GetByID(attachmentID)
+	if err != nil {
+		return nil, "", expiresAt, err
+	}
+	if attachment == nil {
+		return nil, "", expiresAt, ErrAttachmentNotFound
+	}
+
+	key, contentType := attachment.StorageKey, attachment.ContentType
+	switch variant {
+	case models.AttachmentVariantOriginal:
+	case models.AttachmentVariantThumbnail:
+		key, contentType = attachment.ThumbnailKey, "image/jpeg"
+	default:
+		return nil, "", expiresAt, ErrUnknownAttachmentVariant
+	}
+
+	file, err := s.storage.Open(ctx, key)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, "", expiresAt, ErrAttachmentNotFound
+	}
+	if err != nil {
+		return nil, "", expiresAt, err
+	}
+	return file, contentType, expiresAt, nil
+}
+
+// sign sets the signed URLs of an attachment, valid for the configured TTL from now
+func (s *AttachmentService) sign(attachment *models.SignalAttachment, now time.Time) {
+	expiresAt := now.Add(s.cfg.URLTTL).Truncate(time.Second)
+	attachment.URL = s.signedURL(attachment.ID, models.AttachmentVariantOriginal, expiresAt.Unix())
+	attachment.ThumbnailURL = s.signedURL(attachment.ID, models.AttachmentVariantThumbnail, expiresAt.Unix())
+	attachment.URLExpiresAt = &expiresAt
+}
+
+// signedURL returns the path serving an attachment variant until expires (unix seconds)
+func (s *AttachmentService) signedURL(attachmentID int64, variant string, expires int64) string {
+	return fmt.Sprintf("/media/attachments/%d/%s?expires=%d&signature=%s",
+		attachmentID, variant, expires, s.signature(attachmentID, variant, expires))
+}
+
+// signature is the HMAC of an attachment variant and expiry time
+func (s *AttachmentService) signature(attachmentID int64, variant string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(s.cfg.URLSecret))
+	fmt.Fprintf(mac, "attachment:%d:%s:%d", attachmentID, variant, expires)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// removeFiles deletes the stored files of an attachment, logging failures
+func (s *AttachmentService) removeFiles(ctx context.Context, attachment *models.SignalAttachment) {
+	for _, key := range []string{attachment.StorageKey, attachment.ThumbnailKey} {
+		if err := s.storage.Delete(ctx, key); err != nil {
+			log.Printf("Failed to delete attachment file %s: %v", key, err)
+		}
+	}
+}
+
+// signalStoragePrefix is the storage prefix holding the files of a signal
+func signalStoragePrefix(signalID int64) string {
+	return fmt.Sprintf("signals/%d", signalID)
+}
+
+// randomObjectName returns an unguessable name for a stored file
+func randomObjectName() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate file name: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// sanitizeFileName keeps the base name of an uploaded file for display, falling
+// back to a generic name with the detected extension
+func sanitizeFileName(fileName, extension string) string {
+	name := strings.TrimSpace(filepath.Base(strings.ReplaceAll(fileName, "\\", "/")))
+	if name == "" || name == "." || name == "/" {
+		return "chart" + extension
+	}
+	if len(name) > 255 {
+		name = name[len(name)-255:]
+	}
+	return name
+}
+
+// makeThumbnail scales src down so its longest side is at most maxSize, averaging
+// the source pixels covered by each thumbnail pixel. Transparent areas are
+// composited onto white as thumbnails are encoded as JPEG.
+func makeThumbnail(src image.Image, maxSize int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	thumbWidth, thumbHeight := width, height
+	if width > maxSize || height > maxSize {
+		if width >= height {
+			thumbWidth, thumbHeight = maxSize, max(1, height*maxSize/width)
+		} else {
+			thumbWidth, thumbHeight = max(1, width*maxSize/height), maxSize
+		}
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, thumbWidth, thumbHeight))
+	for y := 0; y < thumbHeight; y++ {
+		y0 := bounds.Min.Y + y*height/thumbHeight
+		y1 := max(y0+1, bounds.Min.Y+(y+1)*height/thumbHeight)
+		for x := 0; x < thumbWidth; x++ {
+			x0 := bounds.Min.X + x*width/thumbWidth
+			x1 := max(x0+1, bounds.Min.X+(x+1)*width/thumbWidth)
+
+			var r, g, b, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					background := uint64(0xffff - pa)
+					r += uint64(pr) + background
+					g += uint64(pg) + background
+					b += uint64(pb) + background
+					n++
+				}
+			}
+
+			offset := dst.PixOffset(x, y)
+			dst.Pix[offset] = uint8(r / n >> 8)
+			dst.Pix[offset+1] = uint8(g / n >> 8)
+			dst.Pix[offset+2] = uint8(b / n >> 8)
+			dst.Pix[offset+3] = 0xff
+		}
+	}
+	return dst
+}
@@ -0,0 +1,476 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/config"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/repositories"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+var (
+	ErrMembershipNotFound     = errors.New("channel membership not found")
+	ErrPackageChannelNotFound = errors.New("package channel not found")
+	ErrPackageChannelExists   = errors.New("package already gives access to this channel")
+	ErrReconcileInProgress    = errors.New("membership reconciliation is already running")
+)
+
+// membershipSyncQueueSize is the number of users waiting to be invited the queue
+// holds; users queued beyond it are invited on the next scheduled reconciliation
+const membershipSyncQueueSize = 100
+
+// membershipSync is a user queued to be invited to the channels of their
+// subscriptions, with what triggered it
+type membershipSync struct {
+	userID  int64
+	trigger models.MembershipTrigger
+}
+
+// ChannelMembershipStore keeps channel memberships and their audit trail,
+// implemented by repositories.ChannelMembershipRepository
+type ChannelMembershipStore interface {
+	GetMissing(platforms []string, userID *int64) ([]models.ChannelMembership, error)
+	GetStale(platforms []string) ([]models.ChannelMembership, error)
+	GetPending(platforms []string) ([]models.ChannelMembership, error)
+	GetByUserID(userID int64) ([]models.ChannelMembership, error)
+	GetByID(id int64) (*models.ChannelMembership, error)
+	GetByMember(platform models.ChannelPlatform, targetID, memberID string) (*models.ChannelMembership, error)
+	Save(membership *models.ChannelMembership) (*models.ChannelMembership, error)
+	LogEvent(event *models.MembershipEvent) error
+	GetEvents(filter *models.MembershipEventFilter, limit, offset int) ([]models.MembershipEvent, error)
+	CountEvents(filter *models.MembershipEventFilter) (int64, error)
+	Lock(ctx context.Context) (func(), bool, error)
+}
+
+// ChannelMembershipService keeps the members of private Telegram chats and the
+// holders of Discord guild roles in line with subscriptions. Subscribers of a
+// package get personal invites to its channels, and are kicked or lose the
+// role once their access ends. Users are identified by the Telegram chat and
+// Discord account they linked. Every action is recorded in an audit trail.
+type ChannelMembershipService struct {
+	repo               ChannelMembershipStore
+	packageChannelRepo *repositories.PackageChannelRepository
+	packageRepo        *repositories.PackageRepository
+	subscriptionRepo   *repositories.SubscriptionRepository
+	telegram           *TelegramClient // Nil unless the Telegram bot is configured
+	discord            *DiscordClient  // Nil unless Discord roles are managed
+	syncQueue          chan membershipSync
+	cfg                *config.MembershipConfig
+}
+
+func NewChannelMembershipService(cfg *config.MembershipConfig, notificationCfg *config.NotificationConfig, repo ChannelMembershipStore, packageChannelRepo *repositories.PackageChannelRepository, packageRepo *repositories.PackageRepository, subscriptionRepo *repositories.SubscriptionRepository) *ChannelMembershipService {
+	service := &ChannelMembershipService{
+		repo:               repo,
+		packageChannelRepo: packageChannelRepo,
+		packageRepo:        packageRepo,
+		subscriptionRepo:   subscriptionRepo,
+		syncQueue:          make(chan membershipSync, membershipSyncQueueSize),
+		cfg:                cfg,
+	}
+
+	if notificationCfg.TelegramBotEnabled() {
+		service.telegram = NewTelegramClient(notificationCfg.TelegramAPIURL, notificationCfg.TelegramBotToken)
+	}
+	if cfg.DiscordEnabled() {
+		service.discord = NewDiscordClient(cfg.DiscordAPIURL, cfg.DiscordBotToken)
+	}
+
+	return service
+}
+
+// platforms returns the platforms whose memberships are managed
+func (s *ChannelMembershipService) platforms() []string {
+	var platforms []string
+	if s.telegram != nil {
+		platforms = append(platforms, string(models.ChannelPlatformTelegram))
+	}
+	if s.discord != nil {
+		platforms = append(platforms, string(models.ChannelPlatformDiscord))
+	}
+	return platforms
+}
+
+// GetPackageChannels retrieves the channels a package gives access to
+func (s *ChannelMembershipService) GetPackageChannels(packageID int64) ([]models.PackageChannel, error) {
+	pkg, err := s.packageRepo.GetByID(packageID)
+	if err != nil {
+		return nil, err
+	}
+	if pkg == nil {
+		return nil, ErrPackageNotFound
+	}
+	return s.packageChannelRepo.GetByPackageID(packageID)
+}
+
+// AddPackageChannel gives the subscribers of a package access to a channel. They
+// are invited on the next reconciliation.
+func (s *ChannelMembershipService) AddPackageChannel(packageID int64, create *models.PackageChannelCreate) (*models.PackageChannel, error) {
+	pkg, err := s.packageRepo.GetByID(packageID)
+	if err != nil {
+		return nil, err
+	}
+	if pkg == nil {
+		return nil, ErrPackageNotFound
+	}
+
+	switch create.Platform {
+	case models.ChannelPlatformTelegram:
+		if s.telegram == nil {
+			return nil, utils.ValidationErrors{{Field: "platform", Message: "Telegram chats need the Telegram bot to be configured"}}
+		}
+		if _, err := strconv.ParseInt(create.TargetID, 10, 64); err != nil {
+			return nil, utils.ValidationErrors{{Field: "target_id", Message: "target_id must be the numeric ID of the Telegram chat"}}
+		}
+	case models.ChannelPlatformDiscord:
+		if s.discord == nil {
+			return nil, utils.ValidationErrors{{Field: "platform", Message: "Discord roles need the Discord bot to be configured"}}
+		}
+		if _, err := strconv.ParseUint(create.TargetID, 10, 64); err != nil {
+			return nil, utils.ValidationErrors{{Field: "target_id", Message: "target_id must be the ID of the Discord role"}}
+		}
+	}
+
+	channel, err := s.packageChannelRepo.Create(packageID, create)
+	if err != nil {
+		return nil, err
+	}
+	if channel == nil {
+		return nil, ErrPackageChannelExists
+	}
+	return channel, nil
+}
+
+// RemovePackageChannel takes a channel off a package. Subscribers who have no
+// other package giving access to it are removed on the next reconciliation.
+func (s *ChannelMembershipService) RemovePackageChannel(packageID, id int64) error {
+	deleted, err := s.packageChannelRepo.Delete(packageID, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrPackageChannelNotFound
+	}
+	return nil
+}
+
+// Reconcile deactivates expired subscriptions, then brings every membership in
+// line with the subscriptions: users who lost access are removed, pending invites
+// are checked for users who joined, and subscribers are invited or given their
+// role. Failed actions are recorded and retried on the next run.
+func (s *ChannelMembershipService) Reconcile(ctx context.Context, trigger models.MembershipTrigger, adminID *int64) (*models.ReconcileResult, error) {
+	unlock, locked, err := s.repo.Lock(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !locked {
+		return nil, ErrReconcileInProgress
+	}
+	defer unlock()
+
+	result := &models.ReconcileResult{}
+	result.Deactivated, err = s.subscriptionRepo.DeactivateExpired()
+	if err != nil {
+		return nil, err
+	}
+
+	platforms := s.platforms()
+	if len(platforms) == 0 {
+		return result, nil
+	}
+
+	stale, err := s.repo.GetStale(platforms)
+	if err != nil {
+		return nil, err
+	}
+	for i := range stale {
+		membership, action, err := s.revoke(&stale[i])
+		s.record(result, membership, action, err, trigger, adminID)
+	}
+
+	pending, err := s.repo.GetPending(platforms)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pending {
+		membership, action, err := s.confirm(&pending[i])
+		s.record(result, membership, action, err, trigger, adminID)
+	}
+
+	missing, err := s.repo.GetMissing(platforms, nil)
+	if err != nil {
+		return nil, err
+	}
+	for i := range missing {
+		membership, action, err := s.grant(&missing[i])
+		s.record(result, membership, action, err, trigger, adminID)
+	}
+
+	return result, nil
+}
+
+// QueueSync queues a user to be invited to the channels of their subscriptions
+// by the reconciler, without waiting for the platforms. If the queue is full the
+// next scheduled reconciliation invites them.
+func (s *ChannelMembershipService) QueueSync(userID int64, trigger models.MembershipTrigger) {
+	select {
+	case s.syncQueue <- membershipSync{userID: userID, trigger: trigger}:
+	default:
+		log.Printf("Channel membership sync queue is full, user %d is invited on the next reconciliation", userID)
+	}
+}
+
+// syncUser invites a queued user to the channels of their subscriptions they are
+// not a member of yet. Like Reconcile, it holds the reconciliation lock.
+func (s *ChannelMembershipService) syncUser(ctx context.Context, sync membershipSync) error {
+	platforms := s.platforms()
+	if len(platforms) == 0 {
+		return nil
+	}
+
+	unlock, locked, err := s.repo.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	if !locked {
+		return ErrReconcileInProgress
+	}
+	defer unlock()
+
+	missing, err := s.repo.GetMissing(platforms, &sync.userID)
+	if err != nil {
+		return err
+	}
+	for i := range missing {
+		membership, action, err := s.grant(&missing[i])
+		s.record(nil, membership, action, err, sync.trigger, nil)
+	}
+	return nil
+}
+
+// GetMemberships retrieves the current memberships of a user
+func (s *ChannelMembershipService) GetMemberships(userID int64) ([]models.ChannelMembership, error) {
+	return s.repo.GetByUserID(userID)
+}
+
+// Invite creates a new personal invite for a user's membership, e.g. once the
+// previous one expired or after they left the chat. Users who are still members
+// are confirmed instead.
+func (s *ChannelMembershipService) Invite(userID, membershipID int64) (*models.ChannelMembership, error) {
+	membership, err := s.repo.GetByID(membershipID)
+	if err != nil {
+		return nil, err
+	}
+	if membership == nil || membership.UserID != userID || membership.Status == models.MembershipStatusRemoved {
+		return nil, ErrMembershipNotFound
+	}
+
+	if membership.Platform == models.ChannelPlatformTelegram && membership.InviteLink != nil && s.telegram != nil {
+		if err := s.telegram.RevokeInviteLink(membership.TargetID, *membership.InviteLink); err != nil {
+			log.Printf("Failed to revoke Telegram invite of membership %d: %v", membership.ID, err)
+		}
+	}
+
+	saved, action, err := s.grant(membership)
+	s.record(nil, saved, action, err, models.MembershipTriggerUser, nil)
+	if err != nil {
+		return nil, err
+	}
+	return saved, nil
+}
+
+// HandleJoinRequest answers a request to join a Telegram chat. Invite links only
+// create join requests, so whoever follows one is let in only if the chat is one
+// of the channels of the Telegram account they linked.
+func (s *ChannelMembershipService) HandleJoinRequest(request *models.TelegramChatJoinRequest) error {
+	if s.telegram == nil {
+		return nil
+	}
+
+	chatID := strconv.FormatInt(request.Chat.ID, 10)
+	memberID := strconv.FormatInt(request.From.ID, 10)
+	membership, err := s.repo.GetByMember(models.ChannelPlatformTelegram, chatID, memberID)
+	if err != nil {
+		return err
+	}
+	if membership == nil {
+		log.Printf("Declined Telegram user %s joining chat %s without a membership", memberID, chatID)
+		return s.telegram.DeclineJoinRequest(chatID, memberID)
+	}
+
+	if err := s.telegram.ApproveJoinRequest(chatID, memberID); err != nil {
+		s.record(nil, membership, "", err, models.MembershipTriggerUser, nil)
+		return err
+	}
+	if membership.Status == models.MembershipStatusActive {
+		return nil
+	}
+	saved, err := s.save(membership, models.MembershipStatusActive, nil, nil)
+	s.record(nil, saved, models.MembershipActionGranted, err, models.MembershipTriggerUser, nil)
+	return err
+}
+
+// GetEvents retrieves audit trail entries matching filter, newest first
+func (s *ChannelMembershipService) GetEvents(filter *models.MembershipEventFilter, limit, offset int) ([]models.MembershipEvent, error) {
+	return s.repo.GetEvents(filter, limit, offset)
+}
+
+// CountEvents returns the number of audit trail entries matching filter
+func (s *ChannelMembershipService) CountEvents(filter *models.MembershipEventFilter) (int64, error) {
+	return s.repo.CountEvents(filter)
+}
+
+// grant gives a user access to a channel. Telegram users are invited unless they
+// are in the chat already; Discord users are given the role, or invited to the
+// guild first if they have not joined it.
+func (s *ChannelMembershipService) grant(membership *models.ChannelMembership) (*models.ChannelMembership, models.MembershipAction, error) {
+	if !slices.Contains(s.platforms(), string(membership.Platform)) {
+		return membership, "", fmt.Errorf("%s memberships are not managed", membership.Platform)
+	}
+
+	joined, err := s.admit(membership)
+	if err != nil {
+		return membership, "", err
+	}
+
+	if joined {
+		saved, err := s.save(membership, models.MembershipStatusActive, nil, nil)
+		return saved, models.MembershipActionGranted, err
+	}
+
+	expiresAt := time.Now().Add(s.cfg.InviteTTL)
+	var inviteLink string
+	switch membership.Platform {
+	case models.ChannelPlatformTelegram:
+		inviteLink, err = s.telegram.CreateInviteLink(membership.TargetID, fmt.Sprintf("user %d", membership.UserID), expiresAt)
+	case models.ChannelPlatformDiscord:
+		inviteLink, err = s.discord.CreateInvite(s.cfg.DiscordInviteChannelID, s.cfg.InviteTTL)
+	}
+	if err != nil {
+		return membership, "", err
+	}
+
+	saved, err := s.save(membership, models.MembershipStatusPending, &inviteLink, &expiresAt)
+	if err != nil {
+		return membership, "", err
+	}
+
+	if saved.Platform == models.ChannelPlatformTelegram {
+		// The user linked the bot, so the invite is sent to them right away
+		text := fmt.Sprintf("Your subscription gives you access to a private group. Join it with this link until %s UTC, your request is approved for this account only:\n%s",
+			expiresAt.UTC().Format("2006-01-02 15:04"), inviteLink)
+		if err := s.telegram.SendMessage(saved.MemberID, text, ""); err != nil {
+			log.Printf("Failed to send Telegram invite of membership %d: %v", saved.ID, err)
+		}
+	}
+	return saved, models.MembershipActionInvited, nil
+}
+
+// confirm grants pending memberships of users who joined with their invite
+func (s *ChannelMembershipService) confirm(membership *models.ChannelMembership) (*models.ChannelMembership, models.MembershipAction, error) {
+	joined, err := s.admit(membership)
+	if err != nil || !joined {
+		return membership, "", err
+	}
+
+	saved, err := s.save(membership, models.MembershipStatusActive, nil, nil)
+	return saved, models.MembershipActionGranted, err
+}
+
+// revoke kicks a user from a Telegram chat or takes their Discord role
+func (s *ChannelMembershipService) revoke(membership *models.ChannelMembership) (*models.ChannelMembership, models.MembershipAction, error) {
+	var err error
+	switch membership.Platform {
+	case models.ChannelPlatformTelegram:
+		if membership.InviteLink != nil {
+			if revokeErr := s.telegram.RevokeInviteLink(membership.TargetID, *membership.InviteLink); revokeErr != nil {
+				log.Printf("Failed to revoke Telegram invite of membership %d: %v", membership.ID, revokeErr)
+			}
+		}
+		err = s.telegram.KickChatMember(membership.TargetID, membership.MemberID)
+	case models.ChannelPlatformDiscord:
+		err = s.discord.RemoveRole(s.cfg.DiscordGuildID, membership.MemberID, membership.TargetID)
+		if errors.Is(err, ErrDiscordUnknownMember) {
+			err = nil // Left the guild, and the role with it
+		}
+	}
+	if err != nil {
+		return membership, "", err
+	}
+
+	saved, err := s.save(membership, models.MembershipStatusRemoved, nil, nil)
+	return saved, models.MembershipActionRemoved, err
+}
+
+// admit reports whether a user is in the Telegram chat or Discord guild of a
+// membership. Discord members are given the role on the way.
+func (s *ChannelMembershipService) admit(membership *models.ChannelMembership) (bool, error) {
+	switch membership.Platform {
+	case models.ChannelPlatformTelegram:
+		return s.telegram.IsChatMember(membership.TargetID, membership.MemberID)
+	case models.ChannelPlatformDiscord:
+		err := s.discord.AddRole(s.cfg.DiscordGuildID, membership.MemberID, membership.TargetID)
+		if errors.Is(err, ErrDiscordUnknownMember) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	return false, fmt.Errorf("unknown platform %q", membership.Platform)
+}
+
+// save stores the new state of a membership
+func (s *ChannelMembershipService) save(membership *models.ChannelMembership, status models.MembershipStatus, inviteLink *string, inviteExpiresAt *time.Time) (*models.ChannelMembership, error) {
+	updated := *membership
+	updated.Status = status
+	updated.InviteLink = inviteLink
+	updated.InviteExpiresAt = inviteExpiresAt
+	return s.repo.Save(&updated)
+}
+
+// record adds the outcome of an action on a membership to the audit trail and
+// to result, if any. Nothing is recorded when no action was taken.
+func (s *ChannelMembershipService) record(result *models.ReconcileResult, membership *models.ChannelMembership, action models.MembershipAction, err error, trigger models.MembershipTrigger, adminID *int64) {
+	event := &models.MembershipEvent{
+		UserID:      membership.UserID,
+		Platform:    membership.Platform,
+		TargetID:    membership.TargetID,
+		Action:      action,
+		TriggeredBy: trigger,
+		AdminID:     adminID,
+	}
+	if membership.ID > 0 {
+		event.MembershipID = &membership.ID
+	}
+	if err != nil {
+		detail := err.Error()
+		event.Action = models.MembershipActionFailed
+		event.Detail = &detail
+		log.Printf("Channel membership of user %d in %s %s failed: %v", membership.UserID, membership.Platform, membership.TargetID, err)
+	}
+	if event.Action == "" {
+		return
+	}
+
+	if result != nil {
+		switch event.Action {
+		case models.MembershipActionInvited:
+			result.Invited++
+		case models.MembershipActionGranted:
+			result.Granted++
+		case models.MembershipActionRemoved:
+			result.Removed++
+		case models.MembershipActionFailed:
+			result.Failed++
+		}
+	}
+
+	if err := s.repo.LogEvent(event); err != nil {
+		log.Printf("Failed to record channel membership event: %v", err)
+	}
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ErrDiscordUnknownMember is returned for users who have not joined the guild
+var ErrDiscordUnknownMember = errors.New("discord user is not a member of the guild")
+
+// discordUnknownMemberCode is the JSON error code Discord returns for users not in the guild
+const discordUnknownMemberCode = 10007
+
+// DiscordUser is the Discord account an OAuth access token was issued for
+type DiscordUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// DiscordClient calls the Discord API, as the configured bot unless a user's
+// access token is given
+type DiscordClient struct {
+	baseURL    string
+	botToken   string
+	httpClient *http.Client
+}
+
+// NewDiscordClient creates a client for the Discord API at baseURL, normally
+// https://discord.com/api/v10
+func NewDiscordClient(baseURL, botToken string) *DiscordClient {
+	return &DiscordClient{
+		baseURL:    baseURL,
+		botToken:   botToken,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// AddRole gives a guild member a role. It returns ErrDiscordUnknownMember if the
+// user has not joined the guild.
+func (c *DiscordClient) AddRole(guildID, userID, roleID string) error {
+	path := fmt.Sprintf("/guilds/%s/members/%s/roles/%s", guildID, userID, roleID)
+	return c.do("PUT", path, "Bot "+c.botToken, nil, nil)
+}
+
+// RemoveRole takes a role from a guild member. It returns ErrDiscordUnknownMember
+// if the user has left the guild.
+func (c *DiscordClient) RemoveRole(guildID, userID, roleID string) error {
+	path := fmt.Sprintf("/guilds/%s/members/%s/roles/%s", guildID, userID, roleID)
+	return c.do("DELETE", path, "Bot "+c.botToken, nil, nil)
+}
+
+// CreateInvite creates an invite to a guild channel that can be used once, for
+// maxAge (at most 7 days)
+func (c *DiscordClient) CreateInvite(channelID string, maxAge time.Duration) (string, error) {
+	body := map[string]interface{}{
+		"max_age":  int(maxAge.Seconds()),
+		"max_uses": 1,
+		"unique":   true,
+	}
+
+	var invite struct {
+		Code string `json:"code"`
+	}
+	if err := c.do("POST", fmt.Sprintf("/channels/%s/invites", channelID), "Bot "+c.botToken, body, &invite); err != nil {
+		return "", err
+	}
+	return "https://discord.gg/" + invite.Code, nil
+}
+
+// GetCurrentUser retrieves the account an OAuth access token was issued for
+func (c *DiscordClient) GetCurrentUser(accessToken string) (*DiscordUser, error) {
+	var user DiscordUser
+	if err := c.do("GET", "/users/@me", "Bearer "+accessToken, nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// do sends a request to the Discord API, decoding the response into result
+// unless nil
+func (c *DiscordClient) do(method, path, authorization string, body interface{}, result interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal discord request: %w", err)
+		}
+		reader = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create discord request: %w", err)
+	}
+
+	req.Header.Set("Authorization", authorization)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send discord request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var apiErr struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		if apiErr.Code == discordUnknownMemberCode {
+			return ErrDiscordUnknownMember
+		}
+		if apiErr.Message != "" {
+			return fmt.Errorf("discord API returned status %d: %s", resp.StatusCode, apiErr.Message)
+		}
+		return fmt.Errorf("discord API returned status %d", resp.StatusCode)
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode discord response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/config"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/repositories"
+	"golang.org/x/oauth2"
+)
+
+var (
+	ErrDiscordLinkingDisabled = errors.New("discord linking is not configured")
+	ErrDiscordNotLinked       = errors.New("discord account not linked")
+	ErrDiscordAuthFailed      = errors.New("discord authorization failed")
+)
+
+// discordAuthURL is where clients send users to authorize the app to read their
+// Discord account, with the identify scope
+const discordAuthURL = "https://discord.com/oauth2/authorize"
+
+// DiscordLinkService links users' Discord accounts to their account through
+// Discord OAuth, so the guild roles of their packages can be given to them
+type DiscordLinkService struct {
+	repo        *repositories.DiscordLinkRepository
+	client      *DiscordClient
+	oauthConfig *oauth2.Config
+	memberships *ChannelMembershipService
+	cfg         *config.MembershipConfig
+}
+
+func NewDiscordLinkService(cfg *config.MembershipConfig, repo *repositories.DiscordLinkRepository, memberships *ChannelMembershipService) *DiscordLinkService {
+	return &DiscordLinkService{
+		repo:   repo,
+		client: NewDiscordClient(cfg.DiscordAPIURL, cfg.DiscordBotToken),
+		oauthConfig: &oauth2.Config{
+			ClientID:     cfg.DiscordClientID,
+			ClientSecret: cfg.DiscordClientSecret,
+			RedirectURL:  cfg.DiscordRedirectURL,
+			Scopes:       []string{"identify"},
+			Endpoint: oauth2.Endpoint{
+				AuthURL:   discordAuthURL,
+				TokenURL:  cfg.DiscordAPIURL + "/oauth2/token",
+				AuthStyle: oauth2.AuthStyleInParams,
+			},
+		},
+		memberships: memberships,
+		cfg:         cfg,
+	}
+}
+
+// Link exchanges an authorization code for the Discord account of the user who
+// granted it and links the account to userID. The user is queued to be given
+// the roles of their packages.
+func (s *DiscordLinkService) Link(ctx context.Context, userID int64, code string) (*models.DiscordLink, error) {
+	if !s.cfg.DiscordEnabled() {
+		return nil, ErrDiscordLinkingDisabled
+	}
+
+	token, err := s.oauthConfig.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to exchange code: %v", ErrDiscordAuthFailed, err)
+	}
+
+	user, err := s.client.GetCurrentUser(token.AccessToken)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get discord user: %w", err)
+	}
+
+	var username *string
+	if user.Username != "" {
+		username = &user.Username
+	}
+
+	link, err := s.repo.Link(userID, user.ID, username)
+	if err != nil {
+		return nil, err
+	}
+
+	s.memberships.QueueSync(userID, models.MembershipTriggerUser)
+
+	return link, nil
+}
+
+// GetLink retrieves the Discord account linked to a user
+func (s *DiscordLinkService) GetLink(userID int64) (*models.DiscordLink, error) {
+	link, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrDiscordNotLinked
+	}
+	return link, nil
+}
+
+// Unlink removes the Discord account linked to a user. Their roles are taken on
+// the next reconciliation.
+func (s *DiscordLinkService) Unlink(userID int64) error {
+	deleted, err := s.repo.Delete(userID)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrDiscordNotLinked
+	}
+	return nil
+}
@@ -5,9 +5,12 @@ import (
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
+	"html"
 	"log"
+	"mime"
 	"net/http"
 	"net/smtp"
+	"strings"
 	"time"
 
 	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
@@ -20,6 +23,7 @@ type EmailSender interface {
 	SendPasswordChangedEmail(email, name string) error
 	SendWelcomeEmail(email, name string) error
 	SendSubscriptionConfirmation(email, name string, subscriptions []models.SubscriptionWithPackage, totalAmount float64) error
+	SendSignalAlert(email, name, title, message string) error
 }
 
 // EmailService wraps the email sender implementation
@@ -72,6 +76,20 @@ func (s *EmailService) SendSubscriptionConfirmation(email, name string, subscrip
 	return s.sender.SendSubscriptionConfirmation(email, name, subscriptions, totalAmount)
 }
 
+func (s *EmailService) SendSignalAlert(email, name, title, message string) error {
+	return s.sender.SendSignalAlert(email, name, title, message)
+}
+
+// signalAlertHTML renders a plain text signal notification as an email body
+func signalAlertHTML(name, message, fromName string) string {
+	return fmt.Sprintf(`
+		<h2>Hi %s,</h2>
+		<p>%s</p>
+		<p>Open the app for full details.</p>
+		<p>Best regards,<br>%s Team</p>
+	`, html.EscapeString(name), strings.ReplaceAll(html.EscapeString(message), "\n", "<br>"), fromName)
+}
+
 // MockEmailService simulates email sending by logging
 type MockEmailService struct {
 	frontendURL      string
@@ -129,6 +147,13 @@ func (s *MockEmailService) SendSubscriptionConfirmation(email, name string, subs
 	return nil
 }
 
+func (s *MockEmailService) SendSignalAlert(email, name, title, message string) error {
+	log.Printf("[EMAIL SIMULATION] Signal alert to %s\n", email)
+	log.Printf("[EMAIL SIMULATION] Name: %s\n", name)
+	log.Printf("[EMAIL SIMULATION] Subject: %s\n", title)
+	return nil
+}
+
 // ResendEmailService sends emails using Resend API
 type ResendEmailService struct {
 	apiKey           string
@@ -258,6 +283,10 @@ func (s *ResendEmailService) SendSubscriptionConfirmation(email, name string, su
 	return s.sendEmail(email, subject, body)
 }
 
+func (s *ResendEmailService) SendSignalAlert(email, name, title, message string) error {
+	return s.sendEmail(email, title, signalAlertHTML(name, message, s.fromName))
+}
+
 // SMTPEmailService sends emails using SMTP
 type SMTPEmailService struct {
 	host             string
@@ -411,3 +440,8 @@ func (s *SMTPEmailService) SendSubscriptionConfirmation(email, name string, subs
 	`, name, packagesHTML, totalAmount, s.fromName)
 	return s.sendEmail(email, subject, body)
 }
+
+func (s *SMTPEmailService) SendSignalAlert(email, name, title, message string) error {
+	// Signal titles carry emoji, which headers only allow encoded
+	return s.sendEmail(email, mime.QEncoding.Encode("utf-8", title), signalAlertHTML(name, message, s.fromName))
+}
@@ -0,0 +1,254 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+const (
+	// expoPushBatchSize is the most messages the Expo push API accepts per request
+	expoPushBatchSize = 100
+
+	// expoReceiptBatchSize is the most receipts the Expo push API returns per request
+	expoReceiptBatchSize = 1000
+
+	// expoReceiptDelay is how long Expo recommends waiting before fetching a receipt
+	expoReceiptDelay = 15 * time.Minute
+
+	// expoReceiptTTL is how long Expo keeps receipts around
+	expoReceiptTTL = 24 * time.Hour
+)
+
+// expoDeviceNotRegistered is the error Expo reports for tokens that can no longer
+// receive pushes, e.g. because the app was uninstalled
+const expoDeviceNotRegistered = "DeviceNotRegistered"
+
+// PushTokenStore keeps the push tokens of users' devices and the tickets of the
+// pushes sent to them, implemented by repositories.PushTokenRepository
+type PushTokenStore interface {
+	GetTokensByUserIDs(userIDs []int64) ([]string, error)
+	DeleteTokens(tokens []string) (int64, error)
+	SaveTickets(tickets []models.PushTicket) error
+	GetDueTickets(age time.Duration, limit int) ([]models.PushTicket, error)
+	DeleteTickets(ids []string) error
+	DeleteStaleTickets(age time.Duration) error
+}
+
+// ExpoNotificationService pushes personal notifications to the mobile app through
+// the Expo push API
+type ExpoNotificationService struct {
+	repo        PushTokenStore
+	baseURL     string
+	accessToken string
+	httpClient  *http.Client
+}
+
+func NewExpoNotificationService(repo PushTokenStore, baseURL, accessToken string) *ExpoNotificationService {
+	return &ExpoNotificationService{
+		repo:        repo,
+		baseURL:     strings.TrimRight(baseURL, "/"),
+		accessToken: accessToken,
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// expoMessage is a single push message of the Expo push API
+type expoMessage struct {
+	To    string                 `json:"to"`
+	Title string                 `json:"title"`
+	Body  string                 `json:"body"`
+	Data  map[string]interface{} `json:"data"`
+	Sound string                 `json:"sound"`
+}
+
+// expoStatus is a push ticket or receipt of the Expo push API
+type expoStatus struct {
+	ID      string `json:"id"`
+	Status  string `json:"status"` // "ok" or "error"
+	Message string `json:"message"`
+	Details struct {
+		Error string `json:"error"`
+	} `json:"details"`
+}
+
+// Deliver pushes a message to every device of the recipients, in batches. Tokens
+// Expo rejects as not registered are removed right away; the tickets of accepted
+// messages are saved after each batch to check their receipts later. A failed
+// batch is logged and the others still sent; an error is only returned when no
+// batch went through, so a retry never pushes a message to a device twice.
+func (s *ExpoNotificationService) Deliver(recipients []models.NotificationRecipient, message *UserMessage) error {
+	userIDs := make([]int64, len(recipients))
+	for i := range recipients {
+		userIDs[i] = recipients[i].UserID
+	}
+
+	tokens, err := s.repo.GetTokensByUserIDs(userIDs)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		return nil
+	}
+
+	var invalidTokens []string
+	var lastErr error
+	sent := 0
+	for start := 0; start < len(tokens); start += expoPushBatchSize {
+		batch := tokens[start:min(start+expoPushBatchSize, len(tokens))]
+		messages := make([]expoMessage, len(batch))
+		for i, token := range batch {
+			messages[i] = expoMessage{To: token, Title: message.Title, Body: message.Body, Data: message.Data, Sound: "default"}
+		}
+
+		statuses, err := s.sendMessages(messages)
+		if err != nil {
+			log.Printf("Failed to push %q to %d devices: %v", message.Title, len(batch), err)
+			lastErr = err
+			continue
+		}
+		sent++
+
+		var tickets []models.PushTicket
+		for i, status := range statuses {
+			if i >= len(batch) {
+				break
+			}
+			switch {
+			case status.Status == "ok":
+				tickets = append(tickets, models.PushTicket{ID: status.ID, Token: batch[i]})
+			case status.Details.Error == expoDeviceNotRegistered:
+				invalidTokens = append(invalidTokens, batch[i])
+			default:
+				log.Printf("Expo rejected push %q: %s", message.Title, status.Message)
+			}
+		}
+		if err := s.repo.SaveTickets(tickets); err != nil {
+			log.Printf("Failed to save Expo push tickets: %v", err)
+		}
+	}
+
+	if _, err := s.repo.DeleteTokens(invalidTokens); err != nil {
+		log.Printf("Failed to delete unregistered Expo push tokens: %v", err)
+	}
+	if sent == 0 {
+		return fmt.Errorf("failed to push to %d devices: %w", len(tokens), lastErr)
+	}
+	return nil
+}
+
+// CheckReceipts fetches the receipts of up to limit push tickets that are old
+// enough and removes the tokens of devices that are no longer registered. It
+// returns the number of receipts received.
+func (s *ExpoNotificationService) CheckReceipts(limit int) (int, error) {
+	if err := s.repo.DeleteStaleTickets(expoReceiptTTL); err != nil {
+		return 0, err
+	}
+
+	tickets, err := s.repo.GetDueTickets(expoReceiptDelay, min(limit, expoReceiptBatchSize))
+	if err != nil {
+		return 0, err
+	}
+	if len(tickets) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]string, len(tickets))
+	for i := range tickets {
+		ids[i] = tickets[i].ID
+	}
+	receipts, err := s.getReceipts(ids)
+	if err != nil {
+		return 0, err
+	}
+
+	// Tickets without a receipt yet are checked again on a later run
+	var checked, invalidTokens []string
+	for _, ticket := range tickets {
+		receipt, ok := receipts[ticket.ID]
+		if !ok {
+			continue
+		}
+		checked = append(checked, ticket.ID)
+		if receipt.Status != "ok" && receipt.Details.Error == expoDeviceNotRegistered {
+			invalidTokens = append(invalidTokens, ticket.Token)
+		}
+	}
+
+	pruned, err := s.repo.DeleteTokens(invalidTokens)
+	if err != nil {
+		return 0, err
+	}
+	if pruned > 0 {
+		log.Printf("Removed %d unregistered Expo push tokens", pruned)
+	}
+	if err := s.repo.DeleteTickets(checked); err != nil {
+		return 0, err
+	}
+
+	return len(checked), nil
+}
+
+// sendMessages posts a batch of messages to the Expo push API and returns their
+// tickets, in the order of the messages
+func (s *ExpoNotificationService) sendMessages(messages []expoMessage) ([]expoStatus, error) {
+	var response struct {
+		Data []expoStatus `json:"data"`
+	}
+	if err := s.post("/--/api/v2/push/send", messages, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// getReceipts fetches the receipts of push tickets, keyed by ticket ID. Receipts
+// that are not ready yet are missing.
+func (s *ExpoNotificationService) getReceipts(ids []string) (map[string]expoStatus, error) {
+	var response struct {
+		Data map[string]expoStatus `json:"data"`
+	}
+	if err := s.post("/--/api/v2/push/getReceipts", map[string]interface{}{"ids": ids}, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// post sends a JSON request to the Expo push API and decodes the response into result
+func (s *ExpoNotificationService) post(path string, body interface{}, result interface{}) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal expo request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", s.baseURL+path, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create expo request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if s.accessToken != "" {
+		req.Header.Set("Authorization", "Bearer "+s.accessToken)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send expo request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("expo API returned status %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("failed to decode expo response: %w", err)
+	}
+	return nil
+}
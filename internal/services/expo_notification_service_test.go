@@ -0,0 +1,221 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+// fakePushTokenStore keeps push tokens and tickets in memory and records the
+// changes the service makes to them
+type fakePushTokenStore struct {
+	tokens         []string
+	tickets        []models.PushTicket
+	requested      [][]int64  // Users of each GetTokensByUserIDs call
+	saved          [][]string // Ticket IDs of each SaveTickets call
+	deletedTokens  [][]string // Tokens of each DeleteTokens call
+	deletedTickets [][]string // Ticket IDs of each DeleteTickets call
+}
+
+func (f *fakePushTokenStore) GetTokensByUserIDs(userIDs []int64) ([]string, error) {
+	f.requested = append(f.requested, userIDs)
+	return slices.Clone(f.tokens), nil
+}
+
+func (f *fakePushTokenStore) DeleteTokens(tokens []string) (int64, error) {
+	f.deletedTokens = append(f.deletedTokens, tokens)
+	before := len(f.tokens)
+	f.tokens = slices.DeleteFunc(f.tokens, func(token string) bool { return slices.Contains(tokens, token) })
+	return int64(before - len(f.tokens)), nil
+}
+
+func (f *fakePushTokenStore) SaveTickets(tickets []models.PushTicket) error {
+	ids := make([]string, len(tickets))
+	for i := range tickets {
+		ids[i] = tickets[i].ID
+	}
+	f.saved = append(f.saved, ids)
+	f.tickets = append(f.tickets, tickets...)
+	return nil
+}
+
+func (f *fakePushTokenStore) GetDueTickets(age time.Duration, limit int) ([]models.PushTicket, error) {
+	return slices.Clone(f.tickets[:min(limit, len(f.tickets))]), nil
+}
+
+func (f *fakePushTokenStore) DeleteTickets(ids []string) error {
+	f.deletedTickets = append(f.deletedTickets, ids)
+	f.tickets = slices.DeleteFunc(f.tickets, func(ticket models.PushTicket) bool { return slices.Contains(ids, ticket.ID) })
+	return nil
+}
+
+func (f *fakePushTokenStore) DeleteStaleTickets(time.Duration) error { return nil }
+
+// newTestExpoService returns a service pushing through the fake Expo API handler
+// to the devices of tokens
+func newTestExpoService(t *testing.T, tokens []string, handler http.HandlerFunc) (*ExpoNotificationService, *fakePushTokenStore) {
+	t.Helper()
+
+	api := httptest.NewServer(handler)
+	t.Cleanup(api.Close)
+
+	store := &fakePushTokenStore{tokens: slices.Clone(tokens)}
+	return NewExpoNotificationService(store, api.URL+"/", ""), store
+}
+
+func testPushTokens(n int) []string {
+	tokens := make([]string, n)
+	for i := range tokens {
+		tokens[i] = fmt.Sprintf("ExponentPushToken[%d]", i)
+	}
+	return tokens
+}
+
+// writeTickets answers a push request with a ticket per message; unregistered
+// tokens get a DeviceNotRegistered error
+func writeTickets(t *testing.T, w http.ResponseWriter, messages []expoMessage, unregistered string) {
+	statuses := make([]map[string]interface{}, len(messages))
+	for i, message := range messages {
+		if message.To == unregistered {
+			statuses[i] = map[string]interface{}{"status": "error", "message": "not registered", "details": map[string]string{"error": expoDeviceNotRegistered}}
+		} else {
+			statuses[i] = map[string]interface{}{"status": "ok", "id": "ticket-" + message.To}
+		}
+	}
+	if err := json.NewEncoder(w).Encode(map[string]interface{}{"data": statuses}); err != nil {
+		t.Error(err)
+	}
+}
+
+func decodeMessages(t *testing.T, r *http.Request) []expoMessage {
+	if r.URL.Path != "/--/api/v2/push/send" {
+		t.Errorf("unexpected Expo call %s", r.URL.Path)
+	}
+	var messages []expoMessage
+	if err := json.NewDecoder(r.Body).Decode(&messages); err != nil {
+		t.Errorf("decode push request: %v", err)
+	}
+	return messages
+}
+
+// savedCounts returns the number of tickets of each SaveTickets call
+func savedCounts(fake *fakePushTokenStore) []int {
+	counts := make([]int, len(fake.saved))
+	for i := range fake.saved {
+		counts[i] = len(fake.saved[i])
+	}
+	return counts
+}
+
+var testRecipients = []models.NotificationRecipient{{UserID: 1}}
+
+func TestExpoDeliverBatches(t *testing.T) {
+	tokens := testPushTokens(250)
+	var batches []int
+	service, fake := newTestExpoService(t, tokens, func(w http.ResponseWriter, r *http.Request) {
+		messages := decodeMessages(t, r)
+		batches = append(batches, len(messages))
+		writeTickets(t, w, messages, tokens[5])
+	})
+
+	if err := service.Deliver(testRecipients, &UserMessage{Title: "TP1 reached", Body: "EURUSD"}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+
+	if !slices.Equal(batches, []int{100, 100, 50}) {
+		t.Errorf("batches = %v, want [100 100 50]", batches)
+	}
+	if len(fake.requested) != 1 || !slices.Equal(fake.requested[0], []int64{1}) {
+		t.Errorf("tokens requested for %v, want user 1 once", fake.requested)
+	}
+	if got := savedCounts(fake); !slices.Equal(got, []int{99, 100, 50}) {
+		t.Errorf("saved tickets per batch = %v, want [99 100 50]", got)
+	}
+	if len(fake.deletedTokens) != 1 || !slices.Equal(fake.deletedTokens[0], []string{tokens[5]}) {
+		t.Errorf("deleted tokens = %v, want only the unregistered %s", fake.deletedTokens, tokens[5])
+	}
+}
+
+func TestExpoDeliverFailedBatch(t *testing.T) {
+	tokens := testPushTokens(250)
+	requests := 0
+	service, fake := newTestExpoService(t, tokens, func(w http.ResponseWriter, r *http.Request) {
+		messages := decodeMessages(t, r)
+		requests++
+		if requests == 2 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		writeTickets(t, w, messages, "")
+	})
+
+	// The batches that went through are not pushed again, so the delivery is not retried
+	if err := service.Deliver(testRecipients, &UserMessage{Title: "TP1 reached"}); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	if requests != 3 {
+		t.Errorf("sent %d batches, want 3", requests)
+	}
+	if got := savedCounts(fake); !slices.Equal(got, []int{100, 50}) {
+		t.Errorf("saved tickets per batch = %v, want those of the accepted batches [100 50]", got)
+	}
+}
+
+func TestExpoDeliverAllBatchesFail(t *testing.T) {
+	service, fake := newTestExpoService(t, testPushTokens(150), func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	if err := service.Deliver(testRecipients, &UserMessage{Title: "TP1 reached"}); err == nil {
+		t.Error("expected an error when no batch was accepted")
+	}
+	if len(fake.saved) != 0 {
+		t.Errorf("saved tickets %v, want none", fake.saved)
+	}
+}
+
+func TestExpoCheckReceipts(t *testing.T) {
+	tokens := testPushTokens(3)
+	service, fake := newTestExpoService(t, tokens, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/--/api/v2/push/getReceipts" {
+			t.Errorf("unexpected Expo call %s", r.URL.Path)
+		}
+		var request struct {
+			IDs []string `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("decode receipts request: %v", err)
+		}
+		if len(request.IDs) != 3 {
+			t.Errorf("requested %d receipts, want 3", len(request.IDs))
+		}
+		// The receipt of the third ticket is not ready yet
+		w.Write([]byte(`{"data":{
+			"ticket-0":{"status":"ok"},
+			"ticket-1":{"status":"error","message":"not registered","details":{"error":"DeviceNotRegistered"}}
+		}}`))
+	})
+	for i, token := range tokens {
+		fake.tickets = append(fake.tickets, models.PushTicket{ID: fmt.Sprintf("ticket-%d", i), Token: token})
+	}
+
+	checked, err := service.CheckReceipts(100)
+	if err != nil {
+		t.Fatalf("CheckReceipts: %v", err)
+	}
+	if checked != 2 {
+		t.Errorf("checked %d receipts, want 2", checked)
+	}
+	if len(fake.deletedTokens) != 1 || !slices.Equal(fake.deletedTokens[0], []string{tokens[1]}) {
+		t.Errorf("deleted tokens = %v, want only the unregistered %s", fake.deletedTokens, tokens[1])
+	}
+	if len(fake.deletedTickets) != 1 || !slices.Equal(fake.deletedTickets[0], []string{"ticket-0", "ticket-1"}) {
+		t.Errorf("deleted tickets = %v, want those with a receipt", fake.deletedTickets)
+	}
+}
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// ExpoReceiptChecker redeems Expo push tickets for their receipts and prunes the
+// tokens of devices that are no longer registered
+type ExpoReceiptChecker struct {
+	expoService *ExpoNotificationService
+	interval    time.Duration
+}
+
+func NewExpoReceiptChecker(expoService *ExpoNotificationService, interval time.Duration) *ExpoReceiptChecker {
+	return &ExpoReceiptChecker{
+		expoService: expoService,
+		interval:    interval,
+	}
+}
+
+// Start runs the checker until ctx is cancelled
+func (c *ExpoReceiptChecker) Start(ctx context.Context) {
+	log.Printf("Expo receipt checker started (every %s)", c.interval)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.CheckDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Println("Expo receipt checker stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// CheckDue checks the receipts of every push ticket that is due, in batches
+func (c *ExpoReceiptChecker) CheckDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		checked, err := c.expoService.CheckReceipts(expoReceiptBatchSize)
+		if err != nil {
+			log.Printf("Expo receipt check failed: %v", err)
+			return
+		}
+		if checked < expoReceiptBatchSize {
+			return
+		}
+	}
+}
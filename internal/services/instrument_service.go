@@ -0,0 +1,353 @@
+package services
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/marketdata"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/repositories"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/risk"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+var (
+	ErrInstrumentNotFound = errors.New("instrument not found")
+	ErrInstrumentExists   = errors.New("instrument already exists")
+	ErrUnknownInstrument  = errors.New("unknown instrument")
+	ErrInvalidCSV         = errors.New("invalid instrument CSV")
+)
+
+// instrumentCSVColumns are the columns accepted by ImportCSV; the first four are required
+var instrumentCSVColumns = []string{"symbol", "asset_class", "display_name", "tick_size", "pip_size", "lot_size", "quote_currency", "sector"}
+
+type InstrumentService struct {
+	repo *repositories.InstrumentRepository
+}
+
+func NewInstrumentService(repo *repositories.InstrumentRepository) *InstrumentService {
+	return &InstrumentService{repo: repo}
+}
+
+// Create normalises and validates an instrument and adds it to the catalog
+func (s *InstrumentService) Create(instrument *models.InstrumentCreate) (*models.Instrument, error) {
+	if errs := normalizeInstrument(instrument); len(errs) > 0 {
+		return nil, errs
+	}
+
+	existing, err := s.repo.GetBySymbol(instrument.AssetClass, instrument.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrInstrumentExists, instrument.AssetClass, instrument.Symbol)
+	}
+
+	return s.repo.Create(instrument)
+}
+
+// GetByID retrieves an instrument by ID
+func (s *InstrumentService) GetByID(id int64) (*models.Instrument, error) {
+	instrument, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if instrument == nil {
+		return nil, ErrInstrumentNotFound
+	}
+	return instrument, nil
+}
+
+// GetAll retrieves the instruments matching filter
+func (s *InstrumentService) GetAll(filter *models.InstrumentFilter, limit, offset int) ([]models.Instrument, error) {
+	return s.repo.GetAll(filter, limit, offset)
+}
+
+// Count returns the total count of instruments matching filter
+func (s *InstrumentService) Count(filter *models.InstrumentFilter) (int64, error) {
+	return s.repo.Count(filter)
+}
+
+// Update updates an instrument's details
+func (s *InstrumentService) Update(id int64, update *models.InstrumentUpdate) (*models.Instrument, error) {
+	existing, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrInstrumentNotFound
+	}
+
+	var errs utils.ValidationErrors
+	if update.PipSize != nil && existing.AssetClass != models.AssetClassForex {
+		errs = append(errs, utils.FieldError{Field: "pip_size", Message: "pip_size only applies to FOREX instruments"})
+	}
+	if update.Sector != nil && existing.AssetClass != models.AssetClassPSX {
+		errs = append(errs, utils.FieldError{Field: "sector", Message: "sector only applies to PSX instruments"})
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	if update.QuoteCurrency != nil {
+		quoteCurrency := strings.ToUpper(strings.TrimSpace(*update.QuoteCurrency))
+		update.QuoteCurrency = &quoteCurrency
+	}
+
+	instrument, err := s.repo.Update(id, update)
+	if err != nil {
+		return nil, err
+	}
+	if instrument == nil {
+		return nil, ErrInstrumentNotFound
+	}
+	return instrument, nil
+}
+
+// Delete removes an instrument from the catalog. Signals already posted keep
+// their symbol; deactivating the instrument is usually preferable.
+func (s *InstrumentService) Delete(id int64) error {
+	deleted, err := s.repo.Delete(id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrInstrumentNotFound
+	}
+	return nil
+}
+
+// Resolve returns the active instrument a signal symbol refers to, accepting
+// any spelling that normalises to the canonical symbol (e.g. "eur/usd"). Until
+// the catalog has been populated every symbol is accepted in its normalised form.
+func (s *InstrumentService) Resolve(assetClass models.AssetClass, symbol string) (*models.Instrument, error) {
+	canonical := marketdata.NormalizeSymbol(symbol)
+	instrument, err := s.repo.GetBySymbol(assetClass, canonical)
+	if err != nil {
+		return nil, err
+	}
+	if instrument == nil {
+		count, err := s.repo.Count(nil)
+		if err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return &models.Instrument{Symbol: canonical, AssetClass: assetClass, IsActive: true}, nil
+		}
+	}
+	if instrument == nil || !instrument.IsActive {
+		return nil, fmt.Errorf("%w: %s is not a listed %s instrument", ErrUnknownInstrument, symbol, assetClass)
+	}
+	return instrument, nil
+}
+
+// ImportCSV creates or updates instruments from CSV with a header row naming
+// the columns. Invalid rows are reported in the result and do not stop the import.
+func (s *InstrumentService) ImportCSV(reader io.Reader) (*models.InstrumentImportResult, error) {
+	return s.importCSV(reader, func(instrument *models.InstrumentCreate, result *models.InstrumentImportResult) error {
+		_, created, err := s.repo.Upsert(instrument)
+		if err != nil {
+			return err
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Updated++
+		}
+		return nil
+	})
+}
+
+// SeedCSV adds the instruments from CSV that are not yet in the catalog. Rows
+// for existing symbols are skipped, so edits and deactivations made by admins
+// survive a restart that reloads the seed file.
+func (s *InstrumentService) SeedCSV(reader io.Reader) (*models.InstrumentImportResult, error) {
+	return s.importCSV(reader, func(instrument *models.InstrumentCreate, result *models.InstrumentImportResult) error {
+		created, err := s.repo.CreateIfMissing(instrument)
+		if err != nil {
+			return err
+		}
+		if created {
+			result.Created++
+		} else {
+			result.Skipped++
+		}
+		return nil
+	})
+}
+
+// importCSV parses and validates instrument rows and hands each valid one to store
+func (s *InstrumentService) importCSV(reader io.Reader, store func(*models.InstrumentCreate, *models.InstrumentImportResult) error) (*models.InstrumentImportResult, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.TrimLeadingSpace = true
+	csvReader.FieldsPerRecord = -1
+
+	header, err := csvReader.Read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("%w: the file is empty", ErrInvalidCSV)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, required := range instrumentCSVColumns[:4] {
+		if _, ok := columns[required]; !ok {
+			return nil, fmt.Errorf("%w: missing %s column", ErrInvalidCSV, required)
+		}
+	}
+
+	result := &models.InstrumentImportResult{Errors: []models.InstrumentImportError{}}
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+			}
+			result.Errors = append(result.Errors, models.InstrumentImportError{Line: parseErr.Line, Message: err.Error()})
+			continue
+		}
+		line, _ := csvReader.FieldPos(0)
+
+		instrument, err := parseInstrumentRecord(record, columns)
+		if err == nil {
+			err = utils.ValidateStruct(instrument)
+		}
+		if err == nil {
+			if errs := normalizeInstrument(instrument); len(errs) > 0 {
+				err = errs
+			}
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, models.InstrumentImportError{Line: line, Symbol: instrument.Symbol, Message: err.Error()})
+			continue
+		}
+
+		if err := store(instrument, result); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// parseInstrumentRecord reads a CSV row into an instrument. Missing optional
+// columns and empty cells are left unset.
+func parseInstrumentRecord(record []string, columns map[string]int) (*models.InstrumentCreate, error) {
+	value := func(column string) string {
+		i, ok := columns[column]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+	number := func(column string) (*float64, error) {
+		raw := value(column)
+		if raw == "" {
+			return nil, nil
+		}
+		parsed, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s must be a number", column)
+		}
+		return &parsed, nil
+	}
+
+	instrument := &models.InstrumentCreate{
+		Symbol:        value("symbol"),
+		AssetClass:    models.AssetClass(strings.ToUpper(value("asset_class"))),
+		DisplayName:   value("display_name"),
+		QuoteCurrency: value("quote_currency"),
+	}
+	if sector := value("sector"); sector != "" {
+		instrument.Sector = &sector
+	}
+
+	tickSize, err := number("tick_size")
+	if err != nil {
+		return instrument, err
+	}
+	if tickSize != nil {
+		instrument.TickSize = *tickSize
+	}
+	if instrument.PipSize, err = number("pip_size"); err != nil {
+		return instrument, err
+	}
+	lotSize, err := number("lot_size")
+	if err != nil {
+		return instrument, err
+	}
+	if lotSize != nil {
+		instrument.LotSize = *lotSize
+	}
+
+	return instrument, nil
+}
+
+// normalizeInstrument converts the symbol to canonical form, fills defaults and
+// checks the fields that depend on the asset class
+func normalizeInstrument(instrument *models.InstrumentCreate) utils.ValidationErrors {
+	var errs utils.ValidationErrors
+
+	instrument.Symbol = marketdata.NormalizeSymbol(instrument.Symbol)
+	instrument.DisplayName = strings.TrimSpace(instrument.DisplayName)
+	instrument.QuoteCurrency = strings.ToUpper(strings.TrimSpace(instrument.QuoteCurrency))
+	if instrument.LotSize == 0 {
+		instrument.LotSize = 1
+	}
+
+	for _, char := range instrument.Symbol {
+		if (char < 'A' || char > 'Z') && (char < '0' || char > '9') {
+			errs = append(errs, utils.FieldError{Field: "symbol", Message: "symbol may only contain letters and digits besides separators"})
+			break
+		}
+	}
+
+	switch instrument.AssetClass {
+	case models.AssetClassForex:
+		if len(instrument.Symbol) != 6 {
+			errs = append(errs, utils.FieldError{Field: "symbol", Message: "FOREX symbols must be a currency pair such as EURUSD"})
+			break
+		}
+		if instrument.QuoteCurrency == "" {
+			instrument.QuoteCurrency = instrument.Symbol[3:]
+		} else if instrument.QuoteCurrency != instrument.Symbol[3:] {
+			errs = append(errs, utils.FieldError{Field: "quote_currency", Message: fmt.Sprintf("quote_currency must be %s for %s", instrument.Symbol[3:], instrument.Symbol)})
+		}
+		if instrument.PipSize == nil {
+			pipSize := risk.PipSize(instrument.Symbol)
+			instrument.PipSize = &pipSize
+		}
+	case models.AssetClassPSX:
+		if instrument.QuoteCurrency == "" {
+			instrument.QuoteCurrency = "PKR"
+		}
+	default:
+		if instrument.QuoteCurrency == "" {
+			errs = append(errs, utils.FieldError{Field: "quote_currency", Message: "quote_currency is required for CRYPTO instruments"})
+		}
+	}
+
+	if instrument.PipSize != nil && instrument.AssetClass != models.AssetClassForex {
+		errs = append(errs, utils.FieldError{Field: "pip_size", Message: "pip_size only applies to FOREX instruments"})
+	}
+	if instrument.Sector != nil {
+		sector := strings.TrimSpace(*instrument.Sector)
+		instrument.Sector = &sector
+		if instrument.AssetClass != models.AssetClassPSX {
+			errs = append(errs, utils.FieldError{Field: "sector", Message: "sector only applies to PSX instruments"})
+		}
+	}
+
+	return errs
+}
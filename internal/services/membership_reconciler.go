@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+// MembershipReconciler periodically reconciles private channel memberships with
+// subscriptions, deactivating expired subscriptions first. In between it invites
+// the users queued with QueueSync, e.g. once they subscribe.
+type MembershipReconciler struct {
+	service  *ChannelMembershipService
+	interval time.Duration
+}
+
+func NewMembershipReconciler(service *ChannelMembershipService, interval time.Duration) *MembershipReconciler {
+	return &MembershipReconciler{
+		service:  service,
+		interval: interval,
+	}
+}
+
+// Start runs the reconciler until ctx is cancelled
+func (r *MembershipReconciler) Start(ctx context.Context) {
+	log.Printf("Membership reconciler started (every %s)", r.interval)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.reconcile(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Membership reconciler stopped")
+			return
+		case sync := <-r.service.syncQueue:
+			switch err := r.service.syncUser(ctx, sync); {
+			case errors.Is(err, ErrReconcileInProgress):
+				// The reconciliation running invites the user, or the next one does
+			case err != nil:
+				log.Printf("Failed to sync channel memberships of user %d: %v", sync.userID, err)
+			}
+		case <-ticker.C:
+			r.reconcile(ctx)
+		}
+	}
+}
+
+// reconcile runs a scheduled reconciliation, logging its outcome
+func (r *MembershipReconciler) reconcile(ctx context.Context) {
+	result, err := r.service.Reconcile(ctx, models.MembershipTriggerSchedule, nil)
+	switch {
+	case errors.Is(err, ErrReconcileInProgress):
+		// Another instance or an admin is reconciling
+	case err != nil:
+		log.Printf("Membership reconciler run failed: %v", err)
+	case result.Invited+result.Granted+result.Removed+result.Failed > 0 || result.Deactivated > 0:
+		log.Printf("Reconciled memberships: %d subscriptions deactivated, %d invited, %d granted, %d removed, %d failed",
+			result.Deactivated, result.Invited, result.Granted, result.Removed, result.Failed)
+	}
+}
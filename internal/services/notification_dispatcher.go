@@ -0,0 +1,102 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+const (
+	// dispatchBatchSize is the number of queued notifications fanned out per query
+	dispatchBatchSize = 50
+
+	// deliveryBatchSize is the number of deliveries claimed per query. Kept small
+	// so a slow channel does not hold on to many leases.
+	deliveryBatchSize = 10
+
+	// digestBatchSize is the number of user digests claimed per query
+	digestBatchSize = 50
+)
+
+// NotificationDispatcher delivers the notification outbox. Notifications are
+// queued in the database along with the change they announce, so nothing is lost
+// on restart, and both notifications and deliveries are claimed atomically so
+// several instances can run it side by side. It also sends the personal
+// notifications users asked to receive later.
+type NotificationDispatcher struct {
+	outboxService     *NotificationOutboxService
+	userNotifications *UserNotificationService
+	interval          time.Duration
+}
+
+func NewNotificationDispatcher(outboxService *NotificationOutboxService, userNotifications *UserNotificationService, interval time.Duration) *NotificationDispatcher {
+	return &NotificationDispatcher{
+		outboxService:     outboxService,
+		userNotifications: userNotifications,
+		interval:          interval,
+	}
+}
+
+// Start runs the dispatcher until ctx is cancelled. Notifications queued while no
+// instance was running are delivered on the first run.
+func (d *NotificationDispatcher) Start(ctx context.Context) {
+	log.Printf("Notification dispatcher started (every %s)", d.interval)
+
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.DispatchDue(ctx)
+		d.DeliverDue(ctx)
+		d.SendDigests(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Println("Notification dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue fans every queued notification out to its channels, in batches
+func (d *NotificationDispatcher) DispatchDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		dispatched, err := d.outboxService.DispatchDue(dispatchBatchSize)
+		if err != nil {
+			log.Printf("Notification dispatch run failed: %v", err)
+			return
+		}
+		if dispatched < dispatchBatchSize {
+			return
+		}
+	}
+}
+
+// DeliverDue attempts every delivery that is due, in batches
+func (d *NotificationDispatcher) DeliverDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		attempted, err := d.outboxService.DeliverDue(deliveryBatchSize)
+		if err != nil {
+			log.Printf("Notification delivery run failed: %v", err)
+			return
+		}
+		if attempted < deliveryBatchSize {
+			return
+		}
+	}
+}
+
+// SendDigests sends every held personal notification that is due, in batches
+func (d *NotificationDispatcher) SendDigests(ctx context.Context) {
+	for ctx.Err() == nil {
+		sent, err := d.userNotifications.SendDigests(digestBatchSize)
+		if err != nil {
+			log.Printf("Notification digest run failed: %v", err)
+			return
+		}
+		if sent < digestBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/config"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/repositories"
+)
+
+var (
+	ErrNotificationDeliveryNotFound = errors.New("notification delivery not found")
+	ErrDeliveryNotRetryable         = errors.New("only dead-lettered deliveries can be retried")
+)
+
+// deliveryLease is how long a claimed delivery is hidden from other instances
+// while it is being sent
+const deliveryLease = 5 * time.Minute
+
+// NotificationOutboxService delivers queued notifications to their channels,
+// retrying failed attempts with exponential backoff
+type NotificationOutboxService struct {
+	repo                *repositories.NotificationOutboxRepository
+	notificationService *NotificationService
+	cfg                 *config.NotificationConfig
+}
+
+func NewNotificationOutboxService(repo *repositories.NotificationOutboxRepository, notificationService *NotificationService, cfg *config.NotificationConfig) *NotificationOutboxService {
+	return &NotificationOutboxService{
+		repo:                repo,
+		notificationService: notificationService,
+		cfg:                 cfg,
+	}
+}
+
+// DispatchDue fans up to limit queued notifications out to a delivery per channel.
+// It returns the number of notifications dispatched.
+func (s *NotificationOutboxService) DispatchDue(limit int) (int, error) {
+	return s.repo.Dispatch(limit, s.notificationService.Channels)
+}
+
+// DeliverDue makes an attempt at up to limit due deliveries. Failed deliveries are
+// retried later, or dead-lettered once they run out of attempts. It returns the
+// number of deliveries attempted.
+func (s *NotificationOutboxService) DeliverDue(limit int) (int, error) {
+	deliveries, err := s.repo.ClaimDueDeliveries(limit, deliveryLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		sendErr := s.notificationService.Send(delivery.Channel, delivery.Notification)
+		if sendErr == nil {
+			err = s.repo.MarkDeliverySent(delivery.ID)
+		} else {
+			dead := delivery.Attempts >= s.cfg.MaxAttempts || errors.Is(sendErr, ErrUnknownNotificationChannel)
+			err = s.repo.MarkDeliveryFailed(delivery.ID, sendErr.Error(), s.retryDelay(delivery.Attempts), dead)
+		}
+		if err != nil {
+			// The lease runs out and the delivery is attempted again
+			fmt.Printf("Failed to record outcome of notification delivery %d: %v\n", delivery.ID, err)
+		}
+	}
+
+	return len(deliveries), nil
+}
+
+// retryDelay returns the wait before the next attempt after attempts failed ones
+func (s *NotificationOutboxService) retryDelay(attempts int) time.Duration {
+	delay := s.cfg.RetryBaseDelay
+	for i := 1; i < attempts && delay < s.cfg.RetryMaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.cfg.RetryMaxDelay {
+		delay = s.cfg.RetryMaxDelay
+	}
+	return delay
+}
+
+// GetDeliveries retrieves deliveries matching filter
+func (s *NotificationOutboxService) GetDeliveries(filter *models.NotificationDeliveryFilter, limit, offset int) ([]models.NotificationDelivery, error) {
+	return s.repo.GetDeliveries(filter, limit, offset)
+}
+
+// CountDeliveries counts deliveries matching filter
+func (s *NotificationOutboxService) CountDeliveries(filter *models.NotificationDeliveryFilter) (int64, error) {
+	return s.repo.CountDeliveries(filter)
+}
+
+// Retry puts a dead-lettered delivery back in the queue with a fresh set of attempts
+func (s *NotificationOutboxService) Retry(id int64) (*models.NotificationDelivery, error) {
+	delivery, err := s.repo.RetryDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+	if delivery != nil {
+		return delivery, nil
+	}
+
+	existing, err := s.repo.GetDelivery(id)
+	if err != nil {
+		return nil, err
+	}
+	if existing == nil {
+		return nil, ErrNotificationDeliveryNotFound
+	}
+	return nil, fmt.Errorf("%w: delivery is %s", ErrDeliveryNotRetryable, existing.Status)
+}
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/repositories"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// NotificationPreferenceService manages the personal notification preferences of users
+type NotificationPreferenceService struct {
+	repo *repositories.NotificationPreferenceRepository
+}
+
+func NewNotificationPreferenceService(repo *repositories.NotificationPreferenceRepository) *NotificationPreferenceService {
+	return &NotificationPreferenceService{repo: repo}
+}
+
+// Get retrieves a user's preferences, the defaults if they saved none
+func (s *NotificationPreferenceService) Get(userID int64) (*models.NotificationPreferences, error) {
+	preferences, err := s.repo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if preferences == nil {
+		return nil, ErrUserNotFound
+	}
+	return preferences, nil
+}
+
+// Update validates and replaces a user's preferences
+func (s *NotificationPreferenceService) Update(userID int64, update *models.NotificationPreferencesUpdate) (*models.NotificationPreferences, error) {
+	if update.AssetClasses == nil {
+		update.AssetClasses = []models.AssetClass{}
+	}
+	if update.DurationTypes == nil {
+		update.DurationTypes = []models.DurationType{}
+	}
+	if update.Channels == nil {
+		update.Channels = []models.NotificationChannel{}
+	}
+	if update.DigestTime == "" {
+		update.DigestTime = models.DefaultDigestTime
+	}
+
+	var errs utils.ValidationErrors
+	if _, err := time.LoadLocation(update.Timezone); err != nil || update.Timezone == "Local" {
+		errs = append(errs, utils.FieldError{Field: "timezone", Message: "timezone must be an IANA time zone, e.g. Asia/Karachi"})
+	}
+	if _, ok := models.ParseClock(update.DigestTime); !ok {
+		errs = append(errs, utils.FieldError{Field: "digest_time", Message: "digest_time must be a time of day as HH:MM"})
+	}
+	if update.QuietHoursStart != nil && update.QuietHoursEnd != nil {
+		start, okStart := models.ParseClock(*update.QuietHoursStart)
+		end, okEnd := models.ParseClock(*update.QuietHoursEnd)
+		switch {
+		case !okStart:
+			errs = append(errs, utils.FieldError{Field: "quiet_hours_start", Message: "quiet_hours_start must be a time of day as HH:MM"})
+		case !okEnd:
+			errs = append(errs, utils.FieldError{Field: "quiet_hours_end", Message: "quiet_hours_end must be a time of day as HH:MM"})
+		case start == end:
+			errs = append(errs, utils.FieldError{Field: "quiet_hours_end", Message: "quiet_hours_end must differ from quiet_hours_start"})
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	preferences, err := s.repo.Upsert(userID, update)
+	if err != nil {
+		return nil, err
+	}
+	return preferences, nil
+}
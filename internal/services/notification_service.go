@@ -3,173 +3,583 @@ package services
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/omarshah0/rest-api-with-social-auth/internal/config"
 	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/risk"
 )
 
-// NotificationSender is the interface for sending notifications
+// NotificationSender delivers signal notifications to a single channel, formatting
+// each event in the channel's own style
 type NotificationSender interface {
-	SendSignalNotification(signal *models.TradingSignal) error
+	Send(notification *models.SignalNotification) error
 }
 
-// NotificationService manages multiple notification senders
+// ErrUnknownNotificationChannel is returned for channels that are not configured (anymore)
+var ErrUnknownNotificationChannel = errors.New("unknown notification channel")
+
+// notificationChannel is a sender along with the events it is configured to receive
+type notificationChannel struct {
+	name   string
+	sender NotificationSender
+	events map[models.NotificationEvent]bool
+
+	// freeOnly channels are open to anyone and only hear of free-for-all signals
+	freeOnly bool
+}
+
+// NotificationService routes signal notifications to the configured channels
 type NotificationService struct {
-	senders []NotificationSender
+	channels map[string]*notificationChannel
+
+	// tierChannels are the audiences announced new signals, keyed by package tier
+	tierChannels map[models.PackageTier][]*notificationChannel
 }
 
-// NewNotificationService creates a new notification service with configured senders
-func NewNotificationService(
-	telegramEnabled bool, telegramBotToken, telegramChatID string,
-	discordEnabled bool, discordWebhookURL string,
-	expoEnabled bool,
-) *NotificationService {
-	var senders []NotificationSender
+// NewNotificationService creates a new notification service with configured senders.
+// The Telegram chat and Discord webhook make up the STANDARD tier audience, the VIP
+// chat and webhook the VIP one. Personal channels are registered for every tier,
+// e.g. "expo" and "expo_vip", each reaching the subscribers of its tier. Later
+// events on signals go to every sender whose channel is configured to receive
+// them. Once users can link their Telegram account to the bot, the Telegram chat
+// only hears of free-for-all signals. Telegram and Discord messages use the
+// channel's template for the event if one is saved.
+func NewNotificationService(cfg *config.NotificationConfig, userNotifications *UserNotificationService, templates *NotificationTemplateService) *NotificationService {
+	service := &NotificationService{
+		channels:     make(map[string]*notificationChannel),
+		tierChannels: make(map[models.PackageTier][]*notificationChannel),
+	}
+	addSender := func(tier models.PackageTier, name string, sender NotificationSender, events []string) *notificationChannel {
+		channel := &notificationChannel{
+			name:   name,
+			sender: sender,
+			events: notificationEventSet(events),
+		}
+		service.channels[name] = channel
+		service.tierChannels[tier] = append(service.tierChannels[tier], channel)
+		return channel
+	}
 
-	if telegramEnabled && telegramBotToken != "" && telegramChatID != "" {
-		senders = append(senders, NewTelegramNotificationService(telegramBotToken, telegramChatID))
+	if cfg.TelegramEnabled && cfg.TelegramBotToken != "" {
+		telegramClient := NewTelegramClient(cfg.TelegramAPIURL, cfg.TelegramBotToken)
+		if cfg.TelegramChatID != "" {
+			channel := addSender(models.PackageTierStandard, "telegram", NewTelegramNotificationService(telegramClient, cfg.TelegramChatID, templates), cfg.TelegramEvents)
+			// Paying users get their signals from the bot, so the open chat is left
+			// with the signals anyone may see
+			channel.freeOnly = cfg.TelegramBotEnabled()
+		}
+		if cfg.TelegramVIPChatID != "" {
+			addSender(models.PackageTierVIP, "telegram_vip", NewTelegramNotificationService(telegramClient, cfg.TelegramVIPChatID, templates), cfg.TelegramEvents)
+		}
 	}
 
-	if discordEnabled && discordWebhookURL != "" {
-		senders = append(senders, NewDiscordNotificationService(discordWebhookURL))
+	if cfg.DiscordEnabled {
+		if cfg.DiscordWebhookURL != "" {
+			addSender(models.PackageTierStandard, "discord", NewDiscordNotificationService(cfg.DiscordWebhookURL, templates), cfg.DiscordEvents)
+		}
+		if cfg.DiscordVIPWebhookURL != "" {
+			addSender(models.PackageTierVIP, "discord_vip", NewDiscordNotificationService(cfg.DiscordVIPWebhookURL, templates), cfg.DiscordEvents)
+		}
+	}
+
+	// Personal channels reach each user of the tier as their preferences allow
+	for _, tier := range models.PackageTiers {
+		suffix := ""
+		if tier != models.PackageTierStandard {
+			suffix = "_" + strings.ToLower(string(tier))
+		}
+		if cfg.ExpoEnabled {
+			addSender(tier, "expo"+suffix, userNotifications.Sender(models.NotificationChannelPush, tier), cfg.ExpoEvents)
+		}
+		if cfg.EmailEnabled {
+			addSender(tier, "email"+suffix, userNotifications.Sender(models.NotificationChannelEmail, tier), cfg.EmailEvents)
+		}
+		if cfg.TelegramBotEnabled() {
+			addSender(tier, "telegram_dm"+suffix, userNotifications.Sender(models.NotificationChannelTelegram, tier), cfg.TelegramEvents)
+		}
 	}
 
-	if expoEnabled {
-		senders = append(senders, NewExpoNotificationService())
+	return service
+}
+
+// notificationEventSet returns the configured events of a channel; all events
+// when none are configured
+func notificationEventSet(events []string) map[models.NotificationEvent]bool {
+	set := make(map[models.NotificationEvent]bool)
+	for _, event := range events {
+		set[models.NotificationEvent(strings.TrimSpace(event))] = true
+	}
+	if len(set) == 0 {
+		for _, event := range models.NotificationEvents {
+			set[event] = true
+		}
 	}
+	return set
+}
 
-	return &NotificationService{
-		senders: senders,
+// Channels returns the names of the channels that receive a queued notification:
+// those of its tier, or of every tier, configured to receive its event. Higher
+// tiers come first. Channels open to anyone are skipped unless the signal is free
+// for all.
+func (s *NotificationService) Channels(notification *models.OutboxNotification) []string {
+	var names []string
+	for _, tier := range models.PackageTiers {
+		if notification.Tier != nil && *notification.Tier != tier {
+			continue
+		}
+		for _, channel := range s.tierChannels[tier] {
+			if channel.freeOnly && !notification.FreeForAll {
+				continue
+			}
+			if channel.events[notification.Event] {
+				names = append(names, channel.name)
+			}
+		}
 	}
+	return names
 }
 
-// SendSignalNotification sends a signal notification to all configured senders
-func (s *NotificationService) SendSignalNotification(signal *models.TradingSignal) error {
-	if len(s.senders) == 0 {
-		log.Println("No notification senders configured, skipping notification")
-		return nil
+// Send delivers a notification through the named channel
+func (s *NotificationService) Send(channelName string, notification *models.SignalNotification) error {
+	channel, ok := s.channels[channelName]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownNotificationChannel, channelName)
 	}
+	return channel.sender.Send(notification)
+}
 
-	var lastError error
-	for _, sender := range s.senders {
-		if err := sender.SendSignalNotification(signal); err != nil {
-			log.Printf("Failed to send notification: %v", err)
-			lastError = err
+// formatUpdateActions renders the actions taken by a signal update, one per line,
+// e.g. "Stop loss moved to 1.085". Without levels the prices are left out, e.g.
+// "Stop loss moved", for channels anyone may read.
+func formatUpdateActions(update *models.SignalUpdate, levels bool) []string {
+	var actions []string
+	if update.NewStopLoss != nil {
+		action := "Stop loss moved"
+		if levels {
+			action += " to " + formatPrice(*update.NewStopLoss)
 		}
+		actions = append(actions, action)
 	}
+	if update.ClosePercent != nil && update.ClosePrice != nil {
+		action := fmt.Sprintf("Closed %s%% of the position", strconv.FormatFloat(*update.ClosePercent, 'f', -1, 64))
+		if levels {
+			action += " at " + formatPrice(*update.ClosePrice)
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
 
-	return lastError
+// changeLabels names the editable signal fields in notifications
+var changeLabels = map[string]string{
+	"symbol":            "Symbol",
+	"asset_class":       "Asset class",
+	"duration_type":     "Duration",
+	"type":              "Type",
+	"entry_price":       "Entry",
+	"stop_loss_price":   "Stop loss",
+	"take_profit_price": "Take profit",
+	"free_for_all":      "Free for all",
+	"comments":          "Comments",
+	"valid_until":       "Valid until",
+	"targets":           "Targets",
 }
 
-// TelegramNotificationService sends notifications to Telegram
-type TelegramNotificationService struct {
-	botToken   string
-	chatID     string
-	httpClient *http.Client
+// formatChanges renders the fields changed by an edit, one per line, e.g.
+// "Stop loss: 1.08 → 1.085". Values other than prices and names are only
+// reported as updated, as are prices without levels, e.g. "Stop loss updated".
+func formatChanges(changes map[string]models.FieldChange, levels bool) []string {
+	fields := make([]string, 0, len(changes))
+	for field := range changes {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	lines := make([]string, 0, len(fields))
+	for _, field := range fields {
+		label, ok := changeLabels[field]
+		if !ok {
+			label = field
+		}
+		from, fromOK := formatChangeValue(changes[field].From, levels)
+		to, toOK := formatChangeValue(changes[field].To, levels)
+		if fromOK && toOK {
+			lines = append(lines, fmt.Sprintf("%s: %s → %s", label, from, to))
+		} else {
+			lines = append(lines, label+" updated")
+		}
+	}
+	return lines
 }
 
-func NewTelegramNotificationService(botToken, chatID string) *TelegramNotificationService {
-	return &TelegramNotificationService{
-		botToken:   botToken,
-		chatID:     chatID,
-		httpClient: &http.Client{Timeout: 10 * time.Second},
+// formatChangeValue renders a changed price, name or flag; it reports false for
+// other values such as target lists and comments, and for prices without levels
+func formatChangeValue(value interface{}, levels bool) (string, bool) {
+	switch v := value.(type) {
+	case float64:
+		return formatPrice(v), levels
+	case bool:
+		return strconv.FormatBool(v), true
+	case string:
+		return v, true
+	case models.AssetClass:
+		return string(v), true
+	case models.DurationType:
+		return string(v), true
+	case models.SignalType:
+		return string(v), true
 	}
+	return "", false
 }
 
-func (s *TelegramNotificationService) SendSignalNotification(signal *models.TradingSignal) error {
-	message := fmt.Sprintf(
-		"🚨 *New %s %s Signal!*\n\n"+
-			"Asset: %s\n"+
-			"Type: %s\n\n"+
-			"Check the app for details! 📊",
-		signal.AssetClass,
-		signal.DurationType,
-		signal.Symbol,
-		signal.Type,
-	)
+// formatClosedTitle describes how a signal was closed, e.g. "SL hit"
+func formatClosedTitle(signal *models.TradingSignal) string {
+	switch signal.Status {
+	case models.SignalStatusTPHit:
+		return "TP hit"
+	case models.SignalStatusSLHit:
+		return "SL hit"
+	default:
+		return "closed"
+	}
+}
 
-	reqBody := map[string]interface{}{
-		"chat_id":    s.chatID,
-		"text":       message,
-		"parse_mode": "Markdown",
+// formatResult renders the result and return of a closed signal, e.g. "WIN (+2.35%)"
+func formatResult(signal *models.TradingSignal) string {
+	if signal.Result == nil || signal.Return == nil {
+		return "n/a"
 	}
+	return fmt.Sprintf("%s (%+.2f%%)", *signal.Result, *signal.Return)
+}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return fmt.Errorf("failed to marshal telegram request: %w", err)
+// formatExitPrice renders the exit price of a closed signal
+func formatExitPrice(signal *models.TradingSignal) string {
+	if signal.ExitPrice == nil {
+		return "n/a"
 	}
+	return formatPrice(*signal.ExitPrice)
+}
 
-	url := fmt.Sprintf("https://api.telegram.org/bot%s/sendMessage", s.botToken)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("failed to create telegram request: %w", err)
+// escapeMarkdown escapes the characters with a meaning in Telegram's Markdown,
+// so analyst text is shown as written
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
+
+var markdownEscaper = strings.NewReplacer("_", "\\_", "*", "\\*", "`", "\\`", "[", "\\[")
+
+// formatTargetProgress renders the targets of a signal with their hit state, e.g. "TP1 ✅ | TP2 ⏳"
+func formatTargetProgress(signal *models.TradingSignal) string {
+	parts := make([]string, 0, len(signal.Targets))
+	for i := range signal.Targets {
+		marker := "⏳"
+		if signal.Targets[i].IsHit() {
+			marker = "✅"
+		}
+		parts = append(parts, fmt.Sprintf("TP%d %s", signal.Targets[i].Position, marker))
 	}
+	return strings.Join(parts, " | ")
+}
 
-	req.Header.Set("Content-Type", "application/json")
+// formatRiskReward renders the signal's risk-reward ratio, e.g. "1:2.50"
+func formatRiskReward(signal *models.TradingSignal) string {
+	return fmt.Sprintf("1:%.2f", signal.RiskPosition().RiskReward())
+}
 
-	resp, err := s.httpClient.Do(req)
-	if err != nil {
-		return fmt.Errorf("failed to send telegram request: %w", err)
+// formatPrice renders a price without trailing zeros, e.g. "1.0850" becomes "1.085"
+func formatPrice(price float64) string {
+	return strconv.FormatFloat(price, 'f', -1, 64)
+}
+
+// formatTargetGain renders the move from entry to a target in the asset's native
+// unit, e.g. "+25.0 pips (0.23%)"
+func formatTargetGain(signal *models.TradingSignal, target *models.SignalTarget) string {
+	position := signal.RiskPosition()
+	distance := position.NativeDistance(position.Entry, target.Price)
+	unit := risk.Unit(position.AssetClass)
+	if unit == risk.UnitPercent {
+		return "+" + risk.FormatDistance(distance, unit)
 	}
-	defer resp.Body.Close()
+	return fmt.Sprintf("+%s (%.2f%%)", risk.FormatDistance(distance, unit), risk.PercentDistance(position.Entry, target.Price))
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("telegram API returned status %d", resp.StatusCode)
+// TelegramNotificationService sends notifications to a Telegram group or channel
+type TelegramNotificationService struct {
+	client    *TelegramClient
+	chatID    string
+	templates *NotificationTemplateService
+}
+
+func NewTelegramNotificationService(client *TelegramClient, chatID string, templates *NotificationTemplateService) *TelegramNotificationService {
+	return &TelegramNotificationService{
+		client:    client,
+		chatID:    chatID,
+		templates: templates,
+	}
+}
+
+// Send posts the message for a notification's event to the configured chat,
+// rendered from the event's template if one is saved
+func (s *TelegramNotificationService) Send(notification *models.SignalNotification) error {
+	message, parseMode := "", "Markdown"
+	if rendered := s.templates.Render(models.TemplateChannelTelegram, notification); rendered != nil {
+		message, parseMode = rendered.Body, rendered.ParseMode
+	} else {
+		var err error
+		if message, err = telegramMessage(notification); err != nil {
+			return err
+		}
 	}
 
-	log.Printf("Telegram notification sent for signal ID %d", signal.ID)
+	if err := s.client.SendMessage(s.chatID, message, parseMode); err != nil {
+		return err
+	}
+
+	log.Printf("Telegram %s notification sent for signal ID %d", notification.Event, notification.Signal.ID)
 	return nil
 }
 
+// telegramMessage formats a notification as a Telegram Markdown message
+func telegramMessage(notification *models.SignalNotification) (string, error) {
+	signal := notification.Signal
+	var message string
+
+	switch notification.Event {
+	case models.NotificationEventCreated:
+		message = fmt.Sprintf(
+			"🚨 *New %s %s Signal!*\n\n"+
+				"Asset: %s\n"+
+				"Type: %s\n"+
+				"Targets: %d\n"+
+				"Risk/Reward: %s",
+			signal.AssetClass,
+			signal.DurationType,
+			signal.Symbol,
+			signal.Type,
+			len(signal.Targets),
+			formatRiskReward(signal),
+		)
+
+	case models.NotificationEventUpdated:
+		var lines []string
+		if update := notification.Update; update != nil {
+			message = fmt.Sprintf("📝 *Update on %s %s*\n\n%s", signal.Symbol, signal.Type, escapeMarkdown(update.Message))
+			lines = formatUpdateActions(update, false)
+		} else {
+			message = fmt.Sprintf("✏️ *%s %s signal updated*", signal.Symbol, signal.Type)
+			if notification.Reason != "" {
+				message += "\n\n" + escapeMarkdown(notification.Reason)
+			}
+			lines = formatChanges(notification.Changes, false)
+		}
+		if len(lines) > 0 {
+			message += "\n\n" + escapeMarkdown(strings.Join(lines, "\n"))
+		}
+
+	case models.NotificationEventTargetHit:
+		if notification.Target == nil {
+			return "", fmt.Errorf("target_hit notification for signal %d has no target", signal.ID)
+		}
+		message = fmt.Sprintf(
+			"🎯 *TP%d reached on %s!*\n\n"+
+				"Type: %s\n"+
+				"Gain: %s\n"+
+				"Targets: %s",
+			notification.Target.Position,
+			signal.Symbol,
+			signal.Type,
+			formatTargetGain(signal, notification.Target),
+			formatTargetProgress(signal),
+		)
+
+	case models.NotificationEventClosed:
+		message = fmt.Sprintf(
+			"🏁 *%s %s signal %s*\n\n"+
+				"Exit: %s\n"+
+				"Result: %s",
+			signal.Symbol,
+			signal.Type,
+			formatClosedTitle(signal),
+			formatExitPrice(signal),
+			formatResult(signal),
+		)
+
+	case models.NotificationEventCancelled:
+		message = fmt.Sprintf("🚫 *%s %s signal cancelled*\n\nDo not enter this trade.", signal.Symbol, signal.Type)
+		if notification.Reason != "" {
+			message += "\n\n" + escapeMarkdown(notification.Reason)
+		}
+
+	case models.NotificationEventExpired:
+		message = fmt.Sprintf(
+			"⌛ *%s %s signal expired*\n\n"+
+				"Entry was not reached in time, the setup is no longer valid.",
+			signal.Symbol,
+			signal.Type,
+		)
+
+	default:
+		return "", fmt.Errorf("unknown notification event %q", notification.Event)
+	}
+
+	return message + "\n\nCheck the app for details! 📊", nil
+}
+
 // DiscordNotificationService sends notifications to Discord
 type DiscordNotificationService struct {
 	webhookURL string
 	httpClient *http.Client
+	templates  *NotificationTemplateService
 }
 
-func NewDiscordNotificationService(webhookURL string) *DiscordNotificationService {
+func NewDiscordNotificationService(webhookURL string, templates *NotificationTemplateService) *DiscordNotificationService {
 	return &DiscordNotificationService{
 		webhookURL: webhookURL,
 		httpClient: &http.Client{Timeout: 10 * time.Second},
+		templates:  templates,
 	}
 }
 
-func (s *DiscordNotificationService) SendSignalNotification(signal *models.TradingSignal) error {
-	embed := map[string]interface{}{
-		"title":       fmt.Sprintf("🚨 New %s %s Signal!", signal.AssetClass, signal.DurationType),
-		"description": fmt.Sprintf("A new trading signal has been posted for **%s**", signal.Symbol),
-		"color":       0x00ff00, // Green color
-		"fields": []map[string]interface{}{
-			{
-				"name":   "Asset",
-				"value":  string(signal.Symbol),
-				"inline": true,
-			},
-			{
-				"name":   "Type",
-				"value":  string(signal.Type),
-				"inline": true,
-			},
-			{
-				"name":   "Asset Class",
-				"value":  string(signal.AssetClass),
-				"inline": true,
-			},
-			{
-				"name":   "Duration",
-				"value":  string(signal.DurationType),
-				"inline": true,
-			},
-		},
+// Send posts the embed for a notification's event to the configured webhook. The
+// event's template, if one is saved, replaces the embed's description and title.
+func (s *DiscordNotificationService) Send(notification *models.SignalNotification) error {
+	embed, err := discordEmbed(notification)
+	if err != nil {
+		return err
+	}
+
+	if rendered := s.templates.Render(models.TemplateChannelDiscord, notification); rendered != nil {
+		embed["description"] = rendered.Body
+		if rendered.Title != "" {
+			embed["title"] = rendered.Title
+		}
+	}
+
+	if err := s.sendEmbed(embed); err != nil {
+		return err
+	}
+
+	log.Printf("Discord %s notification sent for signal ID %d", notification.Event, notification.Signal.ID)
+	return nil
+}
+
+// discordEmbed formats a notification as a Discord embed
+func discordEmbed(notification *models.SignalNotification) (map[string]interface{}, error) {
+	signal := notification.Signal
+	field := func(name, value string, inline bool) map[string]interface{} {
+		return map[string]interface{}{"name": name, "value": value, "inline": inline}
+	}
+	categoryFields := []map[string]interface{}{
+		field("Asset Class", string(signal.AssetClass), true),
+		field("Duration", string(signal.DurationType), true),
+	}
+
+	var title, description string
+	var color int
+	var fields []map[string]interface{}
+
+	switch notification.Event {
+	case models.NotificationEventCreated:
+		title = fmt.Sprintf("🚨 New %s %s Signal!", signal.AssetClass, signal.DurationType)
+		description = fmt.Sprintf("A new trading signal has been posted for **%s**", signal.Symbol)
+		color = 0x00ff00 // Green color
+		fields = []map[string]interface{}{
+			field("Asset", signal.Symbol, true),
+			field("Type", string(signal.Type), true),
+			field("Asset Class", string(signal.AssetClass), true),
+			field("Duration", string(signal.DurationType), true),
+			field("Targets", strconv.Itoa(len(signal.Targets)), true),
+			field("Risk/Reward", formatRiskReward(signal), true),
+		}
+
+	case models.NotificationEventUpdated:
+		color = 0x3498db // Blue color
+		if update := notification.Update; update != nil {
+			title = fmt.Sprintf("📝 Update on %s %s", signal.Symbol, signal.Type)
+			description = update.Message
+			for _, action := range formatUpdateActions(update, false) {
+				fields = append(fields, field("Action", action, false))
+			}
+		} else {
+			title = fmt.Sprintf("✏️ %s %s signal updated", signal.Symbol, signal.Type)
+			description = notification.Reason
+			for _, change := range formatChanges(notification.Changes, false) {
+				fields = append(fields, field("Change", change, false))
+			}
+		}
+
+	case models.NotificationEventTargetHit:
+		target := notification.Target
+		if target == nil {
+			return nil, fmt.Errorf("target_hit notification for signal %d has no target", signal.ID)
+		}
+		title = fmt.Sprintf("🎯 TP%d reached on %s!", target.Position, signal.Symbol)
+		description = fmt.Sprintf("**%s** %s signal hit take profit %d", signal.Symbol, signal.Type, target.Position)
+		color = 0x3498db // Blue color
+		fields = append([]map[string]interface{}{
+			field("Gain", formatTargetGain(signal, target), true),
+			field("Targets", formatTargetProgress(signal), false),
+		}, categoryFields...)
+
+	case models.NotificationEventClosed:
+		title = fmt.Sprintf("🏁 %s signal %s", signal.Symbol, formatClosedTitle(signal))
+		description = fmt.Sprintf("The **%s** %s signal has been closed", signal.Symbol, signal.Type)
+		color = 0x95a5a6 // Grey color
+		if signal.Result != nil && *signal.Result == models.SignalResultWin {
+			color = 0x00ff00 // Green color
+		} else if signal.Result != nil && *signal.Result == models.SignalResultLoss {
+			color = 0xe74c3c // Red color
+		}
+		fields = append([]map[string]interface{}{
+			field("Exit", formatExitPrice(signal), true),
+			field("Result", formatResult(signal), true),
+		}, categoryFields...)
+
+	case models.NotificationEventCancelled:
+		title = fmt.Sprintf("🚫 %s signal cancelled", signal.Symbol)
+		description = fmt.Sprintf("The **%s** %s signal has been cancelled, do not enter this trade", signal.Symbol, signal.Type)
+		if notification.Reason != "" {
+			description += "\n\n" + notification.Reason
+		}
+		color = 0xe67e22 // Orange color
+		fields = categoryFields
+
+	case models.NotificationEventExpired:
+		title = fmt.Sprintf("⌛ %s signal expired", signal.Symbol)
+		description = fmt.Sprintf("Entry for the **%s** %s signal was not reached in time, the setup is no longer valid", signal.Symbol, signal.Type)
+		color = 0x95a5a6 // Grey color
+		fields = categoryFields
+
+	default:
+		return nil, fmt.Errorf("unknown notification event %q", notification.Event)
+	}
+
+	if fields == nil {
+		fields = []map[string]interface{}{}
+	}
+
+	return map[string]interface{}{
+		"title":       title,
+		"description": description,
+		"color":       color,
+		"fields":      fields,
 		"footer": map[string]string{
 			"text": "Check the app for full details",
 		},
 		"timestamp": time.Now().Format(time.RFC3339),
-	}
+	}, nil
+}
 
+// sendEmbed posts a single embed to the configured webhook
+func (s *DiscordNotificationService) sendEmbed(embed map[string]interface{}) error {
 	reqBody := map[string]interface{}{
 		"content": "",
 		"embeds":  []interface{}{embed},
@@ -197,28 +607,5 @@ func (s *DiscordNotificationService) SendSignalNotification(signal *models.Tradi
 		return fmt.Errorf("discord API returned status %d", resp.StatusCode)
 	}
 
-	log.Printf("Discord notification sent for signal ID %d", signal.ID)
 	return nil
 }
-
-// ExpoNotificationService is a placeholder for Expo push notifications
-type ExpoNotificationService struct{}
-
-func NewExpoNotificationService() *ExpoNotificationService {
-	return &ExpoNotificationService{}
-}
-
-func (s *ExpoNotificationService) SendSignalNotification(signal *models.TradingSignal) error {
-	// TODO: Implement Expo push notification
-	// This is a placeholder for future implementation
-	log.Printf("[EXPO PLACEHOLDER] Would send notification for signal ID %d", signal.ID)
-	log.Printf("[EXPO PLACEHOLDER] Signal: %s %s - %s", signal.AssetClass, signal.DurationType, signal.Symbol)
-	
-	// When implementing:
-	// 1. Store user Expo push tokens in database
-	// 2. Use Expo SDK or API to send push notifications
-	// 3. Send to users who have active subscriptions for this signal type
-	
-	return nil
-}
-
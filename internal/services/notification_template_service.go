@@ -0,0 +1,563 @@
+package services
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"strings"
+	"sync"
+	"text/template"
+	"text/template/parse"
+	"time"
+	"unicode/utf8"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/repositories"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+// ErrNotificationTemplateNotFound is returned when a channel has no template for an event
+var ErrNotificationTemplateNotFound = errors.New("notification template not found")
+
+// templateCacheTTL is how long templates are cached before they are loaded
+// again, so edits made through other instances apply
+const templateCacheTTL = time.Minute
+
+// Message limits of the channels
+const (
+	telegramMessageLimit    = 4096
+	discordTitleLimit       = 256
+	discordDescriptionLimit = 4096
+	discordEmbedLimit       = 6000 // Title, description, fields and footer together
+)
+
+// Functions appended to every action of a template, escaping the values it prints
+const (
+	telegramEscapeFunc = "escapeMarkdownV2"
+	discordEscapeFunc  = "escapeDiscord"
+)
+
+// TemplateData is what notification templates are executed with. Besides the
+// signal only the fields of the notification's event are set.
+type TemplateData struct {
+	Event  models.NotificationEvent
+	Signal *models.TradingSignal
+	Target *models.SignalTarget // target_hit
+	Update *models.SignalUpdate // updated, when an analyst update was posted
+	Reason string               // updated or cancelled
+
+	// Changes are the edited fields, e.g. "Stop loss updated", and Actions those
+	// taken by an update, e.g. "Stop loss moved". Like the built-in messages they
+	// leave out price levels, as templates are posted to the group channels.
+	Changes []string
+	Actions []string
+
+	// Values formatted like the built-in messages
+	RiskReward     string // "1:2.50"
+	TargetProgress string // "TP1 ✅ | TP2 ⏳"
+	TargetGain     string // "+25.0 pips (0.23%)", target_hit
+	ClosedTitle    string // "TP hit", "SL hit" or "closed"
+	ExitPrice      string // "n/a" unless closed
+	Result         string // "WIN (+2.35%)", "n/a" unless closed
+}
+
+// newTemplateData prepares the data templates are executed with for a notification
+func newTemplateData(notification *models.SignalNotification) *TemplateData {
+	signal := notification.Signal
+	data := &TemplateData{
+		Event:          notification.Event,
+		Signal:         signal,
+		Target:         notification.Target,
+		Update:         notification.Update,
+		Reason:         notification.Reason,
+		Changes:        formatChanges(notification.Changes, false),
+		RiskReward:     formatRiskReward(signal),
+		TargetProgress: formatTargetProgress(signal),
+		ClosedTitle:    formatClosedTitle(signal),
+		ExitPrice:      formatExitPrice(signal),
+		Result:         formatResult(signal),
+	}
+	if notification.Update != nil {
+		data.Actions = formatUpdateActions(notification.Update, false)
+	}
+	if notification.Target != nil {
+		data.TargetGain = formatTargetGain(signal, notification.Target)
+	}
+	return data
+}
+
+// templateFuncs are the functions templates may call, along with the escape
+// functions added to their actions
+var templateFuncs = template.FuncMap{
+	"price": formatPrice,
+	"join":  strings.Join,
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+
+	telegramEscapeFunc: func(value interface{}) string { return markdownV2Escaper.Replace(templateString(value)) },
+	discordEscapeFunc:  func(value interface{}) string { return discordEscaper.Replace(templateString(value)) },
+}
+
+// markdownV2Escaper escapes every character with a meaning in Telegram's MarkdownV2
+var markdownV2Escaper = strings.NewReplacer(
+	`\`, `\\`, "_", `\_`, "*", `\*`, "[", `\[`, "]", `\]`, "(", `\(`, ")", `\)`, "~", `\~`, "`", "\\`",
+	">", `\>`, "#", `\#`, "+", `\+`, "-", `\-`, "=", `\=`, "|", `\|`, "{", `\{`, "}", `\}`, ".", `\.`, "!", `\!`,
+)
+
+// discordEscaper escapes the characters with a meaning in Discord's markdown
+var discordEscaper = strings.NewReplacer(`\`, `\\`, "*", `\*`, "_", `\_`, "~", `\~`, "`", "\\`", "|", `\|`, ">", `\>`)
+
+// markdownV2Markers are the characters formatting Telegram templates: *bold*,
+// _italic_, __underline__, ~strikethrough~ and `code`
+const markdownV2Markers = "*_~`"
+
+// templateString renders a value printed by a template: pointers are followed,
+// nil prints nothing, prices print without trailing zeros and times in UTC
+func templateString(value interface{}) string {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return ""
+	}
+	if v.Kind() == reflect.Float64 {
+		return formatPrice(v.Float())
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.UTC().Format("2006-01-02 15:04 UTC")
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// escapeTelegramText escapes the text written in a Telegram template, keeping the
+// formatting markers and characters the author escaped
+func escapeTelegramText(text string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case strings.ContainsRune(markdownV2Markers, r):
+		case strings.ContainsRune("[]()>#+-=|{}.!", r):
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// checkMarkdownV2 reports formatting markers left open in a Telegram message
+func checkMarkdownV2(text string) error {
+	counts := make(map[rune]int)
+	escaped := false
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case strings.ContainsRune(markdownV2Markers, r):
+			counts[r]++
+		}
+	}
+	for _, marker := range markdownV2Markers {
+		if counts[marker]%2 != 0 {
+			return fmt.Errorf("unbalanced %c: formatting markers must come in pairs, escape literal ones with \\", marker)
+		}
+	}
+	return nil
+}
+
+// truncate shortens text to limit characters, ending it with an ellipsis
+func truncate(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:limit-1]) + "…"
+}
+
+// parseTemplate parses a template of channel. Every value it prints is escaped
+// for the channel, and on Telegram its own text is escaped as well except for
+// formatting markers.
+func parseTemplate(channel models.TemplateChannel, name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	escapeFunc := discordEscapeFunc
+	if channel == models.TemplateChannelTelegram {
+		escapeFunc = telegramEscapeFunc
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			escapeTemplateNode(t.Tree.Root, escapeFunc, channel == models.TemplateChannelTelegram)
+		}
+	}
+	return tmpl, nil
+}
+
+// escapeTemplateNode appends escapeFunc to the actions printing a value under
+// node, escaping its text too if escapeText is set
+func escapeTemplateNode(node parse.Node, escapeFunc string, escapeText bool) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			escapeTemplateNode(child, escapeFunc, escapeText)
+		}
+	case *parse.ActionNode:
+		// Declarations and assignments print nothing
+		if len(n.Pipe.Decl) == 0 {
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+				NodeType: parse.NodeCommand,
+				Pos:      n.Pos,
+				Args:     []parse.Node{parse.NewIdentifier(escapeFunc).SetPos(n.Pos)},
+			})
+		}
+	case *parse.TextNode:
+		if escapeText {
+			n.Text = []byte(escapeTelegramText(string(n.Text)))
+		}
+	case *parse.IfNode:
+		escapeTemplateNode(n.List, escapeFunc, escapeText)
+		escapeTemplateNode(n.ElseList, escapeFunc, escapeText)
+	case *parse.RangeNode:
+		escapeTemplateNode(n.List, escapeFunc, escapeText)
+		escapeTemplateNode(n.ElseList, escapeFunc, escapeText)
+	case *parse.WithNode:
+		escapeTemplateNode(n.List, escapeFunc, escapeText)
+		escapeTemplateNode(n.ElseList, escapeFunc, escapeText)
+	}
+}
+
+// compiledTemplate is a parsed notification template
+type compiledTemplate struct {
+	channel models.TemplateChannel
+	title   *template.Template // Nil to keep the built-in title
+	body    *template.Template
+}
+
+// compileTemplate parses the title and body of a template, returning the
+// errors of each
+func compileTemplate(channel models.TemplateChannel, update *models.NotificationTemplateUpdate) (*compiledTemplate, utils.ValidationErrors) {
+	var errs utils.ValidationErrors
+	compiled := &compiledTemplate{channel: channel}
+
+	if update.Title != nil {
+		if channel != models.TemplateChannelDiscord {
+			errs = append(errs, utils.FieldError{Field: "title", Message: "title is only used by Discord templates"})
+		} else if title, err := parseTemplate(channel, "title", *update.Title); err != nil {
+			errs = append(errs, utils.FieldError{Field: "title", Message: err.Error()})
+		} else {
+			compiled.title = title
+		}
+	}
+
+	body, err := parseTemplate(channel, "body", update.Body)
+	if err != nil {
+		errs = append(errs, utils.FieldError{Field: "body", Message: err.Error()})
+	}
+	compiled.body = body
+
+	return compiled, errs
+}
+
+// render executes the template for a notification, applying the channel's
+// limits. It fails if the message would be rejected by the channel: a Telegram
+// message with unbalanced formatting or over the size limit, or a Discord embed
+// whose fields leave no room for the description.
+func (t *compiledTemplate) render(notification *models.SignalNotification) (*models.RenderedNotification, error) {
+	data := newTemplateData(notification)
+
+	var body bytes.Buffer
+	if err := t.body.Execute(&body, data); err != nil {
+		return nil, err
+	}
+	rendered := &models.RenderedNotification{Body: strings.TrimSpace(body.String())}
+
+	switch t.channel {
+	case models.TemplateChannelTelegram:
+		rendered.ParseMode = "MarkdownV2"
+		if err := checkMarkdownV2(rendered.Body); err != nil {
+			return nil, err
+		}
+		if utf8.RuneCountInString(rendered.Body) > telegramMessageLimit {
+			return nil, fmt.Errorf("body renders more than the %d characters of a Telegram message", telegramMessageLimit)
+		}
+	case models.TemplateChannelDiscord:
+		if t.title != nil {
+			var title bytes.Buffer
+			if err := t.title.Execute(&title, data); err != nil {
+				return nil, err
+			}
+			rendered.Title = truncate(strings.TrimSpace(title.String()), discordTitleLimit)
+		}
+
+		// The description gets what the rest of the embed leaves of its limit
+		embed, err := discordEmbed(notification)
+		if err != nil {
+			return nil, err
+		}
+		if rendered.Title != "" {
+			embed["title"] = rendered.Title
+		}
+		embed["description"] = ""
+		room := min(discordDescriptionLimit, discordEmbedLimit-discordEmbedLength(embed))
+		if room <= 0 {
+			return nil, fmt.Errorf("the embed exceeds the %d characters Discord allows", discordEmbedLimit)
+		}
+		rendered.Body = truncate(rendered.Body, room)
+	}
+	return rendered, nil
+}
+
+// discordEmbedLength counts the characters of an embed built by discordEmbed
+// that count towards discordEmbedLimit
+func discordEmbedLength(embed map[string]interface{}) int {
+	length := 0
+	for _, key := range []string{"title", "description"} {
+		if text, ok := embed[key].(string); ok {
+			length += utf8.RuneCountInString(text)
+		}
+	}
+	if fields, ok := embed["fields"].([]map[string]interface{}); ok {
+		for _, field := range fields {
+			for _, key := range []string{"name", "value"} {
+				if text, ok := field[key].(string); ok {
+					length += utf8.RuneCountInString(text)
+				}
+			}
+		}
+	}
+	if footer, ok := embed["footer"].(map[string]string); ok {
+		length += utf8.RuneCountInString(footer["text"])
+	}
+	return length
+}
+
+// templateKey identifies the template of a channel for an event
+type templateKey struct {
+	channel models.TemplateChannel
+	event   models.NotificationEvent
+}
+
+// NotificationTemplateService manages the templates replacing the built-in
+// Telegram messages and Discord embed texts, and renders them for notifications.
+// Events without a template keep the built-in message.
+type NotificationTemplateService struct {
+	repo *repositories.NotificationTemplateRepository
+
+	mu        sync.Mutex
+	templates map[templateKey]*compiledTemplate
+	loadedAt  time.Time
+}
+
+func NewNotificationTemplateService(repo *repositories.NotificationTemplateRepository) *NotificationTemplateService {
+	return &NotificationTemplateService{repo: repo}
+}
+
+// Render renders the template of a channel for a notification. It returns nil
+// when the channel has no template for the event, or its template fails, so the
+// built-in message is sent instead.
+func (s *NotificationTemplateService) Render(channel models.TemplateChannel, notification *models.SignalNotification) *models.RenderedNotification {
+	compiled := s.lookup(templateKey{channel: channel, event: notification.Event})
+	if compiled == nil {
+		return nil
+	}
+
+	rendered, err := compiled.render(notification)
+	if err != nil {
+		log.Printf("Failed to render %s %s template for signal ID %d, using the built-in message: %v",
+			channel, notification.Event, notification.Signal.ID, err)
+		return nil
+	}
+	return rendered
+}
+
+// lookup returns the compiled template of key, loading the templates again once
+// the cache expired
+func (s *NotificationTemplateService) lookup(key templateKey) *compiledTemplate {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if time.Since(s.loadedAt) > templateCacheTTL {
+		// Failures keep the templates loaded before and are retried after the TTL
+		s.loadedAt = time.Now()
+		if err := s.load(); err != nil {
+			log.Printf("Failed to load notification templates: %v", err)
+		}
+	}
+	return s.templates[key]
+}
+
+// load compiles the stored templates, replacing the cached ones
+func (s *NotificationTemplateService) load() error {
+	stored, err := s.repo.GetAll()
+	if err != nil {
+		return err
+	}
+
+	templates := make(map[templateKey]*compiledTemplate, len(stored))
+	for i := range stored {
+		t := &stored[i]
+		compiled, errs := compileTemplate(t.Channel, &models.NotificationTemplateUpdate{Title: t.Title, Body: t.Body})
+		if len(errs) > 0 {
+			log.Printf("Skipping invalid %s %s notification template: %v", t.Channel, t.Event, errs)
+			continue
+		}
+		templates[templateKey{channel: t.Channel, event: t.Event}] = compiled
+	}
+	s.templates = templates
+	return nil
+}
+
+// invalidate makes the next render load the templates again
+func (s *NotificationTemplateService) invalidate() {
+	s.mu.Lock()
+	s.loadedAt = time.Time{}
+	s.mu.Unlock()
+}
+
+// GetAll retrieves every stored template
+func (s *NotificationTemplateService) GetAll() ([]models.NotificationTemplate, error) {
+	return s.repo.GetAll()
+}
+
+// Get retrieves the template of a channel for an event
+func (s *NotificationTemplateService) Get(channel models.TemplateChannel, event models.NotificationEvent) (*models.NotificationTemplate, error) {
+	stored, err := s.repo.Get(channel, event)
+	if err != nil {
+		return nil, err
+	}
+	if stored == nil {
+		return nil, ErrNotificationTemplateNotFound
+	}
+	return stored, nil
+}
+
+// Save stores the template of a channel for an event once it is valid
+func (s *NotificationTemplateService) Save(draft *models.NotificationTemplateDraft, adminID int64) (*models.NotificationTemplate, error) {
+	if _, err := s.Preview(draft); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.repo.Upsert(draft.Channel, draft.Event, &draft.NotificationTemplateUpdate, adminID)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return saved, nil
+}
+
+// Delete removes the template of a channel for an event, restoring the built-in message
+func (s *NotificationTemplateService) Delete(channel models.TemplateChannel, event models.NotificationEvent) error {
+	deleted, err := s.repo.Delete(channel, event)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrNotificationTemplateNotFound
+	}
+	s.invalidate()
+	return nil
+}
+
+// Preview renders a template against a sample signal. It returns
+// utils.ValidationErrors if the template does not parse, fails for any of the
+// event's sample notifications or renders a message the channel rejects.
+func (s *NotificationTemplateService) Preview(draft *models.NotificationTemplateDraft) (*models.RenderedNotification, error) {
+	compiled, errs := compileTemplate(draft.Channel, &draft.NotificationTemplateUpdate)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	var preview *models.RenderedNotification
+	for _, notification := range sampleNotifications(draft.Event) {
+		rendered, err := compiled.render(notification)
+		if err != nil {
+			return nil, utils.ValidationErrors{{Field: "body", Message: err.Error()}}
+		}
+		if rendered.Body == "" {
+			return nil, utils.ValidationErrors{{Field: "body", Message: "body renders an empty message"}}
+		}
+		if preview == nil {
+			preview = rendered
+		}
+	}
+	return preview, nil
+}
+
+// sampleNotifications returns the notifications templates of an event are
+// checked against, the one previewed first. Edits are announced both with and
+// without an analyst update.
+func sampleNotifications(event models.NotificationEvent) []*models.SignalNotification {
+	hitAt := time.Now().Add(-time.Hour)
+	comments := "Breakout above the weekly range, targeting the previous highs."
+	signal := &models.TradingSignal{
+		ID:              1,
+		Symbol:          "EURUSD",
+		AssetClass:      models.AssetClassForex,
+		DurationType:    models.DurationTypeShortTerm,
+		Type:            models.SignalTypeLong,
+		Status:          models.SignalStatusActive,
+		EntryPrice:      1.085,
+		StopLossPrice:   1.08,
+		TakeProfitPrice: 1.095,
+		Comments:        &comments,
+		Targets: []models.SignalTarget{
+			{ID: 1, SignalID: 1, Position: 1, Price: 1.09, AllocationPercent: 50, HitAt: &hitAt},
+			{ID: 2, SignalID: 1, Position: 2, Price: 1.095, AllocationPercent: 50},
+		},
+	}
+	notification := &models.SignalNotification{Event: event, Signal: signal}
+
+	switch event {
+	case models.NotificationEventUpdated:
+		stopLoss, closePercent, closePrice := 1.085, 50.0, 1.09
+		notification.Update = &models.SignalUpdate{
+			ID:           1,
+			SignalID:     1,
+			Message:      "TP1 reached, moving the stop loss to breakeven and taking half off.",
+			NewStopLoss:  &stopLoss,
+			ClosePercent: &closePercent,
+			ClosePrice:   &closePrice,
+			CreatedAt:    time.Now(),
+		}
+		edit := &models.SignalNotification{
+			Event:   event,
+			Signal:  signal,
+			Reason:  "Entry adjusted to the retest of the breakout level.",
+			Changes: map[string]models.FieldChange{"entry_price": {From: 1.086, To: 1.085}},
+		}
+		return []*models.SignalNotification{notification, edit}
+	case models.NotificationEventTargetHit:
+		notification.Target = &signal.Targets[0]
+	case models.NotificationEventClosed:
+		exitPrice, result, ret := 1.095, models.SignalResultWin, 0.69
+		signal.Status = models.SignalStatusTPHit
+		signal.ExitPrice = &exitPrice
+		signal.Result = &result
+		signal.Return = &ret
+		signal.Targets[1].HitAt = &hitAt
+	case models.NotificationEventCancelled:
+		signal.Status = models.SignalStatusCancelled
+		notification.Reason = "The news release changed the outlook."
+	case models.NotificationEventExpired:
+		signal.Status = models.SignalStatusExpired
+		signal.Targets[0].HitAt = nil
+	}
+	return []*models.SignalNotification{notification}
+}
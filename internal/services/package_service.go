@@ -1,12 +1,15 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
 	"github.com/omarshah0/rest-api-with-social-auth/internal/repositories"
 )
 
+var ErrPackageNotFound = errors.New("package not found")
+
 type PackageService struct {
 	repo *repositories.PackageRepository
 }
@@ -17,6 +20,9 @@ func NewPackageService(repo *repositories.PackageRepository) *PackageService {
 
 // Create creates a new package
 func (s *PackageService) Create(pkg *models.PackageCreate) (*models.Package, error) {
+	if pkg.Tier == "" {
+		pkg.Tier = models.PackageTierStandard
+	}
 	return s.repo.Create(pkg)
 }
 
@@ -27,7 +33,7 @@ func (s *PackageService) GetByID(id int64) (*models.Package, error) {
 		return nil, err
 	}
 	if pkg == nil {
-		return nil, fmt.Errorf("package not found")
+		return nil, ErrPackageNotFound
 	}
 	return pkg, nil
 }
@@ -45,25 +45,28 @@ func (s *PaymentService) GetByID(id int64) (*models.Payment, error) {
 	return payment, nil
 }
 
-// GetByUserID retrieves all payments for a user with package details
-func (s *PaymentService) GetByUserID(userID int64, limit, offset int) ([]models.PaymentWithPackage, error) {
-	if limit <= 0 {
-		limit = 50
-	}
-	if limit > 100 {
-		limit = 100
-	}
+// GetByUserID retrieves a page of payments for a user with package details,
+// along with the cursor of the next page
+func (s *PaymentService) GetByUserID(userID int64, page models.PageRequest) ([]models.PaymentWithPackage, string, error) {
+	page.Normalize()
 
-	payments, err := s.paymentRepo.GetByUserID(userID, limit, offset)
+	payments, err := s.paymentRepo.GetByUserID(userID, page.Lookahead())
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(payments) > page.Limit {
+		payments = payments[:page.Limit]
+		last := payments[page.Limit-1]
+		nextCursor = models.PageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
 	}
 
 	var result []models.PaymentWithPackage
 	for _, payment := range payments {
 		pkg, err := s.packageRepo.GetByID(payment.PackageID)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		result = append(result, models.PaymentWithPackage{
@@ -72,7 +75,7 @@ func (s *PaymentService) GetByUserID(userID int64, limit, offset int) ([]models.
 		})
 	}
 
-	return result, nil
+	return result, nextCursor, nil
 }
 
 // GetByTransactionID retrieves a payment by transaction ID
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/repositories"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+var ErrPushTokenNotFound = errors.New("push token not found")
+
+// PushTokenService manages the Expo push tokens of users' devices
+type PushTokenService struct {
+	repo *repositories.PushTokenRepository
+}
+
+func NewPushTokenService(repo *repositories.PushTokenRepository) *PushTokenService {
+	return &PushTokenService{repo: repo}
+}
+
+// Register stores the push token of a user's device, replacing the device's previous one
+func (s *PushTokenService) Register(userID int64, register *models.PushTokenRegister) (*models.PushToken, error) {
+	register.Token = strings.TrimSpace(register.Token)
+	register.DeviceID = strings.TrimSpace(register.DeviceID)
+	if !isExpoPushToken(register.Token) {
+		return nil, utils.ValidationErrors{{Field: "token", Message: "token must be an Expo push token, e.g. ExponentPushToken[...]"}}
+	}
+
+	return s.repo.Register(userID, register)
+}
+
+// Unregister removes the push token of a user's device, e.g. on logout
+func (s *PushTokenService) Unregister(userID int64, deviceID string) error {
+	removed, err := s.repo.Unregister(userID, deviceID)
+	if err != nil {
+		return err
+	}
+	if !removed {
+		return ErrPushTokenNotFound
+	}
+	return nil
+}
+
+// isExpoPushToken reports whether token has the shape of an Expo push token
+func isExpoPushToken(token string) bool {
+	if !strings.HasSuffix(token, "]") {
+		return false
+	}
+	for _, prefix := range []string{"ExponentPushToken[", "ExpoPushToken["} {
+		if strings.HasPrefix(token, prefix) && len(token) > len(prefix)+1 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// SignalExpirer expires pending signals whose valid_until has passed. It is used
+// when no price feed is configured; with a feed the SignalResolver expires
+// signals based on the prices it has seen.
+type SignalExpirer struct {
+	signalService *TradingSignalService
+	interval      time.Duration
+}
+
+func NewSignalExpirer(signalService *TradingSignalService, interval time.Duration) *SignalExpirer {
+	return &SignalExpirer{
+		signalService: signalService,
+		interval:      interval,
+	}
+}
+
+// Start runs the expirer until ctx is cancelled
+func (e *SignalExpirer) Start(ctx context.Context) {
+	log.Printf("Signal expirer started (every %s)", e.interval)
+
+	ticker := time.NewTicker(e.interval)
+	defer ticker.Stop()
+
+	for {
+		expired, err := e.signalService.ExpireDue(time.Now())
+		if err != nil {
+			log.Printf("Signal expirer run failed: %v", err)
+		} else if expired > 0 {
+			log.Printf("Expired %d signals", expired)
+		}
+
+		select {
+		case <-ctx.Done():
+			log.Println("Signal expirer stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// publishBatchSize is the number of due signals claimed per query
+const publishBatchSize = 50
+
+// SignalPublisher publishes scheduled signals once their publish time passes and
+// notifies delayed package tiers once their release time passes. Schedules are
+// stored in the database, so nothing is lost on restart, and both are claimed
+// atomically so several instances can run it side by side.
+type SignalPublisher struct {
+	signalService *TradingSignalService
+	interval      time.Duration
+}
+
+func NewSignalPublisher(signalService *TradingSignalService, interval time.Duration) *SignalPublisher {
+	return &SignalPublisher{
+		signalService: signalService,
+		interval:      interval,
+	}
+}
+
+// Start runs the publisher until ctx is cancelled. Signals that fell due while
+// no instance was running are published on the first run.
+func (p *SignalPublisher) Start(ctx context.Context) {
+	log.Printf("Signal publisher started (every %s)", p.interval)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.PublishDue(ctx)
+		p.ReleaseDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			log.Println("Signal publisher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PublishDue publishes every scheduled signal that is due, in batches
+func (p *SignalPublisher) PublishDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		published, err := p.signalService.PublishDue(publishBatchSize)
+		if err != nil {
+			log.Printf("Signal publisher run failed: %v", err)
+			return
+		}
+		if published > 0 {
+			log.Printf("Published %d scheduled signals", published)
+		}
+		if published < publishBatchSize {
+			return
+		}
+	}
+}
+
+// ReleaseDue notifies every delayed tier release that is due, in batches
+func (p *SignalPublisher) ReleaseDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		released, err := p.signalService.ReleaseDue(publishBatchSize)
+		if err != nil {
+			log.Printf("Tier release run failed: %v", err)
+			return
+		}
+		if released > 0 {
+			log.Printf("Notified %d delayed tier releases", released)
+		}
+		if released < publishBatchSize {
+			return
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/marketdata"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+// SignalResolver checks open signals against a price feed and moves them through
+// their lifecycle: pending signals are activated when entry trades or expire when
+// it does not trade in time, active signals hit their targets or stop loss.
+type SignalResolver struct {
+	signalService *TradingSignalService
+	feed          marketdata.PriceFeed
+	interval      time.Duration
+	lastFetch     time.Time // Zero until the first fetch, so the feed's first candle is seen
+	lastClose     map[string]float64
+}
+
+func NewSignalResolver(signalService *TradingSignalService, feed marketdata.PriceFeed, interval time.Duration) *SignalResolver {
+	return &SignalResolver{
+		signalService: signalService,
+		feed:          feed,
+		interval:      interval,
+		lastClose:     make(map[string]float64),
+	}
+}
+
+// Start runs the resolver until ctx is cancelled
+func (r *SignalResolver) Start(ctx context.Context) {
+	log.Printf("Signal resolver started using %s feed (every %s)", r.feed.Name(), r.interval)
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Println("Signal resolver stopped")
+			return
+		case <-ticker.C:
+			if err := r.ResolveOnce(ctx); err != nil {
+				log.Printf("Signal resolver run failed: %v", err)
+			}
+		}
+	}
+}
+
+// ResolveOnce fetches new prices for all open signals and applies any resulting transitions
+func (r *SignalResolver) ResolveOnce(ctx context.Context) error {
+	signals, err := r.signalService.GetOpenSignals()
+	if err != nil {
+		return fmt.Errorf("failed to load open signals: %w", err)
+	}
+	// Nothing is fetched, so the feed's clock stays where it was
+	if len(signals) == 0 {
+		return nil
+	}
+
+	symbols := make([]string, 0, len(signals))
+	for i := range signals {
+		symbols = append(symbols, signals[i].Symbol)
+	}
+
+	candles, err := r.feed.Fetch(ctx, symbols, r.lastFetch)
+	if err != nil {
+		return fmt.Errorf("failed to fetch prices from %s feed: %w", r.feed.Name(), err)
+	}
+
+	bySymbol := make(map[string][]marketdata.Candle)
+	for _, candle := range candles {
+		bySymbol[candle.Symbol] = append(bySymbol[candle.Symbol], candle)
+		if candle.Time.After(r.lastFetch) {
+			r.lastFetch = candle.Time
+		}
+	}
+
+	for i := range signals {
+		signal := &signals[i]
+		for _, candle := range bySymbol[marketdata.NormalizeSymbol(signal.Symbol)] {
+			if !r.apply(signal, candle) {
+				break
+			}
+		}
+	}
+
+	for symbol, symbolCandles := range bySymbol {
+		r.lastClose[symbol] = symbolCandles[len(symbolCandles)-1].Close
+	}
+
+	return r.expire()
+}
+
+// expire marks pending signals as expired once the feed has delivered prices past
+// their validity without reaching entry. Using the feed's clock rather than the
+// wall clock means a delayed feed cannot expire a setup that was in fact triggered.
+func (r *SignalResolver) expire() error {
+	expired, err := r.signalService.ExpireDue(r.lastFetch)
+	if err != nil {
+		return err
+	}
+	if expired > 0 {
+		log.Printf("Signal resolver expired %d signals", expired)
+	}
+	return nil
+}
+
+// apply evaluates a single candle against a signal. It returns false once the
+// signal is closed or can no longer be processed.
+func (r *SignalResolver) apply(signal *models.TradingSignal, candle marketdata.Candle) bool {
+	note := fmt.Sprintf("Resolved automatically by %s feed", r.feed.Name())
+
+	switch signal.Status {
+	case models.SignalStatusPending:
+		// Scheduled signals only start watching for entry once published
+		listedAt := signal.CreatedAt
+		if signal.PublishedAt != nil {
+			listedAt = *signal.PublishedAt
+		}
+		if !candle.Time.After(listedAt) || !r.reaches(candle, signal.EntryPrice) {
+			return true
+		}
+		// Entry reached after the setup expired does not count
+		if signal.ValidUntil != nil && candle.Time.After(*signal.ValidUntil) {
+			return false
+		}
+		updated, err := r.signalService.TransitionStatus(signal.ID, &models.SignalStatusUpdate{
+			Status: models.SignalStatusActive,
+			Price:  &signal.EntryPrice,
+			Note:   &note,
+		}, nil)
+		if err != nil {
+			r.logFailure(signal, err)
+			return false
+		}
+		*signal = *updated
+		return true
+
+	case models.SignalStatusActive:
+		if signal.ActivatedAt != nil && !candle.Time.After(*signal.ActivatedAt) {
+			return true
+		}
+
+		// When stop loss and a target fall inside the same candle the order is unknown;
+		// assume the stop loss traded first.
+		if r.stopLossHit(signal, candle) {
+			_, err := r.signalService.TransitionStatus(signal.ID, &models.SignalStatusUpdate{
+				Status: models.SignalStatusSLHit,
+				Price:  &signal.StopLossPrice,
+				Note:   &note,
+			}, nil)
+			if err != nil {
+				r.logFailure(signal, err)
+			}
+			return false
+		}
+
+		for _, target := range signal.Targets {
+			if target.IsHit() || !r.targetHit(signal, target, candle) {
+				continue
+			}
+			updated, err := r.signalService.MarkTargetHit(signal.ID, target.Position, nil)
+			if err != nil {
+				r.logFailure(signal, err)
+				return false
+			}
+			*signal = *updated
+			if signal.Status != models.SignalStatusActive {
+				return false
+			}
+		}
+		return true
+
+	default:
+		return false
+	}
+}
+
+// reaches reports whether price traded through level, using the previous close
+// so that gaps between ticks still count as crossing the level
+func (r *SignalResolver) reaches(candle marketdata.Candle, level float64) bool {
+	if candle.Touches(level) {
+		return true
+	}
+	previous, ok := r.lastClose[candle.Symbol]
+	if !ok {
+		return false
+	}
+	return (previous <= level && candle.High >= level) || (previous >= level && candle.Low <= level)
+}
+
+func (r *SignalResolver) stopLossHit(signal *models.TradingSignal, candle marketdata.Candle) bool {
+	if signal.Type == models.SignalTypeLong {
+		return candle.Low <= signal.StopLossPrice
+	}
+	return candle.High >= signal.StopLossPrice
+}
+
+func (r *SignalResolver) targetHit(signal *models.TradingSignal, target models.SignalTarget, candle marketdata.Candle) bool {
+	if signal.Type == models.SignalTypeLong {
+		return candle.High >= target.Price
+	}
+	return candle.Low <= target.Price
+}
+
+func (r *SignalResolver) logFailure(signal *models.TradingSignal, err error) {
+	// Another instance or an admin may have moved the signal first
+	if errors.Is(err, ErrInvalidStatusTransition) {
+		log.Printf("Signal resolver skipped signal %d: %v", signal.ID, err)
+		return
+	}
+	log.Printf("Signal resolver failed for signal %d: %v", signal.ID, err)
+}
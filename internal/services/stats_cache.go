@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/database"
+	"github.com/redis/go-redis/v9"
+)
+
+const statsVersionKey = "stats:version"
+
+// StatsCache caches computed statistics in Redis. Cache keys include a version
+// number that is bumped whenever signals change, so one write invalidates every
+// cached report without scanning for keys.
+type StatsCache struct {
+	redisDB *database.RedisDB
+	ttl     time.Duration
+}
+
+func NewStatsCache(redisDB *database.RedisDB, ttl time.Duration) *StatsCache {
+	return &StatsCache{
+		redisDB: redisDB,
+		ttl:     ttl,
+	}
+}
+
+// Get loads a cached report into dest and reports whether it was found
+func (c *StatsCache) Get(ctx context.Context, key string, dest interface{}) bool {
+	versionedKey, err := c.versionedKey(ctx, key)
+	if err != nil {
+		return false
+	}
+
+	cached, err := c.redisDB.Get(ctx, versionedKey)
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal([]byte(cached), dest) == nil
+}
+
+// Set stores a report. Failures are logged as the cache is only an optimisation.
+func (c *StatsCache) Set(ctx context.Context, key string, value interface{}) {
+	versionedKey, err := c.versionedKey(ctx, key)
+	if err != nil {
+		log.Printf("Failed to cache stats %s: %v", key, err)
+		return
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		log.Printf("Failed to cache stats %s: %v", key, err)
+		return
+	}
+	if err := c.redisDB.Set(ctx, versionedKey, data, c.ttl); err != nil {
+		log.Printf("Failed to cache stats %s: %v", key, err)
+	}
+}
+
+// Invalidate discards every cached report
+func (c *StatsCache) Invalidate() {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if _, err := c.redisDB.Incr(ctx, statsVersionKey); err != nil {
+		log.Printf("Failed to invalidate stats cache: %v", err)
+	}
+}
+
+func (c *StatsCache) versionedKey(ctx context.Context, key string) (string, error) {
+	version, err := c.redisDB.Get(ctx, statsVersionKey)
+	if err == redis.Nil {
+		version = "0"
+	} else if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("stats:v%s:%s", version, key), nil
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/repositories"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/stats"
+)
+
+type StatsService struct {
+	repo        *repositories.StatsRepository
+	packageRepo *repositories.PackageRepository
+	cache       *StatsCache
+}
+
+func NewStatsService(repo *repositories.StatsRepository, packageRepo *repositories.PackageRepository, cache *StatsCache) *StatsService {
+	return &StatsService{
+		repo:        repo,
+		packageRepo: packageRepo,
+		cache:       cache,
+	}
+}
+
+// GetPerformance returns the track record grouped by asset class and duration
+func (s *StatsService) GetPerformance(ctx context.Context, filter *models.StatsFilter) (*models.PerformanceReport, error) {
+	cacheKey := "performance:" + statsFilterKey(filter)
+
+	var report models.PerformanceReport
+	if s.cache.Get(ctx, cacheKey, &report) {
+		return &report, nil
+	}
+
+	signals, err := s.getSignals(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report = models.PerformanceReport{
+		StatsFilter: *filter,
+		Overall:     stats.Performance(signals),
+		Groups:      stats.PerformanceByGroup(signals),
+		GeneratedAt: time.Now(),
+	}
+
+	s.cache.Set(ctx, cacheKey, &report)
+	return &report, nil
+}
+
+// GetEquityCurve returns the cumulative return series of closed signals with
+// drawdown figures and monthly returns
+func (s *StatsService) GetEquityCurve(ctx context.Context, filter *models.StatsFilter, interval string) (*models.EquityCurve, error) {
+	cacheKey := fmt.Sprintf("equity:%s:%s", interval, statsFilterKey(filter))
+
+	var curve models.EquityCurve
+	if s.cache.Get(ctx, cacheKey, &curve) {
+		return &curve, nil
+	}
+
+	signals, err := s.getSignals(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	end := time.Now()
+	if filter.To != nil && filter.To.Before(end) {
+		end = *filter.To
+	}
+
+	curve = stats.Equity(signals, interval, end)
+	curve.StatsFilter = *filter
+	curve.GeneratedAt = time.Now()
+
+	s.cache.Set(ctx, cacheKey, &curve)
+	return &curve, nil
+}
+
+// getSignals loads the signals matching filter, narrowing a package filter to
+// the asset class and duration the package covers
+func (s *StatsService) getSignals(filter *models.StatsFilter) ([]models.TradingSignal, error) {
+	query := *filter
+	if filter.PackageID != nil {
+		pkg, err := s.packageRepo.GetByID(*filter.PackageID)
+		if err != nil {
+			return nil, err
+		}
+		if pkg == nil {
+			return nil, ErrPackageNotFound
+		}
+		query.AssetClass = &pkg.AssetClass
+		query.DurationType = &pkg.DurationType
+	}
+	return s.repo.GetSignals(&query)
+}
+
+// statsFilterKey identifies a filter in cache keys
+func statsFilterKey(filter *models.StatsFilter) string {
+	key := fmt.Sprintf("%s:%s", formatStatsBound(filter.From), formatStatsBound(filter.To))
+	if filter.AssetClass != nil {
+		key += ":asset_class=" + string(*filter.AssetClass)
+	}
+	if filter.DurationType != nil {
+		key += ":duration_type=" + string(*filter.DurationType)
+	}
+	if filter.PackageID != nil {
+		key += fmt.Sprintf(":package=%d", *filter.PackageID)
+	}
+	return key
+}
+
+func formatStatsBound(bound *time.Time) string {
+	if bound == nil {
+		return "-"
+	}
+	return bound.UTC().Format(time.RFC3339)
+}
@@ -14,6 +14,7 @@ type SubscriptionService struct {
 	paymentRepo      *repositories.PaymentRepository
 	emailService     *EmailService
 	userRepo         *repositories.UserRepository
+	memberships      *ChannelMembershipService
 }
 
 func NewSubscriptionService(
@@ -22,6 +23,7 @@ func NewSubscriptionService(
 	paymentRepo *repositories.PaymentRepository,
 	emailService *EmailService,
 	userRepo *repositories.UserRepository,
+	memberships *ChannelMembershipService,
 ) *SubscriptionService {
 	return &SubscriptionService{
 		subscriptionRepo: subscriptionRepo,
@@ -29,6 +31,7 @@ func NewSubscriptionService(
 		paymentRepo:      paymentRepo,
 		emailService:     emailService,
 		userRepo:         userRepo,
+		memberships:      memberships,
 	}
 }
 
@@ -98,6 +101,9 @@ func (s *SubscriptionService) Subscribe(userID int64, packageIDs []int64) (*mode
 		}
 	}
 
+	// Invite to the private channels of the packages once the reconciler gets to it
+	s.memberships.QueueSync(userID, models.MembershipTriggerSubscribe)
+
 	return &models.SubscribeResponse{
 		Subscriptions: subscriptions,
 		TotalAmount:   totalAmount,
@@ -149,18 +155,28 @@ func (s *SubscriptionService) GetActiveSubscriptions(userID int64) ([]models.Sub
 	return result, nil
 }
 
-// GetAllSubscriptions retrieves all subscriptions (active and expired) for a user
-func (s *SubscriptionService) GetAllSubscriptions(userID int64, limit, offset int) ([]models.SubscriptionWithPackage, error) {
-	subscriptions, err := s.subscriptionRepo.GetAllByUserID(userID, limit, offset)
+// GetAllSubscriptions retrieves a page of subscriptions (active and expired) for a user,
+// along with the cursor of the next page
+func (s *SubscriptionService) GetAllSubscriptions(userID int64, page models.PageRequest) ([]models.SubscriptionWithPackage, string, error) {
+	page.Normalize()
+
+	subscriptions, err := s.subscriptionRepo.GetAllByUserID(userID, page.Lookahead())
 	if err != nil {
-		return nil, err
+		return nil, "", err
+	}
+
+	var nextCursor string
+	if len(subscriptions) > page.Limit {
+		subscriptions = subscriptions[:page.Limit]
+		last := subscriptions[page.Limit-1]
+		nextCursor = models.PageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
 	}
 
 	var result []models.SubscriptionWithPackage
 	for _, sub := range subscriptions {
 		pkg, err := s.packageRepo.GetByID(sub.PackageID)
 		if err != nil {
-			return nil, err
+			return nil, "", err
 		}
 
 		result = append(result, models.SubscriptionWithPackage{
@@ -169,7 +185,7 @@ func (s *SubscriptionService) GetAllSubscriptions(userID int64, limit, offset in
 		})
 	}
 
-	return result, nil
+	return result, nextCursor, nil
 }
 
 // DeactivateExpired deactivates all expired subscriptions
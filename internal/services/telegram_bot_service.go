@@ -0,0 +1,313 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/config"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/repositories"
+)
+
+var (
+	ErrTelegramBotDisabled = errors.New("telegram bot is not configured")
+	ErrTelegramNotLinked   = errors.New("telegram account not linked")
+)
+
+// botSignalsLimit is the number of signals listed by the /signals command
+const botSignalsLimit = 5
+
+const (
+	botHelpReply = "Commands:\n" +
+		"/status - your linked account and subscriptions\n" +
+		"/signals - the latest signals you have access to\n" +
+		"/stop - stop receiving signals here"
+	botNotLinkedReply   = "This chat is not linked to an account. Open the app and choose \"Link Telegram\" to receive your signals here."
+	botInvalidLinkReply = "This link is invalid or has expired. Open the app and choose \"Link Telegram\" to get a new one."
+	botBlockedReply     = "Your account is blocked."
+	botErrorReply       = "Something went wrong, please try again later."
+)
+
+// TelegramLinkStore keeps the Telegram chats users linked and the one-time tokens
+// linking them, implemented by repositories.TelegramLinkRepository
+type TelegramLinkStore interface {
+	CreateToken(userID int64, token string, ttl time.Duration) (time.Time, error)
+	Link(token string, chatID int64, username *string) (*models.TelegramLink, error)
+	GetByUserID(userID int64) (*models.TelegramLink, error)
+	GetByChatID(chatID int64) (*models.TelegramLink, error)
+	DisableMessages(userIDs []int64) (int64, error)
+	Delete(userIDs []int64) (int64, error)
+}
+
+// TelegramBotService links users' Telegram chats to their account and answers
+// the commands they send the bot. Linked users receive the signals their
+// subscriptions cover as direct messages through the telegram personal channel.
+// Requests to join private chats are passed on to the membership service.
+type TelegramBotService struct {
+	client              *TelegramClient
+	linkRepo            TelegramLinkStore
+	userRepo            *repositories.UserRepository
+	subscriptionService *SubscriptionService
+	signalService       *TradingSignalService
+	membershipService   *ChannelMembershipService
+	cfg                 *config.NotificationConfig
+}
+
+func NewTelegramBotService(cfg *config.NotificationConfig, linkRepo TelegramLinkStore, userRepo *repositories.UserRepository, subscriptionService *SubscriptionService, signalService *TradingSignalService, membershipService *ChannelMembershipService) *TelegramBotService {
+	return &TelegramBotService{
+		client:              NewTelegramClient(cfg.TelegramAPIURL, cfg.TelegramBotToken),
+		linkRepo:            linkRepo,
+		userRepo:            userRepo,
+		subscriptionService: subscriptionService,
+		signalService:       signalService,
+		membershipService:   membershipService,
+		cfg:                 cfg,
+	}
+}
+
+// CreateLinkToken issues a one-time token linking the chat that starts the bot
+// with it to the user, along with the deep link doing so
+func (s *TelegramBotService) CreateLinkToken(userID int64) (*models.TelegramLinkToken, error) {
+	if !s.cfg.TelegramBotEnabled() {
+		return nil, ErrTelegramBotDisabled
+	}
+
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, fmt.Errorf("failed to generate telegram link token: %w", err)
+	}
+	token := hex.EncodeToString(buf)
+
+	expiresAt, err := s.linkRepo.CreateToken(userID, token, s.cfg.TelegramLinkTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.TelegramLinkToken{
+		Token:     token,
+		URL:       fmt.Sprintf("https://t.me/%s?start=%s", s.cfg.TelegramBotUsername, token),
+		ExpiresAt: expiresAt,
+	}, nil
+}
+
+// GetLink retrieves the chat linked to a user
+func (s *TelegramBotService) GetLink(userID int64) (*models.TelegramLink, error) {
+	link, err := s.linkRepo.GetByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrTelegramNotLinked
+	}
+	return link, nil
+}
+
+// Unlink removes the chat linked to a user
+func (s *TelegramBotService) Unlink(userID int64) error {
+	unlinked, err := s.linkRepo.Delete([]int64{userID})
+	if err != nil {
+		return err
+	}
+	if unlinked == 0 {
+		return ErrTelegramNotLinked
+	}
+	return nil
+}
+
+// ValidWebhookSecret reports whether a webhook request carries the configured
+// secret token
+func (s *TelegramBotService) ValidWebhookSecret(secret string) bool {
+	if !s.cfg.TelegramBotEnabled() {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(secret), []byte(s.cfg.TelegramWebhookSecret)) == 1
+}
+
+// HandleUpdate answers a command sent to the bot in a private chat or a request
+// to join a private chat. Other updates are ignored.
+func (s *TelegramBotService) HandleUpdate(update *models.TelegramUpdate) error {
+	if update.ChatJoinRequest != nil {
+		if err := s.membershipService.HandleJoinRequest(update.ChatJoinRequest); err != nil {
+			return fmt.Errorf("failed to handle telegram join request: %w", err)
+		}
+		return nil
+	}
+
+	message := update.Message
+	if message == nil || message.From == nil || message.Chat.Type != "private" {
+		return nil
+	}
+
+	command, argument := parseBotCommand(message.Text)
+	var reply string
+	var err error
+	switch command {
+	case "/start":
+		reply, err = s.start(message, argument)
+	case "/status":
+		reply, err = s.status(message.Chat.ID)
+	case "/signals":
+		reply, err = s.signals(message.Chat.ID)
+	case "/stop":
+		reply, err = s.stop(message.Chat.ID)
+	default:
+		reply = botHelpReply
+	}
+
+	chatID := strconv.FormatInt(message.Chat.ID, 10)
+	if err != nil {
+		if sendErr := s.client.SendMessage(chatID, botErrorReply, ""); sendErr != nil {
+			log.Printf("Failed to answer Telegram chat %d: %v", message.Chat.ID, sendErr)
+		}
+		return fmt.Errorf("failed to handle telegram %s command: %w", command, err)
+	}
+	return s.client.SendMessage(chatID, reply, "")
+}
+
+// parseBotCommand splits a message into its command, without the bot username
+// Telegram may append, and its first argument
+func parseBotCommand(text string) (string, string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], "/") {
+		return "", ""
+	}
+
+	command, _, _ := strings.Cut(fields[0], "@")
+	command = strings.ToLower(command)
+	if len(fields) > 1 {
+		return command, fields[1]
+	}
+	return command, ""
+}
+
+// start links the chat with the token of a deep link
+func (s *TelegramBotService) start(message *models.TelegramMessage, token string) (string, error) {
+	if token == "" {
+		link, err := s.linkRepo.GetByChatID(message.Chat.ID)
+		if err != nil {
+			return "", err
+		}
+		if link == nil {
+			return botNotLinkedReply, nil
+		}
+		return "This chat is linked to your account.\n\n" + botHelpReply, nil
+	}
+
+	var username *string
+	if message.From.Username != "" {
+		username = &message.From.Username
+	}
+
+	link, err := s.linkRepo.Link(token, message.Chat.ID, username)
+	if err != nil {
+		return "", err
+	}
+	if link == nil {
+		return botInvalidLinkReply, nil
+	}
+
+	log.Printf("Telegram chat linked to user %d", link.UserID)
+	return "✅ Your account is linked. The signals your subscriptions cover will be sent to this chat.\n\n" + botHelpReply, nil
+}
+
+// linkedUser retrieves the user a chat is linked to, nil with the reply to send
+// if there is none or the user is blocked
+func (s *TelegramBotService) linkedUser(chatID int64) (*models.User, string, error) {
+	link, err := s.linkRepo.GetByChatID(chatID)
+	if err != nil {
+		return nil, "", err
+	}
+	if link == nil {
+		return nil, botNotLinkedReply, nil
+	}
+
+	user, err := s.userRepo.GetByID(link.UserID)
+	if err != nil {
+		return nil, "", err
+	}
+	if user == nil {
+		return nil, botNotLinkedReply, nil
+	}
+	if user.Blocked {
+		return nil, botBlockedReply, nil
+	}
+	return user, "", nil
+}
+
+// status lists the linked account and its active subscriptions
+func (s *TelegramBotService) status(chatID int64) (string, error) {
+	user, reply, err := s.linkedUser(chatID)
+	if user == nil {
+		return reply, err
+	}
+
+	subscriptions, err := s.subscriptionService.GetActiveSubscriptions(user.ID)
+	if err != nil {
+		return "", err
+	}
+
+	lines := []string{fmt.Sprintf("Linked to %s", user.Email), ""}
+	if len(subscriptions) == 0 {
+		lines = append(lines, "You have no active subscriptions, so you only receive free signals here.")
+	} else {
+		lines = append(lines, "Active subscriptions:")
+		for _, subscription := range subscriptions {
+			name := fmt.Sprintf("Package %d", subscription.PackageID)
+			if subscription.Package != nil {
+				name = fmt.Sprintf("%s (%s %s)", subscription.Package.Name, subscription.Package.AssetClass, subscription.Package.DurationType)
+			}
+			lines = append(lines, fmt.Sprintf("• %s until %s", name, subscription.ExpiresAt.Format("2006-01-02")))
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// signals lists the latest signals the linked user has access to
+func (s *TelegramBotService) signals(chatID int64) (string, error) {
+	user, reply, err := s.linkedUser(chatID)
+	if user == nil {
+		return reply, err
+	}
+
+	signals, _, err := s.signalService.GetSignalsForUser(user.ID, &models.TradingSignalFilter{}, models.PageRequest{Limit: botSignalsLimit})
+	if err != nil {
+		return "", err
+	}
+	if len(signals) == 0 {
+		return "There are no signals for you yet.", nil
+	}
+
+	parts := make([]string, len(signals))
+	for i, signal := range signals {
+		parts[i] = fmt.Sprintf("%s %s · %s\nEntry %s · SL %s · TP %s",
+			signal.Symbol, signal.Type, signal.Status,
+			formatPrice(signal.EntryPrice), formatPrice(signal.StopLossPrice), formatPrice(signal.TakeProfitPrice))
+	}
+	return "Latest signals:\n\n" + strings.Join(parts, "\n\n"), nil
+}
+
+// stop turns Telegram messages off for the user the chat is linked to. The chat
+// stays linked, as it identifies the user in the private groups they pay for.
+func (s *TelegramBotService) stop(chatID int64) (string, error) {
+	link, err := s.linkRepo.GetByChatID(chatID)
+	if err != nil {
+		return "", err
+	}
+	if link == nil {
+		return botNotLinkedReply, nil
+	}
+
+	if _, err := s.linkRepo.DisableMessages([]int64{link.UserID}); err != nil {
+		return "", err
+	}
+	log.Printf("Telegram messages turned off for user %d", link.UserID)
+	return "You will no longer receive signals here. Your account stays linked, so you keep access to your private groups. " +
+		"Turn Telegram back on in the app's notification settings to receive signals again.", nil
+}
@@ -0,0 +1,166 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// ErrTelegramChatUnavailable is returned when the bot may no longer message a
+// chat, e.g. because the user blocked it
+var ErrTelegramChatUnavailable = errors.New("telegram chat unavailable")
+
+// TelegramClient calls the Telegram Bot API as the configured bot
+type TelegramClient struct {
+	baseURL    string
+	botToken   string
+	httpClient *http.Client
+}
+
+// NewTelegramClient creates a client for the Bot API at baseURL, normally
+// https://api.telegram.org
+func NewTelegramClient(baseURL, botToken string) *TelegramClient {
+	return &TelegramClient{
+		baseURL:    baseURL,
+		botToken:   botToken,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// SendMessage posts text to a chat, a chat ID or @channel username. The text is
+// sent as plain text when parseMode is empty.
+func (c *TelegramClient) SendMessage(chatID, text, parseMode string) error {
+	params := map[string]interface{}{
+		"chat_id": chatID,
+		"text":    text,
+	}
+	if parseMode != "" {
+		params["parse_mode"] = parseMode
+	}
+	return c.call("sendMessage", params, nil)
+}
+
+// CreateInviteLink creates an invite link to a chat valid until expiresAt. Users
+// following it send a join request, which the bot approves or declines. The bot
+// must be an admin of the chat allowed to invite users.
+func (c *TelegramClient) CreateInviteLink(chatID, name string, expiresAt time.Time) (string, error) {
+	params := map[string]interface{}{
+		"chat_id":              chatID,
+		"name":                 name,
+		"expire_date":          expiresAt.Unix(),
+		"creates_join_request": true,
+	}
+
+	var result struct {
+		InviteLink string `json:"invite_link"`
+	}
+	if err := c.call("createChatInviteLink", params, &result); err != nil {
+		return "", err
+	}
+	return result.InviteLink, nil
+}
+
+// RevokeInviteLink revokes an invite link created by the bot
+func (c *TelegramClient) RevokeInviteLink(chatID, inviteLink string) error {
+	return c.call("revokeChatInviteLink", map[string]interface{}{
+		"chat_id":     chatID,
+		"invite_link": inviteLink,
+	}, nil)
+}
+
+// ApproveJoinRequest lets a user who asked to join a chat in
+func (c *TelegramClient) ApproveJoinRequest(chatID, userID string) error {
+	return c.call("approveChatJoinRequest", map[string]interface{}{"chat_id": chatID, "user_id": userID}, nil)
+}
+
+// DeclineJoinRequest turns down a user's request to join a chat
+func (c *TelegramClient) DeclineJoinRequest(chatID, userID string) error {
+	return c.call("declineChatJoinRequest", map[string]interface{}{"chat_id": chatID, "user_id": userID}, nil)
+}
+
+// IsChatMember reports whether a user is a member of a chat
+func (c *TelegramClient) IsChatMember(chatID, userID string) (bool, error) {
+	var result struct {
+		Status   string `json:"status"`
+		IsMember bool   `json:"is_member"` // Only set for restricted members
+	}
+	if err := c.call("getChatMember", map[string]interface{}{"chat_id": chatID, "user_id": userID}, &result); err != nil {
+		return false, err
+	}
+
+	switch result.Status {
+	case "creator", "administrator", "member":
+		return true, nil
+	case "restricted":
+		return result.IsMember, nil
+	default:
+		return false, nil
+	}
+}
+
+// KickChatMember removes a user from a chat. The user is unbanned right away, so
+// they can join again with a new invite once they resubscribe.
+func (c *TelegramClient) KickChatMember(chatID, userID string) error {
+	if err := c.call("banChatMember", map[string]interface{}{"chat_id": chatID, "user_id": userID}, nil); err != nil {
+		return err
+	}
+	return c.call("unbanChatMember", map[string]interface{}{
+		"chat_id":        chatID,
+		"user_id":        userID,
+		"only_if_banned": true,
+	}, nil)
+}
+
+// call invokes a Bot API method, decoding its result into result unless nil
+func (c *TelegramClient) call(method string, params map[string]interface{}, result interface{}) error {
+	jsonData, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("failed to marshal telegram request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/bot%s/%s", c.baseURL, c.botToken, method)
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create telegram request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send telegram request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	// The body explains failures; it is only required to hold a result
+	decodeErr := json.NewDecoder(resp.Body).Decode(&body)
+
+	if resp.StatusCode == http.StatusForbidden {
+		return fmt.Errorf("%w: chat %v", ErrTelegramChatUnavailable, params["chat_id"])
+	}
+	if resp.StatusCode != http.StatusOK {
+		if body.Description != "" {
+			return fmt.Errorf("telegram API returned status %d: %s", resp.StatusCode, body.Description)
+		}
+		return fmt.Errorf("telegram API returned status %d", resp.StatusCode)
+	}
+
+	if result == nil {
+		return nil
+	}
+	if decodeErr != nil {
+		return fmt.Errorf("failed to decode telegram response: %w", decodeErr)
+	}
+	if err := json.Unmarshal(body.Result, result); err != nil {
+		return fmt.Errorf("failed to decode telegram %s result: %w", method, err)
+	}
+	return nil
+}
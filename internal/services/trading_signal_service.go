@@ -1,93 +1,815 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"time"
 
+	"github.com/omarshah0/rest-api-with-social-auth/internal/config"
 	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
 	"github.com/omarshah0/rest-api-with-social-auth/internal/repositories"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/risk"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/utils"
+)
+
+var (
+	ErrTradingSignalNotFound   = errors.New("trading signal not found")
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+	ErrExitPriceRequired       = errors.New("price is required to close a signal manually")
+	ErrInvalidTargets          = errors.New("invalid take profit targets")
+	ErrUpdateNotAllowed        = errors.New("update not allowed in the signal's status")
 )
 
 type TradingSignalService struct {
-	repo                *repositories.TradingSignalRepository
-	notificationService *NotificationService
+	repo              *repositories.TradingSignalRepository
+	packageRepo       *repositories.PackageRepository
+	instrumentService *InstrumentService
+	rules             *config.SignalRulesConfig
+	visibility        *config.VisibilityConfig
+	statsCache        *StatsCache
 }
 
-func NewTradingSignalService(repo *repositories.TradingSignalRepository, notificationService *NotificationService) *TradingSignalService {
+func NewTradingSignalService(repo *repositories.TradingSignalRepository, packageRepo *repositories.PackageRepository, instrumentService *InstrumentService, rules *config.SignalRulesConfig, visibility *config.VisibilityConfig, statsCache *StatsCache) *TradingSignalService {
 	return &TradingSignalService{
-		repo:                repo,
-		notificationService: notificationService,
+		repo:              repo,
+		packageRepo:       packageRepo,
+		instrumentService: instrumentService,
+		rules:             rules,
+		visibility:        visibility,
+		statsCache:        statsCache,
 	}
 }
 
-// Create creates a new trading signal and sends notifications
+// Create creates a new trading signal and queues its notifications
 func (s *TradingSignalService) Create(signal *models.TradingSignalCreate, createdBy int64) (*models.TradingSignal, error) {
-	newSignal, err := s.repo.Create(signal, createdBy)
+	symbol, err := s.canonicalSymbol(signal.AssetClass, signal.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	signal.Symbol = symbol
+
+	targetsField := "take_profit_price"
+	if len(signal.Targets) > 0 {
+		targetsField = "targets"
+	}
+
+	targets, err := normalizeTargets(signal.Type, signal.EntryPrice, signal.TakeProfitPrice, signal.Targets)
 	if err != nil {
 		return nil, err
 	}
 
-	// Send notifications asynchronously (don't fail if notification fails)
-	go func() {
-		if err := s.notificationService.SendSignalNotification(newSignal); err != nil {
-			fmt.Printf("Failed to send notification for signal %d: %v\n", newSignal.ID, err)
+	if errs := s.validateLevels(&signalLevels{
+		AssetClass:    signal.AssetClass,
+		Type:          signal.Type,
+		EntryPrice:    signal.EntryPrice,
+		StopLossPrice: signal.StopLossPrice,
+		Targets:       targets,
+		TargetsField:  targetsField,
+	}); len(errs) > 0 {
+		return nil, errs
+	}
+
+	signal.Targets = targets
+	signal.TakeProfitPrice = targets[len(targets)-1].Price
+	if signal.PublishAt != nil && !signal.PublishAt.After(time.Now()) {
+		signal.PublishAt = nil
+	} else if signal.PublishAt != nil {
+		// publish_at has no time zone, so the offset is applied before it is stored
+		publishAt := signal.PublishAt.UTC()
+		signal.PublishAt = &publishAt
+	}
+	if signal.ValidUntil != nil {
+		// Like publish_at, valid_until is stored without a time zone
+		validUntil := signal.ValidUntil.UTC()
+		signal.ValidUntil = &validUntil
+
+		publishedAt := time.Now()
+		if signal.PublishAt != nil {
+			publishedAt = *signal.PublishAt
+		}
+		if !signal.ValidUntil.After(publishedAt) {
+			return nil, utils.ValidationErrors{{Field: "valid_until", Message: "valid_until must be after the signal is published"}}
 		}
-	}()
+	}
+
+	newSignal, err := s.repo.Create(signal, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	newSignal.AttachMetrics()
+	s.statsCache.Invalidate()
 
 	return newSignal, nil
 }
 
-// GetByID retrieves a trading signal by ID
+// PublishDue publishes up to limit scheduled signals whose publish time has passed
+// and queues their notifications. It returns the number of signals published.
+func (s *TradingSignalService) PublishDue(limit int) (int, error) {
+	signals, err := s.repo.ClaimDueSignals(limit)
+	if err != nil {
+		return 0, err
+	}
+	if len(signals) > 0 {
+		s.statsCache.Invalidate()
+	}
+	return len(signals), nil
+}
+
+// ReleaseDue queues the new signal notifications of up to limit delayed tiers whose
+// release time has passed. Signals closed in the meantime are not announced anymore.
+// It returns the number of releases processed.
+func (s *TradingSignalService) ReleaseDue(limit int) (int, error) {
+	return s.repo.ClaimDueTierReleases(limit)
+}
+
+// GetByID retrieves a trading signal by ID including its status history
 func (s *TradingSignalService) GetByID(id int64) (*models.TradingSignal, error) {
 	signal, err := s.repo.GetByID(id)
 	if err != nil {
 		return nil, err
 	}
 	if signal == nil {
-		return nil, fmt.Errorf("trading signal not found")
+		return nil, ErrTradingSignalNotFound
+	}
+
+	signal.Targets, err = s.repo.GetTargets(id)
+	if err != nil {
+		return nil, err
+	}
+
+	signal.StatusHistory, err = s.repo.GetStatusHistory(id)
+	if err != nil {
+		return nil, err
+	}
+
+	signal.PartialCloses, err = s.repo.GetPartialCloses(id)
+	if err != nil {
+		return nil, err
+	}
+	signal.AttachMetrics()
+	return signal, nil
+}
+
+// GetAll retrieves a page of trading signals matching filter along with the
+// cursor of the next page, which is empty on the last page
+func (s *TradingSignalService) GetAll(filter *models.TradingSignalFilter, page models.PageRequest) ([]models.TradingSignal, string, error) {
+	page.Normalize()
+	signals, err := s.repo.GetAll(filter, page.Lookahead())
+	if err != nil {
+		return nil, "", err
+	}
+
+	signals, nextCursor := trimSignalPage(signals, filter, page.Limit)
+	return signals, nextCursor, s.attachTargets(signals)
+}
+
+// Update updates a trading signal, keeping a revision of the previous values
+func (s *TradingSignalService) Update(id int64, update *models.TradingSignalUpdate, editedBy int64) (*models.TradingSignal, error) {
+	if update.Symbol != nil || update.ValidUntil != nil || changesPriceLevels(update) {
+		existing, err := s.repo.GetByID(id)
+		if err != nil {
+			return nil, err
+		}
+		if existing == nil {
+			return nil, ErrTradingSignalNotFound
+		}
+
+		if update.Symbol != nil || update.AssetClass != nil {
+			assetClass, symbol := existing.AssetClass, existing.Symbol
+			if update.AssetClass != nil {
+				assetClass = *update.AssetClass
+			}
+			if update.Symbol != nil {
+				symbol = *update.Symbol
+			}
+			canonical, err := s.canonicalSymbol(assetClass, symbol)
+			if err != nil {
+				return nil, err
+			}
+			update.Symbol = &canonical
+		}
+
+		if changesPriceLevels(update) {
+			if err := s.validateLevelUpdate(existing, update); err != nil {
+				return nil, err
+			}
+		}
+
+		if update.ValidUntil != nil {
+			if existing.Status != models.SignalStatusPending {
+				return nil, utils.ValidationErrors{{Field: "valid_until", Message: "valid_until can only change while the signal is pending"}}
+			}
+			if !update.ValidUntil.After(time.Now()) {
+				return nil, utils.ValidationErrors{{Field: "valid_until", Message: "valid_until must be in the future"}}
+			}
+			validUntil := update.ValidUntil.UTC()
+			update.ValidUntil = &validUntil
+		}
+	}
+
+	signal, err := s.repo.Update(id, update, editedBy)
+	if err != nil {
+		return nil, err
+	}
+	if signal == nil {
+		return nil, ErrTradingSignalNotFound
+	}
+	s.statsCache.Invalidate()
+
+	if signal.Targets == nil {
+		signal.Targets, err = s.repo.GetTargets(id)
+		if err != nil {
+			return nil, err
+		}
 	}
+	signal.AttachMetrics()
 	return signal, nil
 }
 
-// GetAll retrieves all trading signals
-func (s *TradingSignalService) GetAll(limit, offset int) ([]models.TradingSignal, error) {
-	if limit <= 0 {
-		limit = 50
+// canonicalSymbol resolves a signal symbol against the instrument catalog
+func (s *TradingSignalService) canonicalSymbol(assetClass models.AssetClass, symbol string) (string, error) {
+	instrument, err := s.instrumentService.Resolve(assetClass, symbol)
+	if errors.Is(err, ErrUnknownInstrument) {
+		return "", utils.ValidationErrors{{
+			Field:   "symbol",
+			Message: fmt.Sprintf("%s is not a listed %s instrument", symbol, assetClass),
+		}}
+	}
+	if err != nil {
+		return "", err
+	}
+	return instrument.Symbol, nil
+}
+
+// validateLevelUpdate checks the price levels a signal will have after update
+func (s *TradingSignalService) validateLevelUpdate(existing *models.TradingSignal, update *models.TradingSignalUpdate) error {
+	var err error
+	existing.Targets, err = s.repo.GetTargets(existing.ID)
+	if err != nil {
+		return err
+	}
+
+	targetsField := "targets"
+	if update.Targets == nil && update.TakeProfitPrice != nil {
+		targetsField = "take_profit_price"
+	}
+	if update.Targets != nil || update.TakeProfitPrice != nil {
+		if err := prepareTargetUpdate(existing, update); err != nil {
+			return err
+		}
+	}
+
+	if existing.Status.IsOpen() {
+		if errs := s.validateLevels(updatedLevels(existing, update, targetsField)); len(errs) > 0 {
+			return errs
+		}
+	}
+	return nil
+}
+
+// changesPriceLevels reports whether an update touches any field that affects
+// the validity of the signal's stop loss and take-profit levels
+func changesPriceLevels(update *models.TradingSignalUpdate) bool {
+	return update.AssetClass != nil || update.Type != nil || update.EntryPrice != nil ||
+		update.StopLossPrice != nil || update.TakeProfitPrice != nil || update.Targets != nil
+}
+
+// prepareTargetUpdate validates new take-profit levels against the stored signal
+// and keeps take_profit_price in sync with the final target
+func prepareTargetUpdate(existing *models.TradingSignal, update *models.TradingSignalUpdate) error {
+	if existing.HitTargetCount() > 0 {
+		return fmt.Errorf("%w: targets cannot change once one has been hit", ErrInvalidTargets)
+	}
+	if update.Targets == nil && len(existing.Targets) > 1 {
+		return fmt.Errorf("%w: update the targets list to change take profit on a multi-target signal", ErrInvalidTargets)
+	}
+
+	signalType, entryPrice, takeProfit := existing.Type, existing.EntryPrice, 0.0
+	if update.Type != nil {
+		signalType = *update.Type
+	}
+	if update.EntryPrice != nil {
+		entryPrice = *update.EntryPrice
+	}
+	if update.TakeProfitPrice != nil {
+		takeProfit = *update.TakeProfitPrice
+	}
+
+	targets, err := normalizeTargets(signalType, entryPrice, takeProfit, update.Targets)
+	if err != nil {
+		return err
+	}
+	finalTarget := targets[len(targets)-1].Price
+	update.Targets = targets
+	update.TakeProfitPrice = &finalTarget
+	return nil
+}
+
+// signalLevels holds the prices checked by validateLevels
+type signalLevels struct {
+	AssetClass    models.AssetClass
+	Type          models.SignalType
+	EntryPrice    float64
+	StopLossPrice float64
+	Targets       []models.SignalTargetInput // Targets not yet hit, nearest first
+	TargetsField  string                     // Request field reported for target errors
+
+	// Trailing is set for active signals, whose stop loss may have been moved
+	// to or past entry to protect profits
+	Trailing bool
+}
+
+// updatedLevels returns the price levels a signal will have once update is applied
+func updatedLevels(existing *models.TradingSignal, update *models.TradingSignalUpdate, targetsField string) *signalLevels {
+	levels := &signalLevels{
+		AssetClass:    existing.AssetClass,
+		Type:          existing.Type,
+		EntryPrice:    existing.EntryPrice,
+		StopLossPrice: existing.StopLossPrice,
+		Targets:       update.Targets,
+		TargetsField:  targetsField,
+		Trailing:      existing.Status == models.SignalStatusActive,
+	}
+	if update.AssetClass != nil {
+		levels.AssetClass = *update.AssetClass
+	}
+	if update.Type != nil {
+		levels.Type = *update.Type
+	}
+	if update.EntryPrice != nil {
+		levels.EntryPrice = *update.EntryPrice
+	}
+	if update.StopLossPrice != nil {
+		levels.StopLossPrice = *update.StopLossPrice
+	}
+	if levels.Targets == nil {
+		for _, target := range existing.Targets {
+			if !target.IsHit() {
+				levels.Targets = append(levels.Targets, models.SignalTargetInput{
+					Price:             target.Price,
+					AllocationPercent: target.AllocationPercent,
+				})
+			}
+		}
+	}
+	return levels
+}
+
+// validateLevels checks that the stop loss and take-profit levels sit on the
+// correct side of entry for the signal's direction and that the risk-reward
+// ratio is within the bounds configured for the asset class
+func (s *TradingSignalService) validateLevels(levels *signalLevels) utils.ValidationErrors {
+	var errs utils.ValidationErrors
+
+	lossSide, profitSide := "below", "above"
+	if levels.Type == models.SignalTypeShort {
+		lossSide, profitSide = "above", "below"
+	}
+
+	position := levels.position()
+
+	// Targets are ordered away from entry, so checking the nearest covers them all
+	if len(levels.Targets) > 0 && position.Reward(levels.Targets[0].Price) <= 0 {
+		field := levels.TargetsField
+		if field == "targets" {
+			field = "targets[0].price"
+		}
+		errs = append(errs, utils.FieldError{
+			Field:   field,
+			Message: fmt.Sprintf("%s must be %s entry_price for a %s signal", field, profitSide, levels.Type),
+		})
+	}
+
+	if levels.Trailing {
+		fromStop := risk.Position{Short: position.Short, Entry: levels.StopLossPrice}
+		if len(levels.Targets) > 0 && fromStop.Reward(levels.Targets[0].Price) <= 0 {
+			errs = append(errs, utils.FieldError{
+				Field:   "stop_loss_price",
+				Message: fmt.Sprintf("stop_loss_price must be %s the next take profit target for a %s signal", lossSide, levels.Type),
+			})
+		}
+		return errs
+	}
+
+	if position.Risk() <= 0 {
+		errs = append(errs, utils.FieldError{
+			Field:   "stop_loss_price",
+			Message: fmt.Sprintf("stop_loss_price must be %s entry_price for a %s signal", lossSide, levels.Type),
+		})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	bounds, ok := s.rules.RiskReward[string(levels.AssetClass)]
+	if !ok {
+		return nil
+	}
+	ratio := position.RiskReward()
+	if bounds.Min > 0 && ratio < bounds.Min {
+		errs = append(errs, utils.FieldError{
+			Field:   "risk_reward",
+			Message: fmt.Sprintf("risk-reward ratio %.2f is below the minimum of %.2f for %s signals", ratio, bounds.Min, levels.AssetClass),
+		})
 	}
-	if limit > 100 {
-		limit = 100
+	if bounds.Max > 0 && ratio > bounds.Max {
+		errs = append(errs, utils.FieldError{
+			Field:   "risk_reward",
+			Message: fmt.Sprintf("risk-reward ratio %.2f is above the maximum of %.2f for %s signals", ratio, bounds.Max, levels.AssetClass),
+		})
 	}
-	return s.repo.GetAll(limit, offset)
+	return errs
 }
 
-// Update updates a trading signal
-func (s *TradingSignalService) Update(id int64, update *models.TradingSignalUpdate) (*models.TradingSignal, error) {
-	return s.repo.Update(id, update)
+// position converts the levels for use with the risk package
+func (l *signalLevels) position() risk.Position {
+	position := risk.Position{
+		AssetClass: string(l.AssetClass),
+		Short:      l.Type == models.SignalTypeShort,
+		Entry:      l.EntryPrice,
+		StopLoss:   l.StopLossPrice,
+	}
+	for _, target := range l.Targets {
+		position.Targets = append(position.Targets, risk.Target{
+			Price:             target.Price,
+			AllocationPercent: target.AllocationPercent,
+		})
+	}
+	return position
 }
 
-// Delete deletes a trading signal
+// GetRevisions retrieves the edit history of a signal
+func (s *TradingSignalService) GetRevisions(id int64) ([]models.SignalRevision, error) {
+	signal, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if signal == nil {
+		return nil, ErrTradingSignalNotFound
+	}
+	return s.repo.GetRevisions(id)
+}
+
+// PostUpdate adds an analyst update to the timeline of an open signal and notifies
+// subscribers. A new stop loss is applied to the signal; a partial close is taken
+// into account when the signal's return is computed.
+func (s *TradingSignalService) PostUpdate(id int64, create *models.SignalUpdateCreate, createdBy int64) (*models.SignalUpdate, error) {
+	signal, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if signal == nil {
+		return nil, ErrTradingSignalNotFound
+	}
+	if !signal.Status.IsOpen() {
+		return nil, fmt.Errorf("%w: the signal is %s", ErrUpdateNotAllowed, signal.Status)
+	}
+
+	signal.Targets, err = s.repo.GetTargets(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.validateSignalUpdate(signal, create); err != nil {
+		return nil, err
+	}
+
+	update, err := s.repo.CreateUpdate(id, signal.Status, create, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	if update == nil {
+		return nil, fmt.Errorf("%w: signal is no longer %s", ErrUpdateNotAllowed, signal.Status)
+	}
+	if update.NewStopLoss != nil || update.ClosePercent != nil {
+		s.statsCache.Invalidate()
+	}
+
+	return update, nil
+}
+
+// validateSignalUpdate checks the actions of an update against the signal's
+// current status. A new stop loss is held to the same rules as an edit of the
+// signal's stop loss.
+func (s *TradingSignalService) validateSignalUpdate(signal *models.TradingSignal, create *models.SignalUpdateCreate) error {
+	var errs utils.ValidationErrors
+
+	if (create.ClosePercent == nil) != (create.ClosePrice == nil) {
+		errs = append(errs, utils.FieldError{
+			Field:   "close_price",
+			Message: "close_percent and close_price must be given together",
+		})
+	} else if create.ClosePercent != nil && signal.Status != models.SignalStatusActive {
+		return fmt.Errorf("%w: only %s signals can be partially closed", ErrUpdateNotAllowed, models.SignalStatusActive)
+	}
+
+	if create.NewStopLoss != nil {
+		if *create.NewStopLoss == signal.StopLossPrice {
+			errs = append(errs, utils.FieldError{
+				Field:   "new_stop_loss",
+				Message: "new_stop_loss must differ from the current stop loss",
+			})
+		}
+
+		levels := updatedLevels(signal, &models.TradingSignalUpdate{StopLossPrice: create.NewStopLoss}, "targets")
+		for _, levelErr := range s.validateLevels(levels) {
+			if levelErr.Field == "stop_loss_price" {
+				levelErr.Field = "new_stop_loss"
+			}
+			errs = append(errs, levelErr)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// GetUpdates retrieves the timeline of analyst updates on a signal
+func (s *TradingSignalService) GetUpdates(id int64) ([]models.SignalUpdate, error) {
+	signal, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if signal == nil {
+		return nil, ErrTradingSignalNotFound
+	}
+	return s.repo.GetUpdates(id)
+}
+
+// MarkTargetHit records that a take-profit target was reached. Hitting the final
+// target closes the signal as TP_HIT in the same transaction.
+func (s *TradingSignalService) MarkTargetHit(signalID int64, position int, changedBy *int64) (*models.TradingSignal, error) {
+	signal, err := s.repo.GetByID(signalID)
+	if err != nil {
+		return nil, err
+	}
+	if signal == nil {
+		return nil, ErrTradingSignalNotFound
+	}
+	if signal.Status != models.SignalStatusActive {
+		return nil, fmt.Errorf("%w: targets can only be hit while the signal is %s", ErrInvalidStatusTransition, models.SignalStatusActive)
+	}
+
+	updated, marked, err := s.repo.MarkTargetHit(signalID, position, changedBy)
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, fmt.Errorf("%w: signal is no longer %s", ErrInvalidStatusTransition, models.SignalStatusActive)
+	}
+	if !marked {
+		return nil, fmt.Errorf("%w: TP%d does not exist or was already hit", ErrInvalidTargets, position)
+	}
+	s.statsCache.Invalidate()
+
+	updated.AttachMetrics()
+	return updated, nil
+}
+
+// GetOpenSignals retrieves all pending and active signals with their targets
+func (s *TradingSignalService) GetOpenSignals() ([]models.TradingSignal, error) {
+	signals, err := s.repo.GetOpenSignals()
+	if err != nil {
+		return nil, err
+	}
+	return signals, s.attachTargets(signals)
+}
+
+// attachTargets loads the take-profit targets for a page of signals and derives their metrics
+func (s *TradingSignalService) attachTargets(signals []models.TradingSignal) error {
+	ids := make([]int64, len(signals))
+	for i := range signals {
+		ids[i] = signals[i].ID
+	}
+
+	targets, err := s.repo.GetTargetsForSignals(ids)
+	if err != nil {
+		return err
+	}
+	for i := range signals {
+		signals[i].Targets = targets[signals[i].ID]
+		signals[i].AttachMetrics()
+	}
+	return nil
+}
+
+// normalizeTargets validates the take-profit levels of a signal. Without explicit
+// targets the take profit price becomes a single target holding the full position.
+func normalizeTargets(signalType models.SignalType, entryPrice, takeProfit float64, targets []models.SignalTargetInput) ([]models.SignalTargetInput, error) {
+	if len(targets) == 0 {
+		if takeProfit <= 0 {
+			return nil, fmt.Errorf("%w: take_profit_price or targets is required", ErrInvalidTargets)
+		}
+		return []models.SignalTargetInput{{Price: takeProfit, AllocationPercent: 100}}, nil
+	}
+
+	var total float64
+	for i, target := range targets {
+		total += target.AllocationPercent
+		if i == 0 {
+			continue
+		}
+		previous := targets[i-1].Price
+		if (signalType == models.SignalTypeLong && target.Price <= previous) ||
+			(signalType == models.SignalTypeShort && target.Price >= previous) {
+			return nil, fmt.Errorf("%w: TP%d must be further from entry than TP%d", ErrInvalidTargets, i+1, i)
+		}
+	}
+	if math.Abs(total-100) > 0.01 {
+		return nil, fmt.Errorf("%w: allocations must add up to 100%%, got %.2f%%", ErrInvalidTargets, total)
+	}
+
+	return targets, nil
+}
+
+// TransitionStatus moves a signal to a new lifecycle status. When a terminal trading
+// status is reached the result and return are derived from the exit price, with
+// the signal locked so its targets and partial closes cannot change meanwhile.
+// changedBy is nil for transitions made by the system.
+func (s *TradingSignalService) TransitionStatus(id int64, update *models.SignalStatusUpdate, changedBy *int64) (*models.TradingSignal, error) {
+	updated, err := s.repo.UpdateStatus(id, func(signal *models.TradingSignal) (*models.SignalStatusChange, error) {
+		if !signal.Status.CanTransitionTo(update.Status) {
+			return nil, fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, signal.Status, update.Status)
+		}
+
+		change := &models.SignalStatusChange{
+			From:      signal.Status,
+			To:        update.Status,
+			Price:     update.Price,
+			Note:      update.Note,
+			ChangedBy: changedBy,
+		}
+
+		if update.Status.ProducesResult() {
+			exitPrice, err := exitPriceFor(signal, update)
+			if err != nil {
+				return nil, err
+			}
+
+			if update.Status == models.SignalStatusTPHit && update.Price == nil {
+				// Every remaining target is closed at its own level
+				now := time.Now()
+				for i := range signal.Targets {
+					if signal.Targets[i].HitAt == nil {
+						signal.Targets[i].HitAt = &now
+					}
+				}
+			}
+
+			ret := signal.BlendedReturn(exitPrice)
+			result := models.ResultForReturn(ret)
+			change.Price = &exitPrice
+			change.ExitPrice = &exitPrice
+			change.Return = &ret
+			change.Result = &result
+		}
+		return change, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if updated == nil {
+		return nil, ErrTradingSignalNotFound
+	}
+	s.statsCache.Invalidate()
+
+	updated.Targets, err = s.repo.GetTargets(id)
+	if err != nil {
+		return nil, err
+	}
+	updated.AttachMetrics()
+
+	return updated, nil
+}
+
+// ExpireDue moves pending signals whose validity ended at or before cutoff to
+// EXPIRED and returns how many were expired. Signals activated or expired
+// concurrently by another instance are skipped.
+func (s *TradingSignalService) ExpireDue(cutoff time.Time) (int, error) {
+	// valid_until is stored in UTC without a time zone, so the cutoff must be too
+	signals, err := s.repo.GetExpiredPending(cutoff.UTC())
+	if err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for i := range signals {
+		note := fmt.Sprintf("Entry not reached before %s", signals[i].ValidUntil.UTC().Format(time.RFC3339))
+		_, err := s.TransitionStatus(signals[i].ID, &models.SignalStatusUpdate{
+			Status: models.SignalStatusExpired,
+			Note:   &note,
+		}, nil)
+		if errors.Is(err, ErrInvalidStatusTransition) {
+			continue
+		}
+		if err != nil {
+			return expired, fmt.Errorf("failed to expire signal %d: %w", signals[i].ID, err)
+		}
+		expired++
+	}
+
+	return expired, nil
+}
+
+// exitPriceFor returns the price a trade was closed at, defaulting to the signal's levels
+func exitPriceFor(signal *models.TradingSignal, update *models.SignalStatusUpdate) (float64, error) {
+	if update.Price != nil {
+		return *update.Price, nil
+	}
+	switch update.Status {
+	case models.SignalStatusTPHit:
+		return signal.TakeProfitPrice, nil
+	case models.SignalStatusSLHit:
+		return signal.StopLossPrice, nil
+	default:
+		return 0, ErrExitPriceRequired
+	}
+}
+
+// Delete deletes a trading signal. Subscribers are told a running setup is cancelled.
 func (s *TradingSignalService) Delete(id int64) error {
-	return s.repo.Delete(id)
+	if err := s.repo.Delete(id); err != nil {
+		return err
+	}
+	s.statsCache.Invalidate()
+	return nil
 }
 
-// Count returns the total count of trading signals
-func (s *TradingSignalService) Count() (int64, error) {
-	return s.repo.Count()
+// Count returns the total count of trading signals matching filter
+func (s *TradingSignalService) Count(filter *models.TradingSignalFilter) (int64, error) {
+	return s.repo.Count(filter)
 }
 
-// GetSignalsForUser retrieves trading signals visible to a specific user
-func (s *TradingSignalService) GetSignalsForUser(userID int64, limit, offset int) ([]models.TradingSignal, error) {
-	if limit <= 0 {
-		limit = 50
+// GetSignalsForUser retrieves a page of trading signals visible to a specific user
+// that match filter, along with the cursor of the next page
+func (s *TradingSignalService) GetSignalsForUser(userID int64, filter *models.TradingSignalFilter, page models.PageRequest) ([]models.TradingSignal, string, error) {
+	page.Normalize()
+	signals, err := s.repo.GetSignalsForUser(userID, filter, page.Lookahead())
+	if err != nil {
+		return nil, "", err
 	}
-	if limit > 100 {
-		limit = 100
+
+	signals, nextCursor := trimSignalPage(signals, filter, page.Limit)
+	if err := s.attachTargets(signals); err != nil {
+		return nil, "", err
 	}
-	return s.repo.GetSignalsForUser(userID, limit, offset)
+	return signals, nextCursor, s.lockSignals(signals)
+}
+
+// lockSignals turns the signals marked Locked into teasers listing the active
+// packages that unlock them, leaving out tiers whose release delay has not passed
+func (s *TradingSignalService) lockSignals(signals []models.TradingSignal) error {
+	var packagesByCategory map[string][]models.Package
+	now := time.Now()
+	for i := range signals {
+		if !signals[i].Locked {
+			continue
+		}
+
+		if packagesByCategory == nil {
+			packages, err := s.packageRepo.GetAll(true, 0, 0)
+			if err != nil {
+				return err
+			}
+			packagesByCategory = make(map[string][]models.Package)
+			for _, pkg := range packages {
+				key := models.SignalCategoryKey(pkg.AssetClass, pkg.DurationType)
+				packagesByCategory[key] = append(packagesByCategory[key], pkg)
+			}
+		}
+
+		var unlockPackageIDs []int64
+		for _, pkg := range packagesByCategory[models.SignalCategoryKey(signals[i].AssetClass, signals[i].DurationType)] {
+			releasedAt := signals[i].PublishedAt.Add(s.visibility.ReleaseDelay(string(pkg.Tier)))
+			if !releasedAt.After(now) {
+				unlockPackageIDs = append(unlockPackageIDs, pkg.ID)
+			}
+		}
+		signals[i].Lock(unlockPackageIDs)
+	}
+	return nil
+}
+
+// trimSignalPage drops the lookahead row and returns the cursor of the next page.
+// Lists sorted by return have no cursor as they are not ordered by creation time.
+func trimSignalPage(signals []models.TradingSignal, filter *models.TradingSignalFilter, limit int) ([]models.TradingSignal, string) {
+	if len(signals) <= limit {
+		return signals, ""
+	}
+	signals = signals[:limit]
+	if filter != nil && filter.SortBy == models.SignalSortReturn {
+		return signals, ""
+	}
+
+	last := signals[limit-1]
+	return signals, models.PageCursor{CreatedAt: last.CreatedAt, ID: last.ID}.Encode()
 }
 
-// CountForUser returns the total count of trading signals visible to a specific user
-func (s *TradingSignalService) CountForUser(userID int64) (int64, error) {
-	return s.repo.CountForUser(userID)
+// CountForUser returns the total count of trading signals listed to a specific user that match filter
+func (s *TradingSignalService) CountForUser(userID int64, filter *models.TradingSignalFilter) (int64, error) {
+	return s.repo.CountForUser(userID, filter)
 }
 
 // CheckUserAccessToSignal checks if a user has access to a specific signal
@@ -0,0 +1,358 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/config"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/repositories"
+)
+
+// digestLease is how long claimed digest items are hidden from other instances
+// while the digests are being sent
+const digestLease = 5 * time.Minute
+
+// UserMessage is a personal notification, formatted as plain text
+type UserMessage struct {
+	Title string
+	Body  string
+	Data  map[string]interface{} // Passed on to the app along with pushes
+}
+
+// UserChannel delivers messages to individual users through one of their
+// personal channels. Failures for single recipients are logged; an error is
+// only returned when no recipient could be reached, as a retry would otherwise
+// message the others twice.
+type UserChannel interface {
+	Deliver(recipients []models.NotificationRecipient, message *UserMessage) error
+}
+
+// UserNotificationService fans signal notifications out to the users entitled to
+// the signal, honouring each user's preferences: the signals they follow, their
+// channels, quiet hours and instant or digest delivery. Notifications a user
+// should not get right away are held and sent later as a digest.
+type UserNotificationService struct {
+	preferenceRepo *repositories.NotificationPreferenceRepository
+	digestRepo     *repositories.NotificationDigestRepository
+	channels       map[models.NotificationChannel]UserChannel
+}
+
+func NewUserNotificationService(cfg *config.NotificationConfig, preferenceRepo *repositories.NotificationPreferenceRepository, digestRepo *repositories.NotificationDigestRepository, pushTokenRepo *repositories.PushTokenRepository, telegramLinkRepo *repositories.TelegramLinkRepository, emailService *EmailService) *UserNotificationService {
+	service := &UserNotificationService{
+		preferenceRepo: preferenceRepo,
+		digestRepo:     digestRepo,
+		channels:       make(map[models.NotificationChannel]UserChannel),
+	}
+
+	if cfg.ExpoEnabled {
+		service.channels[models.NotificationChannelPush] = NewExpoNotificationService(pushTokenRepo, cfg.ExpoAPIURL, cfg.ExpoAccessToken)
+	}
+	if cfg.EmailEnabled {
+		service.channels[models.NotificationChannelEmail] = &emailChannel{emailService: emailService}
+	}
+	if cfg.TelegramBotEnabled() {
+		service.channels[models.NotificationChannelTelegram] = &telegramChannel{
+			client:   NewTelegramClient(cfg.TelegramAPIURL, cfg.TelegramBotToken),
+			linkRepo: telegramLinkRepo,
+		}
+	}
+
+	return service
+}
+
+// userChannelSender is the outbox sender of a personal channel for the users of a tier
+type userChannelSender struct {
+	service *UserNotificationService
+	channel models.NotificationChannel
+	tier    models.PackageTier
+}
+
+func (s *userChannelSender) Send(notification *models.SignalNotification) error {
+	return s.service.Send(s.channel, s.tier, notification)
+}
+
+// Sender returns the sender delivering the outbox to the users of tier through channel
+func (s *UserNotificationService) Sender(channel models.NotificationChannel, tier models.PackageTier) NotificationSender {
+	return &userChannelSender{service: s, channel: channel, tier: tier}
+}
+
+// Send delivers a notification through channel to the users of tier entitled to
+// its signal who follow it there. It goes out right away to users who want it now;
+// for the others it is held until their digest or the end of their quiet hours.
+func (s *UserNotificationService) Send(channel models.NotificationChannel, tier models.PackageTier, notification *models.SignalNotification) error {
+	userChannel, ok := s.channels[channel]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownNotificationChannel, channel)
+	}
+
+	message, err := userMessage(notification)
+	if err != nil {
+		return err
+	}
+
+	recipients, err := s.preferenceRepo.GetRecipients(notification.Signal, tier)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	var instant []models.NotificationRecipient
+	held := make(map[int64]time.Duration)
+	for _, recipient := range recipients {
+		preferences := recipient.Preferences
+		if !preferences.Matches(notification.Signal) || !preferences.HasChannel(channel) {
+			continue
+		}
+		if delay := preferences.DeliverAt(now).Sub(now); delay > 0 {
+			held[recipient.UserID] = delay
+		} else {
+			instant = append(instant, recipient)
+		}
+	}
+
+	// Once some users have been reached the notification counts as delivered,
+	// so later failures are logged rather than retried
+	var lastErr error
+	sent, queued := 0, 0
+	if len(instant) > 0 {
+		if err := userChannel.Deliver(instant, message); err != nil {
+			log.Printf("Failed to send %s %s notification for signal ID %d: %v", channel, notification.Event, notification.Signal.ID, err)
+			lastErr = err
+		} else {
+			sent = len(instant)
+		}
+	}
+	for userID, delay := range held {
+		item := &models.NotificationDigestItem{UserID: userID, Channel: channel, Title: message.Title, Body: message.Body}
+		if err := s.digestRepo.Queue(item, delay); err != nil {
+			log.Printf("Failed to hold %s notification for user %d: %v", channel, userID, err)
+			lastErr = err
+			continue
+		}
+		queued++
+	}
+
+	log.Printf("%s %s notification for signal ID %d: %d sent, %d held", channel, notification.Event, notification.Signal.ID, sent, queued)
+	if sent == 0 && queued == 0 {
+		return lastErr
+	}
+	return nil
+}
+
+// digestKey identifies the digest of a user on a channel
+type digestKey struct {
+	userID  int64
+	channel models.NotificationChannel
+}
+
+// SendDigests delivers the due held notifications of up to limit users and
+// channels, as one message per user and channel. Digests that fail are retried
+// once their lease runs out. It returns the number of digests processed.
+func (s *UserNotificationService) SendDigests(limit int) (int, error) {
+	items, err := s.digestRepo.ClaimDue(limit, digestLease)
+	if err != nil {
+		return 0, err
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	var keys []digestKey
+	digests := make(map[digestKey][]models.NotificationDigestItem)
+	var userIDs []int64
+	for _, item := range items {
+		key := digestKey{userID: item.UserID, channel: item.Channel}
+		if _, ok := digests[key]; !ok {
+			keys = append(keys, key)
+			userIDs = append(userIDs, item.UserID)
+		}
+		digests[key] = append(digests[key], item)
+	}
+
+	recipients, err := s.preferenceRepo.GetRecipientsByIDs(userIDs)
+	if err != nil {
+		return 0, err
+	}
+	recipientsByID := make(map[int64]models.NotificationRecipient, len(recipients))
+	for _, recipient := range recipients {
+		recipientsByID[recipient.UserID] = recipient
+	}
+
+	var done []int64
+	for _, key := range keys {
+		userChannel, channelOK := s.channels[key.channel]
+		recipient, recipientOK := recipientsByID[key.userID]
+		// Digests of blocked users and of channels no longer configured are dropped
+		if channelOK && recipientOK {
+			if err := userChannel.Deliver([]models.NotificationRecipient{recipient}, digestMessage(digests[key])); err != nil {
+				log.Printf("Failed to send %s digest to user %d: %v", key.channel, key.userID, err)
+				continue
+			}
+		}
+		for _, item := range digests[key] {
+			done = append(done, item.ID)
+		}
+	}
+
+	if err := s.digestRepo.Delete(done); err != nil {
+		return 0, err
+	}
+	return len(keys), nil
+}
+
+// digestMessage combines held notifications into a single message
+func digestMessage(items []models.NotificationDigestItem) *UserMessage {
+	if len(items) == 1 {
+		return &UserMessage{Title: items[0].Title, Body: items[0].Body}
+	}
+
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = item.Title + "\n" + item.Body
+	}
+	return &UserMessage{
+		Title: fmt.Sprintf("📬 %d signal notifications", len(items)),
+		Body:  strings.Join(parts, "\n\n"),
+	}
+}
+
+// userMessage formats a notification as a plain text personal message
+func userMessage(notification *models.SignalNotification) (*UserMessage, error) {
+	signal := notification.Signal
+	message := &UserMessage{
+		Data: map[string]interface{}{
+			"signal_id": signal.ID,
+			"event":     notification.Event,
+		},
+	}
+
+	switch notification.Event {
+	case models.NotificationEventCreated:
+		message.Title = fmt.Sprintf("🚨 New %s %s Signal!", signal.AssetClass, signal.DurationType)
+		message.Body = fmt.Sprintf("%s %s · %d targets · Risk/Reward %s", signal.Symbol, signal.Type, len(signal.Targets), formatRiskReward(signal))
+
+	case models.NotificationEventUpdated:
+		var lines []string
+		if update := notification.Update; update != nil {
+			message.Title = fmt.Sprintf("📝 Update on %s %s", signal.Symbol, signal.Type)
+			lines = append([]string{update.Message}, formatUpdateActions(update, true)...)
+		} else {
+			message.Title = fmt.Sprintf("✏️ %s %s signal updated", signal.Symbol, signal.Type)
+			lines = formatChanges(notification.Changes, true)
+			if notification.Reason != "" {
+				lines = append([]string{notification.Reason}, lines...)
+			}
+		}
+		message.Body = strings.Join(lines, "\n")
+
+	case models.NotificationEventTargetHit:
+		if notification.Target == nil {
+			return nil, fmt.Errorf("target_hit notification for signal %d has no target", signal.ID)
+		}
+		message.Title = fmt.Sprintf("🎯 TP%d reached on %s!", notification.Target.Position, signal.Symbol)
+		message.Body = fmt.Sprintf("%s %s · Gain %s", signal.Symbol, signal.Type, formatTargetGain(signal, notification.Target))
+
+	case models.NotificationEventClosed:
+		message.Title = fmt.Sprintf("🏁 %s %s signal %s", signal.Symbol, signal.Type, formatClosedTitle(signal))
+		message.Body = fmt.Sprintf("Exit %s · Result %s", formatExitPrice(signal), formatResult(signal))
+
+	case models.NotificationEventCancelled:
+		message.Title = fmt.Sprintf("🚫 %s %s signal cancelled", signal.Symbol, signal.Type)
+		message.Body = "Do not enter this trade."
+		if notification.Reason != "" {
+			message.Body += " " + notification.Reason
+		}
+
+	case models.NotificationEventExpired:
+		message.Title = fmt.Sprintf("⌛ %s %s signal expired", signal.Symbol, signal.Type)
+		message.Body = fmt.Sprintf("Entry %s was not reached in time, the setup is no longer valid.", formatPrice(signal.EntryPrice))
+
+	default:
+		return nil, fmt.Errorf("unknown notification event %q", notification.Event)
+	}
+
+	return message, nil
+}
+
+// emailChannel delivers personal notifications by email
+type emailChannel struct {
+	emailService *EmailService
+}
+
+// Deliver emails the message to every recipient, logging the ones that fail. It
+// reports the last failure when no email could be sent.
+func (c *emailChannel) Deliver(recipients []models.NotificationRecipient, message *UserMessage) error {
+	failed := 0
+	var lastErr error
+	for _, recipient := range recipients {
+		if err := c.emailService.SendSignalAlert(recipient.Email, recipient.Name, message.Title, message.Body); err != nil {
+			log.Printf("Failed to email user %d: %v", recipient.UserID, err)
+			failed++
+			lastErr = err
+		}
+	}
+	if failed > 0 && failed == len(recipients) {
+		return fmt.Errorf("failed to email %d recipients: %w", failed, lastErr)
+	}
+	return nil
+}
+
+// telegramChannel delivers personal notifications as direct messages from the
+// bot to the chats users linked
+type telegramChannel struct {
+	client   *TelegramClient
+	linkRepo *repositories.TelegramLinkRepository
+}
+
+// Deliver messages the linked chat of every recipient, logging the ones that
+// fail. It reports the last failure when no message could be sent. Recipients
+// without a linked chat are skipped, and users whose chat blocked the bot have
+// Telegram messages turned off. Their chat stays linked, so they keep access to
+// their private groups.
+func (c *telegramChannel) Deliver(recipients []models.NotificationRecipient, message *UserMessage) error {
+	userIDs := make([]int64, len(recipients))
+	for i, recipient := range recipients {
+		userIDs[i] = recipient.UserID
+	}
+
+	links, err := c.linkRepo.GetByUserIDs(userIDs)
+	if err != nil {
+		return err
+	}
+
+	text := message.Title + "\n\n" + message.Body
+	failed := 0
+	var lastErr error
+	var unavailable []int64
+	for _, link := range links {
+		if err := c.client.SendMessage(strconv.FormatInt(link.ChatID, 10), text, ""); err != nil {
+			if errors.Is(err, ErrTelegramChatUnavailable) {
+				unavailable = append(unavailable, link.UserID)
+				continue
+			}
+			log.Printf("Failed to message the Telegram chat of user %d: %v", link.UserID, err)
+			failed++
+			lastErr = err
+		}
+	}
+
+	if len(unavailable) > 0 {
+		if _, err := c.linkRepo.DisableMessages(unavailable); err != nil {
+			log.Printf("Failed to turn off messages to unavailable Telegram chats: %v", err)
+		} else {
+			log.Printf("Turned off messages to %d Telegram chats that blocked the bot", len(unavailable))
+		}
+	}
+
+	if failed > 0 && failed+len(unavailable) == len(links) {
+		return fmt.Errorf("failed to message %d Telegram chats: %w", failed, lastErr)
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package stats
+
+import (
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+)
+
+// Equity builds the cumulative return series of the closed signals in daily or
+// weekly buckets. A drawdown still open at the end of the series is measured
+// up to end.
+func Equity(signals []models.TradingSignal, interval string, end time.Time) models.EquityCurve {
+	curve := models.EquityCurve{
+		Interval:       interval,
+		Points:         []models.EquityPoint{},
+		MonthlyReturns: []models.MonthlyReturn{},
+	}
+
+	closed := closedSignals(signals)
+	curve.Trades = len(closed)
+	if len(closed) == 0 {
+		return curve
+	}
+
+	var cumulative, peak float64
+	peakAt := closeTime(&closed[0])
+	var underwaterSince *time.Time
+	var longestUnderwater time.Duration
+
+	var point *models.EquityPoint
+	var month *models.MonthlyReturn
+	for i := range closed {
+		signal := &closed[i]
+		ret := *signal.Return
+		closedAt := closeTime(signal).UTC()
+
+		cumulative += ret
+		if cumulative >= peak {
+			if underwaterSince != nil {
+				if duration := closedAt.Sub(*underwaterSince); duration > longestUnderwater {
+					longestUnderwater = duration
+				}
+				underwaterSince = nil
+			}
+			peak, peakAt = cumulative, closedAt
+		} else {
+			if underwaterSince == nil {
+				since := peakAt
+				underwaterSince = &since
+			}
+			if drawdown := peak - cumulative; drawdown > curve.MaxDrawdown {
+				curve.MaxDrawdown = drawdown
+				peakTime, troughTime := peakAt, closedAt
+				curve.MaxDrawdownPeak, curve.MaxDrawdownTrough = &peakTime, &troughTime
+			}
+		}
+
+		point = equityBucket(&curve, point, bucketStart(closedAt, interval), interval)
+		point.Return += ret
+		point.Trades++
+		point.CumulativeReturn = cumulative
+		point.Drawdown = peak - cumulative
+
+		month = monthBucket(&curve, month, closedAt)
+		month.Return += ret
+		month.Trades++
+		if models.ResultForReturn(ret) == models.SignalResultWin {
+			month.Wins++
+		}
+	}
+
+	if underwaterSince != nil {
+		if duration := end.Sub(*underwaterSince); duration > longestUnderwater {
+			longestUnderwater = duration
+		}
+	}
+
+	curve.TotalReturn = round(cumulative)
+	curve.MaxDrawdown = round(curve.MaxDrawdown)
+	curve.MaxDrawdownDays = round(longestUnderwater.Hours() / 24)
+	curve.CurrentDrawdown = round(peak - cumulative)
+	for i := range curve.Points {
+		curve.Points[i].Return = round(curve.Points[i].Return)
+		curve.Points[i].CumulativeReturn = round(curve.Points[i].CumulativeReturn)
+		curve.Points[i].Drawdown = round(curve.Points[i].Drawdown)
+	}
+	for i := range curve.MonthlyReturns {
+		curve.MonthlyReturns[i].Return = round(curve.MonthlyReturns[i].Return)
+	}
+
+	return curve
+}
+
+// equityBucket returns the point for the bucket starting at start, appending it
+// and any empty buckets since the current point so the series has no gaps
+func equityBucket(curve *models.EquityCurve, current *models.EquityPoint, start time.Time, interval string) *models.EquityPoint {
+	if current != nil && current.Date.Equal(start) {
+		return current
+	}
+	if current != nil {
+		for next := nextBucket(current.Date, interval); next.Before(start); next = nextBucket(next, interval) {
+			curve.Points = append(curve.Points, models.EquityPoint{
+				Date:             next,
+				CumulativeReturn: current.CumulativeReturn,
+				Drawdown:         current.Drawdown,
+			})
+		}
+	}
+	curve.Points = append(curve.Points, models.EquityPoint{Date: start})
+	return &curve.Points[len(curve.Points)-1]
+}
+
+// monthBucket returns the monthly return for the month of at, appending empty
+// months since the current one
+func monthBucket(curve *models.EquityCurve, current *models.MonthlyReturn, at time.Time) *models.MonthlyReturn {
+	start := time.Date(at.Year(), at.Month(), 1, 0, 0, 0, 0, time.UTC)
+	key := start.Format("2006-01")
+	if current != nil && current.Month == key {
+		return current
+	}
+	if current != nil {
+		previous, _ := time.Parse("2006-01", current.Month)
+		for next := previous.AddDate(0, 1, 0); next.Before(start); next = next.AddDate(0, 1, 0) {
+			curve.MonthlyReturns = append(curve.MonthlyReturns, models.MonthlyReturn{Month: next.Format("2006-01")})
+		}
+	}
+	curve.MonthlyReturns = append(curve.MonthlyReturns, models.MonthlyReturn{Month: key})
+	return &curve.MonthlyReturns[len(curve.MonthlyReturns)-1]
+}
+
+// bucketStart returns the start of the UTC day, or of the week beginning Monday, containing at
+func bucketStart(at time.Time, interval string) time.Time {
+	day := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, time.UTC)
+	if interval != models.EquityIntervalWeekly {
+		return day
+	}
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+func nextBucket(start time.Time, interval string) time.Time {
+	if interval == models.EquityIntervalWeekly {
+		return start.AddDate(0, 0, 7)
+	}
+	return start.AddDate(0, 0, 1)
+}
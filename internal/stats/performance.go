@@ -0,0 +1,139 @@
+package stats
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/omarshah0/rest-api-with-social-auth/internal/models"
+	"github.com/omarshah0/rest-api-with-social-auth/internal/risk"
+)
+
+// Performance computes the track record of a set of signals. Signals without a
+// result count towards the total only.
+func Performance(signals []models.TradingSignal) models.PerformanceStats {
+	var performance models.PerformanceStats
+	performance.TotalSignals = len(signals)
+
+	closed := closedSignals(signals)
+	performance.ClosedSignals = len(closed)
+	if len(closed) == 0 {
+		return performance
+	}
+
+	var totalReturn, grossProfit, grossLoss, totalR, totalRiskReward float64
+	var consecutiveLosses int
+	for i := range closed {
+		signal := &closed[i]
+		ret := *signal.Return
+		totalReturn += ret
+
+		switch models.ResultForReturn(ret) {
+		case models.SignalResultWin:
+			performance.Wins++
+			grossProfit += ret
+			consecutiveLosses = 0
+		case models.SignalResultLoss:
+			performance.Losses++
+			grossLoss -= ret
+			consecutiveLosses++
+			if consecutiveLosses > performance.MaxConsecutiveLosses {
+				performance.MaxConsecutiveLosses = consecutiveLosses
+			}
+		default:
+			performance.Breakevens++
+			consecutiveLosses = 0
+		}
+
+		position := signal.RiskPosition()
+		if riskPercent := risk.PercentDistance(position.Entry, position.StopLoss); riskPercent > 0 {
+			totalR += ret / riskPercent
+		}
+		totalRiskReward += position.RiskReward()
+
+		if performance.BestTrade == nil || ret > performance.BestTrade.Return {
+			performance.BestTrade = tradeSummary(signal)
+		}
+		if performance.WorstTrade == nil || ret < performance.WorstTrade.Return {
+			performance.WorstTrade = tradeSummary(signal)
+		}
+	}
+
+	count := float64(len(closed))
+	performance.WinRate = round(float64(performance.Wins) / count * 100)
+	performance.AverageReturn = round(totalReturn / count)
+	performance.Expectancy = round(totalR / count)
+	performance.AverageRiskReward = round(totalRiskReward / count)
+	if grossLoss > 0 {
+		profitFactor := round(grossProfit / grossLoss)
+		performance.ProfitFactor = &profitFactor
+	}
+
+	return performance
+}
+
+// PerformanceByGroup computes performance for each asset class and duration
+// combination that has signals, ordered by asset class then duration
+func PerformanceByGroup(signals []models.TradingSignal) []models.PerformanceStats {
+	type groupKey struct {
+		assetClass   models.AssetClass
+		durationType models.DurationType
+	}
+
+	groups := make(map[groupKey][]models.TradingSignal)
+	for _, signal := range signals {
+		key := groupKey{signal.AssetClass, signal.DurationType}
+		groups[key] = append(groups[key], signal)
+	}
+
+	result := make([]models.PerformanceStats, 0, len(groups))
+	for key, groupSignals := range groups {
+		performance := Performance(groupSignals)
+		performance.AssetClass = key.assetClass
+		performance.DurationType = key.durationType
+		result = append(result, performance)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].AssetClass != result[j].AssetClass {
+			return result[i].AssetClass < result[j].AssetClass
+		}
+		return result[i].DurationType < result[j].DurationType
+	})
+
+	return result
+}
+
+// closedSignals returns the signals that have a return, in the order they closed
+func closedSignals(signals []models.TradingSignal) []models.TradingSignal {
+	var closed []models.TradingSignal
+	for _, signal := range signals {
+		if signal.Return != nil {
+			closed = append(closed, signal)
+		}
+	}
+	sort.SliceStable(closed, func(i, j int) bool {
+		return closeTime(&closed[i]).Before(closeTime(&closed[j]))
+	})
+	return closed
+}
+
+// closeTime returns when a signal closed. Signals closed before lifecycle
+// tracking have no close time and fall back to their last update.
+func closeTime(signal *models.TradingSignal) time.Time {
+	if signal.ClosedAt != nil {
+		return *signal.ClosedAt
+	}
+	return signal.UpdatedAt
+}
+
+func tradeSummary(signal *models.TradingSignal) *models.TradeSummary {
+	return &models.TradeSummary{
+		SignalID: signal.ID,
+		Symbol:   signal.Symbol,
+		Return:   *signal.Return,
+	}
+}
+
+func round(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,24 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned when no object is stored under a key
+var ErrNotFound = errors.New("object not found")
+
+// Storage is the interface implemented by file stores. Keys are slash-separated
+// relative paths such as "signals/42/chart.png". The local filesystem store is
+// the default; S3-compatible stores plug in behind the same interface.
+type Storage interface {
+	// Put stores the contents of r under key, replacing any existing object
+	Put(ctx context.Context, key string, r io.Reader, contentType string) error
+	// Open returns the contents stored under key, or ErrNotFound
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object stored under key. Missing objects are not an error.
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix removes every object whose key starts with prefix + "/"
+	DeletePrefix(ctx context.Context, prefix string) error
+}
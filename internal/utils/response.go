@@ -2,6 +2,7 @@ package utils
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 )
 
@@ -22,8 +23,9 @@ type ErrorResponse struct {
 
 // ErrorDetails contains error information
 type ErrorDetails struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
+	Code    int          `json:"code"`
+	Message string       `json:"message"`
+	Fields  []FieldError `json:"fields,omitempty"`
 }
 
 // SendSuccess sends a successful JSON response
@@ -58,6 +60,30 @@ func SendError(w http.ResponseWriter, statusCode int, errorType string, message
 	json.NewEncoder(w).Encode(response)
 }
 
+// SendValidationError sends a 400 validation error response. When err carries
+// ValidationErrors each invalid field is listed in the response.
+func SendValidationError(w http.ResponseWriter, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusBadRequest)
+
+	details := ErrorDetails{
+		Code:    http.StatusBadRequest,
+		Message: err.Error(),
+	}
+	var fieldErrs ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		details.Fields = fieldErrs
+	}
+
+	response := ErrorResponse{
+		Status: "error",
+		Type:   ErrorTypeValidation,
+		Error:  details,
+	}
+
+	json.NewEncoder(w).Encode(response)
+}
+
 // Common error type constants
 const (
 	ErrorTypeBadRequest          = "bad_request"
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
@@ -13,19 +14,91 @@ func init() {
 	validate = validator.New()
 }
 
-// ValidateStruct validates a struct and returns formatted error messages
+// FieldError describes why a single request field failed validation
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors is returned when one or more request fields are invalid
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, fieldErr := range e {
+		messages[i] = fieldErr.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// ValidateStruct validates a struct and returns ValidationErrors describing each invalid field
 func ValidateStruct(s interface{}) error {
 	err := validate.Struct(s)
 	if err == nil {
 		return nil
 	}
 
-	var errorMessages []string
+	var errs ValidationErrors
 	for _, err := range err.(validator.ValidationErrors) {
-		errorMessages = append(errorMessages, formatValidationError(err))
+		errs = append(errs, FieldError{
+			Field:   jsonFieldPath(reflect.TypeOf(s), err.StructNamespace()),
+			Message: formatValidationError(err),
+		})
+	}
+
+	return errs
+}
+
+// jsonFieldPath converts the namespace of a field of root, e.g.
+// "TradingSignalCreate.Targets[1].Price", to its path in the request body, e.g.
+// "targets[1].price". Fields of embedded structs are flattened like in JSON.
+func jsonFieldPath(root reflect.Type, namespace string) string {
+	segments := strings.Split(namespace, ".")[1:]
+	current := root
+	var path []string
+
+	for _, segment := range segments {
+		name, index := segment, ""
+		if i := strings.Index(segment, "["); i >= 0 {
+			name, index = segment[:i], segment[i:]
+		}
+
+		for current != nil && current.Kind() == reflect.Pointer {
+			current = current.Elem()
+		}
+		var field reflect.StructField
+		found := false
+		if current != nil && current.Kind() == reflect.Struct {
+			field, found = current.FieldByName(name)
+		}
+		if !found {
+			path = append(path, strings.ToLower(segment))
+			current = nil
+			continue
+		}
+
+		jsonName := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		switch {
+		case field.Anonymous && jsonName == "":
+			// Embedded struct fields appear at the level of their parent
+		case jsonName == "" || jsonName == "-":
+			path = append(path, strings.ToLower(name)+index)
+		default:
+			path = append(path, jsonName+index)
+		}
+
+		current = field.Type
+		for range strings.Count(index, "[") {
+			for current.Kind() == reflect.Pointer {
+				current = current.Elem()
+			}
+			if current.Kind() == reflect.Slice || current.Kind() == reflect.Array || current.Kind() == reflect.Map {
+				current = current.Elem()
+			}
+		}
 	}
 
-	return fmt.Errorf(strings.Join(errorMessages, "; "))
+	return strings.Join(path, ".")
 }
 
 // formatValidationError formats a validation error into a human-readable message
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_trading_signal_status_history_signal_id;
+DROP TABLE IF EXISTS trading_signal_status_history;
+
+DROP INDEX IF EXISTS idx_trading_signals_status;
+
+ALTER TABLE trading_signals
+DROP COLUMN IF EXISTS closed_at,
+DROP COLUMN IF EXISTS activated_at,
+DROP COLUMN IF EXISTS exit_price,
+DROP COLUMN IF EXISTS status;
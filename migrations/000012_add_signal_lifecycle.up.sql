@@ -0,0 +1,40 @@
+-- Add lifecycle columns to trading_signals
+ALTER TABLE trading_signals
+ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'ACTIVE', 'TP_HIT', 'SL_HIT', 'CLOSED_MANUALLY', 'CANCELLED', 'EXPIRED')),
+ADD COLUMN exit_price DECIMAL(20, 8),
+ADD COLUMN activated_at TIMESTAMP,
+ADD COLUMN closed_at TIMESTAMP;
+
+-- Existing signals were live trades; map their manually set result onto a status
+UPDATE trading_signals
+SET status = CASE result
+        WHEN 'WIN' THEN 'TP_HIT'
+        WHEN 'LOSS' THEN 'SL_HIT'
+        WHEN 'BREAKEVEN' THEN 'CLOSED_MANUALLY'
+        ELSE 'ACTIVE'
+    END,
+    activated_at = created_at,
+    closed_at = CASE WHEN result IS NOT NULL THEN updated_at END;
+
+CREATE INDEX idx_trading_signals_status ON trading_signals(status);
+
+-- Every status change of a signal, including its initial status
+CREATE TABLE IF NOT EXISTS trading_signal_status_history (
+    id SERIAL PRIMARY KEY,
+    signal_id INTEGER NOT NULL REFERENCES trading_signals(id) ON DELETE CASCADE,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    price DECIMAL(20, 8),
+    note TEXT,
+    changed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_trading_signal_status_history_signal_id ON trading_signal_status_history(signal_id, created_at);
+
+-- Seed history for existing signals
+INSERT INTO trading_signal_status_history (signal_id, from_status, to_status, changed_by, created_at)
+SELECT id, NULL, 'ACTIVE', created_by, created_at FROM trading_signals;
+
+INSERT INTO trading_signal_status_history (signal_id, from_status, to_status, changed_by, created_at)
+SELECT id, 'ACTIVE', status, created_by, closed_at FROM trading_signals WHERE status <> 'ACTIVE';
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_trading_signal_targets_signal_id;
+
+DROP TABLE IF EXISTS trading_signal_targets;
@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS trading_signal_targets (
+    id SERIAL PRIMARY KEY,
+    signal_id INTEGER NOT NULL REFERENCES trading_signals(id) ON DELETE CASCADE,
+    position INTEGER NOT NULL CHECK (position > 0),
+    price DECIMAL(20, 8) NOT NULL CHECK (price > 0),
+    allocation_percent DECIMAL(5, 2) NOT NULL CHECK (allocation_percent > 0 AND allocation_percent <= 100),
+    hit_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(signal_id, position)
+);
+
+CREATE INDEX idx_trading_signal_targets_signal_id ON trading_signal_targets(signal_id);
+
+-- Existing signals get their single take profit as TP1 holding the full position
+INSERT INTO trading_signal_targets (signal_id, position, price, allocation_percent, hit_at)
+SELECT id, 1, take_profit_price, 100, CASE WHEN status = 'TP_HIT' THEN closed_at END
+FROM trading_signals;
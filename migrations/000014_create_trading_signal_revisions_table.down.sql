@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS trading_signal_revisions_immutable ON trading_signal_revisions;
+DROP FUNCTION IF EXISTS prevent_trading_signal_revision_changes();
+DROP TABLE IF EXISTS trading_signal_revisions;
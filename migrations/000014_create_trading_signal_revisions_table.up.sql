@@ -0,0 +1,30 @@
+-- Immutable record of every edit made to a trading signal. signal_id has no
+-- foreign key so the audit trail outlives a deleted signal.
+CREATE TABLE IF NOT EXISTS trading_signal_revisions (
+    id SERIAL PRIMARY KEY,
+    signal_id INTEGER NOT NULL,
+    revision INTEGER NOT NULL,
+    changes JSONB NOT NULL,
+    reason TEXT NOT NULL,
+    edited_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (signal_id, revision)
+);
+
+-- Revisions can never be changed once written; only the editor is cleared when
+-- their user is deleted
+CREATE OR REPLACE FUNCTION prevent_trading_signal_revision_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF ROW(NEW.id, NEW.signal_id, NEW.revision, NEW.changes, NEW.reason, NEW.created_at)
+        IS DISTINCT FROM ROW(OLD.id, OLD.signal_id, OLD.revision, OLD.changes, OLD.reason, OLD.created_at)
+        OR NEW.edited_by IS NOT NULL AND NEW.edited_by IS DISTINCT FROM OLD.edited_by THEN
+        RAISE EXCEPTION 'trading signal revisions are immutable';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trading_signal_revisions_immutable
+BEFORE UPDATE ON trading_signal_revisions
+FOR EACH ROW EXECUTE FUNCTION prevent_trading_signal_revision_changes();
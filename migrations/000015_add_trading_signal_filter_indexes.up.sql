@@ -0,0 +1,3 @@
+-- Support case-insensitive symbol prefix filters and sorting by return
+CREATE INDEX idx_trading_signals_symbol_prefix ON trading_signals(UPPER(symbol) text_pattern_ops);
+CREATE INDEX idx_trading_signals_return ON trading_signals(return DESC NULLS LAST, id DESC);
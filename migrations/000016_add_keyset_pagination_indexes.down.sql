@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_user_subscriptions_user_created_at_id;
+DROP INDEX IF EXISTS idx_payment_history_user_created_at_id;
+DROP INDEX IF EXISTS idx_trading_signals_created_at_id;
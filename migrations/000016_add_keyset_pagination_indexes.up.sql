@@ -0,0 +1,4 @@
+-- Keyset pagination orders lists by (created_at, id)
+CREATE INDEX idx_trading_signals_created_at_id ON trading_signals(created_at DESC, id DESC);
+CREATE INDEX idx_payment_history_user_created_at_id ON payment_history(user_id, created_at DESC, id DESC);
+CREATE INDEX idx_user_subscriptions_user_created_at_id ON user_subscriptions(user_id, created_at DESC, id DESC);
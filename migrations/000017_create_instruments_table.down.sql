@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_instruments_is_active;
+DROP INDEX IF EXISTS idx_instruments_symbol;
+
+DROP TABLE IF EXISTS instruments;
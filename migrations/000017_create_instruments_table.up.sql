@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS instruments (
+    id SERIAL PRIMARY KEY,
+    symbol VARCHAR(50) NOT NULL,
+    asset_class VARCHAR(20) NOT NULL CHECK (asset_class IN ('FOREX', 'CRYPTO', 'PSX')),
+    display_name VARCHAR(100) NOT NULL,
+    tick_size DECIMAL(20, 10) NOT NULL CHECK (tick_size > 0),
+    pip_size DECIMAL(20, 10) CHECK (pip_size > 0),
+    lot_size DECIMAL(20, 8) NOT NULL DEFAULT 1 CHECK (lot_size > 0),
+    quote_currency VARCHAR(10) NOT NULL,
+    sector VARCHAR(100),
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE(asset_class, symbol)
+);
+
+-- Create indexes
+CREATE INDEX idx_instruments_symbol ON instruments(symbol);
+CREATE INDEX idx_instruments_is_active ON instruments(is_active);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_trading_signals_publish_due;
+
+ALTER TABLE trading_signals
+DROP COLUMN IF EXISTS published_at,
+DROP COLUMN IF EXISTS publish_at;
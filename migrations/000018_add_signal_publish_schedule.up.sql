@@ -0,0 +1,10 @@
+-- Signals with a future publish_at stay hidden until the scheduler sets published_at
+ALTER TABLE trading_signals
+ADD COLUMN publish_at TIMESTAMP,
+ADD COLUMN published_at TIMESTAMP;
+
+-- Existing signals were published when they were created
+UPDATE trading_signals SET published_at = created_at;
+
+-- Lets the scheduler find due signals without scanning published ones
+CREATE INDEX idx_trading_signals_publish_due ON trading_signals(publish_at) WHERE published_at IS NULL;
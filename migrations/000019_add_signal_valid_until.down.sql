@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_trading_signals_valid_until;
+
+ALTER TABLE trading_signals
+DROP COLUMN IF EXISTS valid_until;
@@ -0,0 +1,5 @@
+-- Pending signals whose entry is not reached by valid_until are expired automatically
+ALTER TABLE trading_signals
+ADD COLUMN valid_until TIMESTAMP;
+
+CREATE INDEX idx_trading_signals_valid_until ON trading_signals(valid_until) WHERE status = 'PENDING';
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS signal_tier_releases;
+
+-- Fails while packages of several tiers share an asset class, duration and billing cycle
+ALTER TABLE packages
+DROP CONSTRAINT IF EXISTS packages_asset_class_duration_type_billing_cycle_tier_key;
+
+ALTER TABLE packages
+ADD CONSTRAINT packages_asset_class_duration_type_billing_cycle_key UNIQUE (asset_class, duration_type, billing_cycle);
+
+ALTER TABLE packages
+DROP COLUMN IF EXISTS tier;
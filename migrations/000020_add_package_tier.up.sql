@@ -0,0 +1,21 @@
+-- Package tiers: higher tiers see new signals before lower ones
+ALTER TABLE packages
+ADD COLUMN tier VARCHAR(20) NOT NULL DEFAULT 'STANDARD' CHECK (tier IN ('VIP', 'STANDARD'));
+
+-- The same asset class, duration and billing cycle can now be sold in every tier
+ALTER TABLE packages
+DROP CONSTRAINT IF EXISTS packages_asset_class_duration_type_billing_cycle_key;
+
+ALTER TABLE packages
+ADD CONSTRAINT packages_asset_class_duration_type_billing_cycle_tier_key UNIQUE (asset_class, duration_type, billing_cycle, tier);
+
+-- New signal notifications for tiers released after publication, sent by the signal publisher
+CREATE TABLE IF NOT EXISTS signal_tier_releases (
+    signal_id INTEGER NOT NULL REFERENCES trading_signals(id) ON DELETE CASCADE,
+    tier VARCHAR(20) NOT NULL CHECK (tier IN ('VIP', 'STANDARD')),
+    release_at TIMESTAMP NOT NULL,
+    notified_at TIMESTAMP,
+    PRIMARY KEY (signal_id, tier)
+);
+
+CREATE INDEX idx_signal_tier_releases_due ON signal_tier_releases(release_at) WHERE notified_at IS NULL;
@@ -0,0 +1,16 @@
+-- Chart images attached to trading signals; the files live in attachment storage
+CREATE TABLE IF NOT EXISTS signal_attachments (
+    id SERIAL PRIMARY KEY,
+    signal_id INTEGER NOT NULL REFERENCES trading_signals(id) ON DELETE CASCADE,
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes > 0),
+    width INTEGER NOT NULL CHECK (width > 0),
+    height INTEGER NOT NULL CHECK (height > 0),
+    storage_key VARCHAR(255) NOT NULL UNIQUE,
+    thumbnail_key VARCHAR(255) NOT NULL UNIQUE,
+    uploaded_by INTEGER NOT NULL REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_signal_attachments_signal_id ON signal_attachments(signal_id);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS signal_updates_append_only ON signal_updates;
+DROP FUNCTION IF EXISTS prevent_signal_update_changes();
+DROP TABLE IF EXISTS signal_updates;
@@ -0,0 +1,31 @@
+-- Analyst updates posted on a signal's timeline, with the actions they applied
+CREATE TABLE IF NOT EXISTS signal_updates (
+    id SERIAL PRIMARY KEY,
+    signal_id INTEGER NOT NULL REFERENCES trading_signals(id) ON DELETE CASCADE,
+    message TEXT NOT NULL,
+    new_stop_loss DECIMAL(20, 8) CHECK (new_stop_loss > 0),
+    close_percent DECIMAL(5, 2) CHECK (close_percent > 0 AND close_percent < 100),
+    close_price DECIMAL(20, 8) CHECK (close_price > 0),
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((close_percent IS NULL) = (close_price IS NULL))
+);
+
+CREATE INDEX idx_signal_updates_signal_id ON signal_updates(signal_id, created_at);
+
+-- Updates are append-only; only the author is cleared when their user is deleted
+CREATE OR REPLACE FUNCTION prevent_signal_update_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF ROW(NEW.id, NEW.signal_id, NEW.message, NEW.new_stop_loss, NEW.close_percent, NEW.close_price, NEW.created_at)
+        IS DISTINCT FROM ROW(OLD.id, OLD.signal_id, OLD.message, OLD.new_stop_loss, OLD.close_percent, OLD.close_price, OLD.created_at)
+        OR NEW.created_by IS NOT NULL AND NEW.created_by IS DISTINCT FROM OLD.created_by THEN
+        RAISE EXCEPTION 'signal updates are append-only';
+    END IF;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER signal_updates_append_only
+BEFORE UPDATE ON signal_updates
+FOR EACH ROW EXECUTE FUNCTION prevent_signal_update_changes();
@@ -0,0 +1,33 @@
+-- Notifications waiting to be fanned out to the channels of their audience. Rows are
+-- written in the same transaction as the change they announce. signal_id has no
+-- foreign key so withdrawn signals can still be announced.
+CREATE TABLE IF NOT EXISTS notification_outbox (
+    id BIGSERIAL PRIMARY KEY,
+    signal_id INTEGER NOT NULL,
+    event VARCHAR(20) NOT NULL,
+    tier VARCHAR(20) CHECK (tier IN ('VIP', 'STANDARD')), -- NULL for every channel
+    payload JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    dispatched_at TIMESTAMP
+);
+
+CREATE INDEX idx_notification_outbox_undispatched ON notification_outbox(id) WHERE dispatched_at IS NULL;
+
+-- Delivery of a notification through a single channel, retried with backoff until
+-- it is sent or dead-lettered
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    outbox_id BIGINT NOT NULL REFERENCES notification_outbox(id) ON DELETE CASCADE,
+    channel VARCHAR(50) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'PENDING' CHECK (status IN ('PENDING', 'SENT', 'DEAD')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    sent_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (outbox_id, channel)
+);
+
+CREATE INDEX idx_notification_deliveries_due ON notification_deliveries(next_attempt_at) WHERE status = 'PENDING';
+CREATE INDEX idx_notification_deliveries_status ON notification_deliveries(status, id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS expo_push_tickets;
+DROP TABLE IF EXISTS expo_push_tokens;
@@ -0,0 +1,20 @@
+-- Expo push tokens of the mobile app, one per user and device
+CREATE TABLE IF NOT EXISTS expo_push_tokens (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device_id VARCHAR(255) NOT NULL,
+    token VARCHAR(255) NOT NULL UNIQUE,
+    platform VARCHAR(20) CHECK (platform IN ('ios', 'android')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, device_id)
+);
+
+-- Push tickets waiting for their receipt, which tells whether a token is still valid
+CREATE TABLE IF NOT EXISTS expo_push_tickets (
+    id VARCHAR(64) PRIMARY KEY,
+    token VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_expo_push_tickets_created_at ON expo_push_tickets(created_at);
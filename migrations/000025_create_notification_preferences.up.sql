@@ -0,0 +1,28 @@
+-- Personal notification preferences; users without a row get the defaults
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    asset_classes TEXT[] NOT NULL DEFAULT '{}',
+    duration_types TEXT[] NOT NULL DEFAULT '{}',
+    channels TEXT[] NOT NULL DEFAULT '{push}',
+    quiet_hours_start VARCHAR(5),
+    quiet_hours_end VARCHAR(5),
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    mode VARCHAR(10) NOT NULL DEFAULT 'INSTANT' CHECK (mode IN ('INSTANT', 'DIGEST')),
+    digest_time VARCHAR(5) NOT NULL DEFAULT '08:00',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((quiet_hours_start IS NULL) = (quiet_hours_end IS NULL))
+);
+
+-- Personal notifications held back for a digest or until quiet hours end
+CREATE TABLE IF NOT EXISTS notification_digest_items (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    channel VARCHAR(20) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    body TEXT NOT NULL,
+    deliver_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_notification_digest_items_due ON notification_digest_items(deliver_at, user_id, channel);
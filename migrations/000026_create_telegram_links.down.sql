@@ -0,0 +1,3 @@
+ALTER TABLE notification_preferences ALTER COLUMN channels SET DEFAULT '{push}';
+DROP TABLE IF EXISTS telegram_link_tokens;
+DROP TABLE IF EXISTS telegram_links;
@@ -0,0 +1,20 @@
+-- Telegram chats linked to user accounts, which receive signals as direct messages
+CREATE TABLE IF NOT EXISTS telegram_links (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    chat_id BIGINT NOT NULL UNIQUE,
+    username VARCHAR(255),
+    linked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- One-time tokens users start the bot with to link their chat
+CREATE TABLE IF NOT EXISTS telegram_link_tokens (
+    token VARCHAR(64) PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    expires_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_telegram_link_tokens_user_id ON telegram_link_tokens(user_id);
+
+-- Telegram direct messages are on by default; they only reach linked users
+ALTER TABLE notification_preferences ALTER COLUMN channels SET DEFAULT '{push,telegram}';
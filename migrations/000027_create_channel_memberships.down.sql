@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS channel_membership_events;
+DROP TABLE IF EXISTS channel_memberships;
+DROP TABLE IF EXISTS discord_links;
+DROP TABLE IF EXISTS package_channels;
@@ -0,0 +1,52 @@
+-- Private Telegram chats and Discord guild roles the subscribers of a package get access to
+CREATE TABLE IF NOT EXISTS package_channels (
+    id SERIAL PRIMARY KEY,
+    package_id INTEGER NOT NULL REFERENCES packages(id) ON DELETE CASCADE,
+    platform VARCHAR(10) NOT NULL CHECK (platform IN ('TELEGRAM', 'DISCORD')),
+    target_id VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (package_id, platform, target_id)
+);
+
+-- Discord accounts linked to user accounts, whose guild roles follow their subscriptions
+CREATE TABLE IF NOT EXISTS discord_links (
+    user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    discord_user_id VARCHAR(32) NOT NULL UNIQUE,
+    username VARCHAR(255),
+    linked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Access of users to private chats and roles, kept in line with their subscriptions
+CREATE TABLE IF NOT EXISTS channel_memberships (
+    id BIGSERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    platform VARCHAR(10) NOT NULL CHECK (platform IN ('TELEGRAM', 'DISCORD')),
+    target_id VARCHAR(64) NOT NULL,
+    member_id VARCHAR(32) NOT NULL,
+    status VARCHAR(10) NOT NULL CHECK (status IN ('PENDING', 'ACTIVE', 'REMOVED')),
+    invite_link VARCHAR(255),
+    invite_expires_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, platform, target_id)
+);
+
+CREATE INDEX idx_channel_memberships_status ON channel_memberships(status);
+
+-- Audit trail of every membership action; users are kept as IDs so the trail
+-- outlives deleted accounts
+CREATE TABLE IF NOT EXISTS channel_membership_events (
+    id BIGSERIAL PRIMARY KEY,
+    membership_id BIGINT,
+    user_id INTEGER NOT NULL,
+    platform VARCHAR(10) NOT NULL,
+    target_id VARCHAR(64) NOT NULL,
+    action VARCHAR(10) NOT NULL CHECK (action IN ('INVITED', 'GRANTED', 'REMOVED', 'FAILED')),
+    triggered_by VARCHAR(10) NOT NULL CHECK (triggered_by IN ('SCHEDULE', 'ADMIN', 'SUBSCRIBE', 'USER')),
+    detail TEXT,
+    admin_id INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_channel_membership_events_user_id ON channel_membership_events(user_id, created_at DESC);
+CREATE INDEX idx_channel_membership_events_created_at ON channel_membership_events(created_at DESC);
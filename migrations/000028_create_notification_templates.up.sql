@@ -0,0 +1,11 @@
+-- Message templates overriding the built-in Telegram and Discord notifications
+CREATE TABLE IF NOT EXISTS notification_templates (
+    channel VARCHAR(20) NOT NULL CHECK (channel IN ('telegram', 'discord')),
+    event VARCHAR(20) NOT NULL CHECK (event IN ('created', 'updated', 'target_hit', 'closed', 'cancelled', 'expired')),
+    title TEXT,
+    body TEXT NOT NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (channel, event)
+);